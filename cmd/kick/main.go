@@ -10,14 +10,19 @@ import (
 	"github.com/kick-project/kick/internal/di"
 	"github.com/kick-project/kick/internal/resources/errs"
 	"github.com/kick-project/kick/internal/resources/logger"
+	"github.com/kick-project/kick/internal/resources/output"
+	"github.com/kick-project/kick/internal/subcmds/infocmd"
 	"github.com/kick-project/kick/internal/subcmds/initcmd"
 	"github.com/kick-project/kick/internal/subcmds/installcmd"
+	"github.com/kick-project/kick/internal/subcmds/outdatedcmd"
+	"github.com/kick-project/kick/internal/subcmds/projectcmd"
 	"github.com/kick-project/kick/internal/subcmds/removecmd"
 	"github.com/kick-project/kick/internal/subcmds/repocmd"
 	"github.com/kick-project/kick/internal/subcmds/searchcmd"
 	"github.com/kick-project/kick/internal/subcmds/setupcmd"
 	"github.com/kick-project/kick/internal/subcmds/startcmd"
 	"github.com/kick-project/kick/internal/subcmds/updatecmd"
+	"github.com/kick-project/kick/internal/subcmds/upgradecmd"
 )
 
 //nolint
@@ -26,7 +31,15 @@ func main() {
 	loadDotenv()
 	home, err := os.UserHomeDir()
 	errs.FatalF("error: %w", err)
-	inject := di.New(&di.Options{Home: home})
+	args, global := internal.GetOptGlobal(os.Args)
+	_, err = output.New(global.Output)
+	errs.FatalF("error: %w", err)
+	inject := di.New(&di.Options{
+		Home:    home,
+		Offline: global.Offline,
+		Output:  global.Output,
+		Refresh: global.Refresh,
+	})
 	exitHdlr := inject.MakeExitHandler()
 
 	// open log file and close on exit
@@ -46,13 +59,14 @@ func main() {
 		inject.LogLevel(logger.DebugLevel)
 	}
 
-	args := os.Args
 	o := internal.GetOptMain(args)
 	switch {
 	case o.Start:
 		startcmd.Start(args[1:], inject)
 	case o.Search:
 		exitHdlr.Exit(searchcmd.Search(args[1:], inject))
+	case o.Info:
+		exitHdlr.Exit(infocmd.Info(args[1:], inject))
 	case o.Setup:
 		exitHdlr.Exit(setupcmd.SetupCmd(args[1:], inject))
 	case o.Update:
@@ -65,6 +79,12 @@ func main() {
 		exitHdlr.Exit(initcmd.Init(args[1:], inject))
 	case o.Repo:
 		exitHdlr.Exit(repocmd.Repo(args[1:], inject))
+	case o.Outdated:
+		exitHdlr.Exit(outdatedcmd.Outdated(args[1:], inject))
+	case o.Upgrade:
+		exitHdlr.Exit(upgradecmd.Upgrade(args[1:], inject))
+	case o.Project:
+		exitHdlr.Exit(projectcmd.Project(args[1:], inject))
 	}
 	exitHdlr.Exit(255)
 }
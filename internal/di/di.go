@@ -3,11 +3,13 @@
 package di
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	fp "path/filepath"
+	"time"
 
 	"github.com/go-playground/validator"
 	"github.com/kick-project/kick/internal/di/callbacks"
@@ -23,21 +25,25 @@ import (
 	"github.com/kick-project/kick/internal/resources/handle"
 	"github.com/kick-project/kick/internal/resources/logger"
 	"github.com/kick-project/kick/internal/resources/model"
+	"github.com/kick-project/kick/internal/resources/output"
 	"github.com/kick-project/kick/internal/resources/sync"
 	"github.com/kick-project/kick/internal/resources/template"
 	"github.com/kick-project/kick/internal/resources/template/renderer"
 	"github.com/kick-project/kick/internal/resources/template/variables"
 	"github.com/kick-project/kick/internal/resources/templatescan"
 	"github.com/kick-project/kick/internal/resources/vcs"
+	"github.com/kick-project/kick/internal/services/info"
 	"github.com/kick-project/kick/internal/services/initialize"
 	"github.com/kick-project/kick/internal/services/install"
 	"github.com/kick-project/kick/internal/services/list"
+	"github.com/kick-project/kick/internal/services/project"
 	"github.com/kick-project/kick/internal/services/remove"
 	"github.com/kick-project/kick/internal/services/repo"
 	"github.com/kick-project/kick/internal/services/search"
 	"github.com/kick-project/kick/internal/services/setup"
 	"github.com/kick-project/kick/internal/services/start"
 	"github.com/kick-project/kick/internal/services/update"
+	"github.com/kick-project/kick/internal/services/upgrade"
 	_ "github.com/mattn/go-sqlite3" // Driver for database/sql
 	"github.com/olekukonko/tablewriter"
 	"gorm.io/driver/sqlite"
@@ -72,6 +78,19 @@ type DI struct {
 	Stdin            io.Reader
 	Stderr           io.Writer
 	Stdout           io.Writer
+	// Parent context of fetches. Fetches also stop when kick is interrupted
+	Context context.Context
+
+	// Serve templates and repos from local clones only
+	Offline bool
+	// Fetch templates even if they were fetched within FetchTTL
+	Refresh bool
+	// Time a fetched template is fresh for
+	FetchTTL time.Duration
+	// Time limit of each fetch
+	FetchTimeout time.Duration
+	// Output format of listings. One of the output.Formats
+	Output string
 
 	// Cached objects
 	cacheConfigFile  *config.File
@@ -85,26 +104,34 @@ type DI struct {
 	cacheScan        *templatescan.Scan
 	cacheSetup       *setup.Setup
 	cacheHandle      *handle.Handle
+	cacheInfo        *info.Info
 	cacheList        *list.List
 	cacheLogFile     *os.File
+	cacheProject     *project.Project
 	cacheInit        *initialize.Init
 	cacheInstall     *install.Install
 	cacheRemove      *remove.Remove
+	cacheRenderers   *renderer.Registry
 	cacheSearch      *search.Search
 	cacheStart       *start.Start
 	cacheSync        *sync.Sync
 	cacheTemplate    *template.Template
 	cacheUpdate      *update.Update
+	cacheUpgrade     *upgrade.Upgrade
 	cacheVCS         *vcs.VCS
 }
 
 type Options struct {
-	Home     string    // Path to home directory
-	DBPath   string    // SQLite DB path
-	ExitMode int       // Valid values (exit.MNone, exit.MPanic) defaults to exit.MNone
-	Stdin    io.Reader // Stdin injected
-	Stdout   io.Writer // Stdout injected
-	Stderr   io.Writer // Stderr injected
+	Home     string          // Path to home directory
+	DBPath   string          // SQLite DB path
+	ExitMode int             // Valid values (exit.MNone, exit.MPanic) defaults to exit.MNone
+	Offline  bool            // Serve templates and repos from local clones only
+	Refresh  bool            // Fetch templates even if they are fresh
+	Output   string          // Output format of listings. Defaults to output.TABLE
+	Stdin    io.Reader       // Stdin injected
+	Stdout   io.Writer       // Stdout injected
+	Stderr   io.Writer       // Stderr injected
+	Context  context.Context // Parent context of fetches. Defaults to context.Background()
 }
 
 // New get di using the supplied "home" directory option. Any
@@ -152,15 +179,21 @@ func New(opts *Options) *DI {
 		PathTemplateDir:  pathTemplateDir,
 		PathUserConf:     pathUserConf,
 		Stderr:           dfaults.Interface(os.Stderr, opts.Stderr).(io.Writer),
-		Stdin:            dfaults.Interface(os.Stdin, opts.Stdout).(io.Reader),
+		Stdin:            dfaults.Interface(os.Stdin, opts.Stdin).(io.Reader),
 		Stdout:           dfaults.Interface(os.Stdout, opts.Stdout).(io.Writer),
+		Context:          dfaults.Interface(context.Background(), opts.Context).(context.Context),
 		logLevel:         logLvl,
 		ExitMode:         opts.ExitMode,
+		Refresh:          opts.Refresh,
+		Output:           dfaults.String(output.TABLE, opts.Output),
 	}
 	envs := s.MakeEnvs()
 	if envs.Debug() {
 		s.logLevel = logger.DebugLevel
 	}
+	s.Offline = opts.Offline || envs.Offline()
+	s.FetchTTL = envs.FetchTTL()
+	s.FetchTimeout = envs.FetchTimeout()
 	return s
 }
 
@@ -264,6 +297,7 @@ func (s *DI) MakeCheckVars() *checkvars.Check {
 	s.cacheCheckVars = checkvars.New(checkvars.Options{
 		Err:    s.MakeErrorHandler(),
 		Log:    s.MakeLoggerOutput(""),
+		Stdin:  s.Stdin,
 		Stdout: s.Stdout,
 	})
 	return s.cacheCheckVars
@@ -342,7 +376,15 @@ func (s *DI) MakeClient() *client.Client {
 		CallPlumbRepos:     s.CallMakePlumbRepo(),
 		CallPlumbTemplates: s.CallMakePlumbTemplate(),
 		Err:                s.MakeErrorHandler(),
+		IndexDir:           fp.Join(s.PathMetadataDir, "index"),
+		Log:                s.MakeLoggerOutput(""),
+		Offline:            s.Offline,
+		ORM:                s.MakeORM(),
+		Refresh:            s.Refresh,
+		Stderr:             s.Stderr,
 		Stdout:             s.Stdout,
+		Timeout:            s.FetchTimeout,
+		TTL:                s.FetchTTL,
 		VCS:                s.MakeVCS(),
 	}
 	return client.New(opts)
@@ -399,6 +441,7 @@ func (s *DI) MakeInstall() *install.Install {
 	o := &install.Options{
 		Client:     s.MakeClient(),
 		ConfigFile: s.ConfigFile(),
+		Context:    s.Context,
 		ORM:        s.MakeORM(),
 		Exit:       s.MakeExitHandler(),
 		Err:        s.MakeErrorHandler(),
@@ -425,15 +468,33 @@ func (s *DI) MakeHandle() *handle.Handle {
 	return s.cacheHandle
 }
 
+// MakeInfo dependency injector
+func (s *DI) MakeInfo() *info.Info {
+	if s.cacheInfo != nil {
+		return s.cacheInfo
+	}
+	o := &info.Options{
+		Encoder:    s.MakeEncoder(),
+		ErrHandler: s.MakeErrorHandler(),
+		ORM:        s.MakeORM(),
+		Stderr:     s.Stderr,
+		Stdout:     s.Stdout,
+	}
+	s.validate(o)
+	s.cacheInfo = info.New(o)
+	return s.cacheInfo
+}
+
 // MakeList dependency injector
 func (s *DI) MakeList() *list.List {
 	if s.cacheList != nil {
 		return s.cacheList
 	}
 	l := &list.List{
-		Stderr: s.Stderr,
-		Stdout: s.Stdout,
-		Conf:   s.ConfigFile(),
+		Stderr:  s.Stderr,
+		Stdout:  s.Stdout,
+		Conf:    s.ConfigFile(),
+		Encoder: s.MakeEncoder(),
 	}
 	s.validate(l)
 	s.cacheList = l
@@ -466,6 +527,24 @@ func (s *DI) MakeLogFile(logfile string) *os.File {
 	return f
 }
 
+// MakeProject dependency injector
+func (s *DI) MakeProject() *project.Project {
+	if s.cacheProject != nil {
+		return s.cacheProject
+	}
+	o := &project.Options{
+		Conf:     s.ConfigFile(),
+		Encoder:  s.MakeEncoder(),
+		Errs:     s.MakeErrorHandler(),
+		Log:      s.MakeLoggerOutput(""),
+		Stdout:   s.Stdout,
+		Template: s.TemplateOptions(),
+	}
+	s.validate(o)
+	s.cacheProject = project.New(o)
+	return s.cacheProject
+}
+
 // MakeRemove dependency injector
 func (s *DI) MakeRemove() *remove.Remove {
 	if s.cacheRemove != nil {
@@ -487,15 +566,16 @@ func (s *DI) MakeRemove() *remove.Remove {
 // MakeRepo dependency injector
 func (s *DI) MakeRepo() *repo.Repo {
 	o := &repo.Options{
-		Conf:        s.ConfigFile(),
-		Client:      s.MakeClient(),
-		ErrHandler:  s.MakeErrorHandler(),
-		Log:         s.MakeLoggerOutput(""),
-		ORM:         s.MakeORM(),
-		Stdout:      s.Stdout,
-		TableWriter: s.MakeTableWriter(),
-		Valid:       s.MakeValidate(),
-		VCS:         s.MakeVCS(),
+		Conf:       s.ConfigFile(),
+		Client:     s.MakeClient(),
+		ErrHandler: s.MakeErrorHandler(),
+		Log:        s.MakeLoggerOutput(""),
+		ORM:        s.MakeORM(),
+		Stdout:     s.Stdout,
+		Encoder:    s.MakeEncoder(),
+		Template:   s.TemplateOptions(),
+		Valid:      s.MakeValidate(),
+		VCS:        s.MakeVCS(),
 	}
 	r := repo.New(o)
 	return r
@@ -507,8 +587,9 @@ func (s *DI) MakeSearch() *search.Search {
 		return s.cacheSearch
 	}
 	srch := &search.Search{
-		ORM:    s.MakeORM(),
-		Writer: os.Stdout,
+		ORM:     s.MakeORM(),
+		Writer:  os.Stdout,
+		Encoder: s.MakeEncoder(),
 	}
 	s.cacheSearch = srch
 	return srch
@@ -526,6 +607,7 @@ func (s *DI) MakeStart() *start.Start {
 		Conf:      s.ConfigFile(),
 		Exit:      s.MakeExitHandler(),
 		DB:        s.MakeORMInMemory(),
+		Encoder:   s.MakeEncoder(),
 		Handle:    s.MakeHandle(),
 		Scan:      s.MakeScan(),
 		Stderr:    s.Stderr,
@@ -545,6 +627,7 @@ func (s *DI) MakeSync() *sync.Sync {
 		Client:             s.MakeClient(),
 		Config:             s.ConfigFile(),
 		ConfigTemplatePath: s.PathTemplateConf,
+		Context:            s.Context,
 		Log:                s.MakeLoggerOutput(""),
 		ORM:                s.MakeORM(),
 		Stderr:             s.Stderr,
@@ -562,6 +645,16 @@ func (s *DI) MakeTableWriter() *tablewriter.Table {
 	)
 }
 
+// MakeRenderers dependency injector. Renderers registered before MakeTemplate
+// is called are available to templates.
+func (s *DI) MakeRenderers() *renderer.Registry {
+	if s.cacheRenderers != nil {
+		return s.cacheRenderers
+	}
+	s.cacheRenderers = renderer.DefaultRegistry()
+	return s.cacheRenderers
+}
+
 // MakeTemplate dependency injector
 func (s *DI) MakeTemplate() *template.Template {
 	if s.cacheTemplate != nil {
@@ -569,28 +662,33 @@ func (s *DI) MakeTemplate() *template.Template {
 	}
 	vars := variables.New()
 	vars.ProjectVariable("NAME", s.ProjectName)
-	o := &template.Options{
-		Checkvars:     s.MakeCheckVars(),
-		Client:        s.MakeClient(),
-		Config:        s.ConfigFile(),
-		Log:           s.MakeLoggerOutput(""),
-		Errs:          s.MakeErrorHandler(),
-		Exit:          s.MakeExitHandler(),
-		Stderr:        s.Stderr,
-		Stdout:        s.Stdout,
-		TemplateDir:   s.PathTemplateDir,
-		Variables:     vars,
-		RenderCurrent: "envsubst",
-		RenderersAvail: map[string]renderer.Renderer{
-			"texttemplate": &renderer.RenderText{},
-			"envsubst":     &renderer.RenderEnv{},
-		},
-	}
+	o := s.TemplateOptions()
+	o.Variables = vars
 	t := template.New(o)
 	s.cacheTemplate = t
 	return t
 }
 
+// TemplateOptions options of the template made by MakeTemplate, without
+// variables. Services that make their own templates start from these.
+func (s *DI) TemplateOptions() *template.Options {
+	return &template.Options{
+		Checkvars:      s.MakeCheckVars(),
+		Client:         s.MakeClient(),
+		Config:         s.ConfigFile(),
+		Log:            s.MakeLoggerOutput(""),
+		Errs:           s.MakeErrorHandler(),
+		Exit:           s.MakeExitHandler(),
+		Stderr:         s.Stderr,
+		Stdin:          s.Stdin,
+		Stdout:         s.Stdout,
+		TemplateDir:    s.PathTemplateDir,
+		RenderCurrent:  renderer.Default,
+		Scan:           s.MakeScan(),
+		RenderersAvail: s.MakeRenderers(),
+	}
+}
+
 // MakeUpdate dependency injector
 func (s *DI) MakeUpdate() *update.Update {
 	if s.cacheUpdate != nil {
@@ -600,6 +698,7 @@ func (s *DI) MakeUpdate() *update.Update {
 		Client:      s.MakeClient(),
 		Err:         s.MakeErrorHandler(),
 		ConfigFile:  s.ConfigFile(),
+		Context:     s.Context,
 		ORM:         s.MakeORM(),
 		Log:         s.MakeLoggerOutput(""),
 		MetadataDir: s.PathMetadataDir,
@@ -609,6 +708,34 @@ func (s *DI) MakeUpdate() *update.Update {
 	return u
 }
 
+// MakeUpgrade dependency injector
+func (s *DI) MakeUpgrade() *upgrade.Upgrade {
+	if s.cacheUpgrade != nil {
+		return s.cacheUpgrade
+	}
+	o := &upgrade.Options{
+		Client:     s.MakeClient(),
+		Conf:       s.ConfigFile(),
+		Encoder:    s.MakeEncoder(),
+		ErrHandler: s.MakeErrorHandler(),
+		Log:        s.MakeLoggerOutput(""),
+		ORM:        s.MakeORM(),
+		Stdout:     s.Stdout,
+		Sync:       s.MakeSync(),
+		VCS:        s.MakeVCS(),
+	}
+	s.validate(o)
+	s.cacheUpgrade = upgrade.New(o)
+	return s.cacheUpgrade
+}
+
+// MakeEncoder dependency injector
+func (s *DI) MakeEncoder() output.Encoder {
+	enc, err := output.New(s.Output)
+	errs.FatalF("%w", err)
+	return enc
+}
+
 // MakeValidate dependency injector
 func (s *DI) MakeValidate() *validator.Validate {
 	v := validator.New()
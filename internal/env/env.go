@@ -3,7 +3,18 @@
 // only to enable or disable feature flags.
 package env
 
-import "os"
+import (
+	"os"
+	"time"
+)
+
+// DefaultFetchTTL time a fetched template is fresh for when KICK_FETCH_TTL is
+// not set
+const DefaultFetchTTL = time.Hour
+
+// DefaultFetchTimeout time limit of each fetch when KICK_FETCH_TIMEOUT is not
+// set
+const DefaultFetchTimeout = 5 * time.Minute
 
 type Vars struct {
 }
@@ -16,6 +27,21 @@ func (v *Vars) LogFile() string {
 	return os.Getenv("KICK_LOG")
 }
 
+// Offline serve templates and repos from local clones only
+func (v *Vars) Offline() bool {
+	return os.Getenv("KICK_OFFLINE") == "true"
+}
+
+// FetchTTL time a fetched template is fresh for, E.G. 30m. Templates are
+// fetched again once stale. Defaults to DefaultFetchTTL, 0 always fetches.
+func (v *Vars) FetchTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("KICK_FETCH_TTL"))
+	if err != nil {
+		return DefaultFetchTTL
+	}
+	return ttl
+}
+
 //
 // Development
 //
@@ -24,3 +50,13 @@ func (v *Vars) LogFile() string {
 func (v *Vars) Debug() bool {
 	return os.Getenv("KICK_DEBUG") == "true"
 }
+
+// FetchTimeout time limit of each template or repo fetch, E.G. 2m. Defaults to
+// DefaultFetchTimeout, 0 is no limit.
+func (v *Vars) FetchTimeout() time.Duration {
+	timeout, err := time.ParseDuration(os.Getenv("KICK_FETCH_TIMEOUT"))
+	if err != nil {
+		return DefaultFetchTimeout
+	}
+	return timeout
+}
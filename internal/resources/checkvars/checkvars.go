@@ -1,14 +1,18 @@
 package checkvars
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/kick-project/kick/internal/resources/config/configtemplate"
 	"github.com/kick-project/kick/internal/resources/errs"
 	"github.com/kick-project/kick/internal/resources/logger"
+	"github.com/kick-project/kick/internal/resources/template/variables"
 	"gopkg.in/yaml.v2"
 )
 
@@ -16,6 +20,7 @@ import (
 type Check struct {
 	err    errs.HandlerIface
 	log    logger.OutputIface
+	stdin  io.Reader
 	stdout io.Writer
 }
 
@@ -32,8 +37,12 @@ func New(opts Options) *Check {
 	p := &Check{
 		err:    opts.Err,
 		log:    opts.Log,
+		stdin:  opts.Stdin,
 		stdout: opts.Stdout,
 	}
+	if p.stdin == nil {
+		p.stdin = os.Stdin
+	}
 	if p.stdout == nil {
 		p.stdout = os.Stdout
 	}
@@ -41,34 +50,173 @@ func New(opts Options) *Check {
 	return p
 }
 
-// Prompt prompt for input based on requird variables where
+// Check checks for required variables that are not set in the environment
+// and prints them where
 //
 // confin is the ".kick.yml" file represented as an io.Reader
 func (p *Check) Check(confin io.Reader) (bool, error) {
-	buf, err := io.ReadAll(confin)
+	data, err := p.load(confin)
 	if err != nil {
-		return false, fmt.Errorf(`prompt: %w`, err)
+		return false, err
+	}
+	miss := []string{}
+	for _, k := range data.EnvNames() {
+		if os.Getenv(k) == "" {
+			miss = append(miss, k)
+		}
+	}
+	if len(miss) > 0 {
+		p.printMissing(data, miss)
+		return false, nil
 	}
 
-	data := &configtemplate.TemplateMain{}
-	err = yaml.Unmarshal(buf, data)
+	return true, nil
+}
+
+// Prompt prompts on stdin for each required variable that is not set in vars
+// and stores the answers in vars where
+//
+// confin is the ".kick.yml" file represented as an io.Reader
+//
+// Template variables already set in vars, E.G. by "kick start --var", are
+// validated in the same way as answers and an error is returned if any of them
+// is invalid. If stdin is closed before all answers are given, the unanswered
+// variables are printed and false is returned.
+func (p *Check) Prompt(confin io.Reader, vars *variables.Variables) (bool, error) {
+	data, err := p.load(confin)
 	if err != nil {
-		return false, fmt.Errorf(`prompt: %w`, err)
+		return false, err
 	}
-	miss := map[string]string{}
-	for k, v := range data.Envs {
-		if os.Getenv(k) == "" {
-			miss[k] = v
+	if err := validateVars(data, vars); err != nil {
+		return false, err
+	}
+	rdr := bufio.NewReader(p.stdin)
+	miss := []string{}
+	for _, k := range data.EnvNames() {
+		if v, ok := vars.Lookup(k); ok && v != "" {
+			continue
+		}
+		if len(miss) > 0 {
+			miss = append(miss, k)
+			continue
 		}
+		answer, err := p.ask(rdr, k, data.Envs[k])
+		if errors.Is(err, io.EOF) {
+			miss = append(miss, k)
+			continue
+		} else if err != nil {
+			return false, fmt.Errorf(`prompt: %w`, err)
+		}
+		vars.SetVariable(k, answer)
 	}
 	if len(miss) > 0 {
-		fmt.Fprintf(p.stdout, "## Required variables. Add these to \"%s\" file or set as environment variables.\n",
-			filepath.Join(os.Getenv("HOME"), ".env"))
-		for k, v := range miss {
-			fmt.Fprintf(p.stdout, "%s=notset # %s\n", k, v)
-		}
+		fmt.Fprintln(p.stdout)
+		p.printMissing(data, miss)
 		return false, nil
 	}
-
 	return true, nil
 }
+
+// Validate validates the template variables set in vars in the same way as
+// Prompt, without prompting for the variables that are not set, where
+//
+// confin is the ".kick.yml" file represented as an io.Reader
+func (p *Check) Validate(confin io.Reader, vars *variables.Variables) error {
+	data, err := p.load(confin)
+	if err != nil {
+		return err
+	}
+	return validateVars(data, vars)
+}
+
+// validateVars validates the template variables set in vars against their
+// declarations and stores the normalized values. Empty values are left to be
+// prompted for.
+func validateVars(data *configtemplate.TemplateMain, vars *variables.Variables) error {
+	for _, k := range data.EnvNames() {
+		v, ok := vars.Vars[k]
+		if !ok || v == "" {
+			continue
+		}
+		value, err := data.Envs[k].Validate(v)
+		if err != nil {
+			return fmt.Errorf("%s: invalid answer: %w", k, err)
+		}
+		vars.SetVariable(k, value)
+	}
+	return nil
+}
+
+// ask prompts for a single variable until a valid answer is given.
+func (p *Check) ask(rdr *bufio.Reader, name string, env configtemplate.Env) (string, error) {
+	for {
+		fmt.Fprint(p.stdout, question(name, env))
+		line, err := rdr.ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+			return "", err
+		}
+		answer := strings.TrimRight(line, "\r\n")
+		if answer == "" {
+			answer = env.Default
+		}
+		if answer == "" && env.IsBool() {
+			answer = "false"
+		}
+		if answer == "" {
+			fmt.Fprintf(p.stdout, "%s can not be empty\n", name)
+			continue
+		}
+		answer, verr := env.Validate(answer)
+		if verr != nil {
+			fmt.Fprintf(p.stdout, "invalid answer: %v\n", verr)
+			if err != nil {
+				return "", err
+			}
+			continue
+		}
+		return answer, nil
+	}
+}
+
+// question formats the prompt text for a variable.
+func question(name string, env configtemplate.Env) string {
+	q := name
+	if env.Desc != "" {
+		q += " - " + env.Desc
+	}
+	switch {
+	case env.IsBool() && env.Default == "true":
+		q += " [Y/n]"
+	case env.IsBool():
+		q += " [y/N]"
+	case len(env.Choices) > 0 && env.Default != "":
+		q += fmt.Sprintf(" (%s) [%s]", strings.Join(env.Choices, "|"), env.Default)
+	case len(env.Choices) > 0:
+		q += fmt.Sprintf(" (%s)", strings.Join(env.Choices, "|"))
+	case env.Default != "":
+		q += fmt.Sprintf(" [%s]", env.Default)
+	}
+	return q + ": "
+}
+
+func (p *Check) load(confin io.Reader) (*configtemplate.TemplateMain, error) {
+	buf, err := io.ReadAll(confin)
+	if err != nil {
+		return nil, fmt.Errorf(`prompt: %w`, err)
+	}
+
+	data := &configtemplate.TemplateMain{}
+	err = yaml.Unmarshal(buf, data)
+	if err != nil {
+		return nil, fmt.Errorf(`prompt: %w`, err)
+	}
+	return data, nil
+}
+
+func (p *Check) printMissing(data *configtemplate.TemplateMain, miss []string) {
+	fmt.Fprintf(p.stdout, "## Required variables. Add these to \"%s\" file or set as environment variables.\n",
+		filepath.Join(os.Getenv("HOME"), ".env"))
+	for _, k := range miss {
+		fmt.Fprintf(p.stdout, "%s=notset # %s\n", k, data.Envs[k].Desc)
+	}
+}
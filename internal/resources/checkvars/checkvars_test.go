@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/kick-project/kick/internal/di"
+	"github.com/kick-project/kick/internal/resources/template/variables"
 	"github.com/kick-project/kick/internal/resources/testtools"
 	"github.com/stretchr/testify/assert"
 )
@@ -38,3 +39,155 @@ envs:
 	assert.Contains(t, stdout.String(), `PROMPT=notset # prompt for variable`)
 	assert.NotContains(t, stdout.String(), `NOPROMPT`)
 }
+
+func TestCheck_Prompt(t *testing.T) {
+	in := &bytes.Buffer{}
+	stdin := &bytes.Buffer{}
+	stdout := &bytes.Buffer{}
+
+	inject := di.New(&di.Options{
+		Home:   filepath.Join(testtools.TempDir(), "home"),
+		Stdin:  stdin,
+		Stdout: stdout,
+	})
+	c := inject.MakeCheckVars()
+
+	in.WriteString(`---
+name: go
+description: go template
+envs:
+  PROMPT_AUTHOR: project author
+  PROMPT_DOCKER:
+    description: include docker
+    type: bool
+  PROMPT_EMAIL:
+    description: maintainer email
+    regex: '^[^@]+@[^@]+$'
+  PROMPT_LICENSE:
+    description: project license
+    default: MIT
+    choices: [MIT, Apache-2.0]
+`)
+	stdin.WriteString("John Smith\nyes\nnotanemail\njohn@smith.com\nGPL\n\n")
+
+	vars := variables.New()
+	ok, err := c.Prompt(in, vars)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "John Smith", vars.Vars["PROMPT_AUTHOR"])
+	assert.Equal(t, "true", vars.Vars["PROMPT_DOCKER"])
+	assert.Equal(t, "john@smith.com", vars.Vars["PROMPT_EMAIL"])
+	assert.Equal(t, "MIT", vars.Vars["PROMPT_LICENSE"])
+	assert.Contains(t, stdout.String(), `PROMPT_AUTHOR - project author: `)
+	assert.Contains(t, stdout.String(), `PROMPT_DOCKER - include docker [y/N]: `)
+	assert.Contains(t, stdout.String(), `PROMPT_LICENSE - project license (MIT|Apache-2.0) [MIT]: `)
+	assert.Contains(t, stdout.String(), `invalid answer: "notanemail" does not match`)
+	assert.Contains(t, stdout.String(), `invalid answer: "GPL" is not one of MIT, Apache-2.0`)
+}
+
+func TestCheck_Prompt_EOF(t *testing.T) {
+	in := &bytes.Buffer{}
+	stdin := &bytes.Buffer{}
+	stdout := &bytes.Buffer{}
+
+	inject := di.New(&di.Options{
+		Home:   filepath.Join(testtools.TempDir(), "home"),
+		Stdin:  stdin,
+		Stdout: stdout,
+	})
+	c := inject.MakeCheckVars()
+
+	in.WriteString(`---
+name: go
+description: go template
+envs:
+  PROMPT_EOF_A: first variable
+  PROMPT_EOF_B: second variable
+`)
+	stdin.WriteString("a\n")
+
+	vars := variables.New()
+	ok, err := c.Prompt(in, vars)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "a", vars.Vars["PROMPT_EOF_A"])
+	assert.Contains(t, stdout.String(), `PROMPT_EOF_B=notset # second variable`)
+	assert.NotContains(t, stdout.String(), `PROMPT_EOF_A=notset`)
+}
+
+func TestCheck_Prompt_Supplied(t *testing.T) {
+	conf := `---
+name: go
+description: go template
+envs:
+  SUPPLIED_DOCKER:
+    description: include docker
+    type: bool
+  SUPPLIED_LICENSE:
+    description: project license
+    choices: [MIT, Apache-2.0]
+`
+	stdout := &bytes.Buffer{}
+	inject := di.New(&di.Options{
+		Home:   filepath.Join(testtools.TempDir(), "home"),
+		Stdin:  &bytes.Buffer{},
+		Stdout: stdout,
+	})
+	c := inject.MakeCheckVars()
+
+	// Supplied values are normalized
+	vars := variables.New()
+	vars.SetVariable("SUPPLIED_DOCKER", "yes")
+	vars.SetVariable("SUPPLIED_LICENSE", "MIT")
+	ok, err := c.Prompt(bytes.NewBufferString(conf), vars)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "true", vars.Vars["SUPPLIED_DOCKER"])
+
+	// Invalid supplied values are an error
+	vars = variables.New()
+	vars.SetVariable("SUPPLIED_DOCKER", "no")
+	vars.SetVariable("SUPPLIED_LICENSE", "bogus")
+	ok, err = c.Prompt(bytes.NewBufferString(conf), vars)
+	assert.False(t, ok)
+	if assert.Error(t, err) {
+		assert.Equal(t, `SUPPLIED_LICENSE: invalid answer: "bogus" is not one of MIT, Apache-2.0`, err.Error())
+	}
+	assert.Empty(t, stdout.String())
+}
+
+func TestCheck_Validate(t *testing.T) {
+	conf := `---
+name: go
+description: go template
+envs:
+  VALIDATE_DOCKER:
+    description: include docker
+    type: bool
+  VALIDATE_LICENSE:
+    description: project license
+    choices: [MIT, Apache-2.0]
+`
+	stdout := &bytes.Buffer{}
+	inject := di.New(&di.Options{
+		Home:   filepath.Join(testtools.TempDir(), "home"),
+		Stdin:  &bytes.Buffer{},
+		Stdout: stdout,
+	})
+	c := inject.MakeCheckVars()
+
+	// Variables that are not set are left alone
+	vars := variables.New()
+	vars.SetVariable("VALIDATE_DOCKER", "yes")
+	assert.NoError(t, c.Validate(bytes.NewBufferString(conf), vars))
+	assert.Equal(t, "true", vars.Vars["VALIDATE_DOCKER"])
+	_, ok := vars.Lookup("VALIDATE_LICENSE")
+	assert.False(t, ok)
+
+	vars.SetVariable("VALIDATE_LICENSE", "bogus")
+	err := c.Validate(bytes.NewBufferString(conf), vars)
+	if assert.Error(t, err) {
+		assert.Equal(t, `VALIDATE_LICENSE: invalid answer: "bogus" is not one of MIT, Apache-2.0`, err.Error())
+	}
+	assert.Empty(t, stdout.String())
+}
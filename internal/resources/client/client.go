@@ -1,23 +1,47 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/go-playground/validator"
 	"github.com/kick-project/kick/internal/di/callbacks"
 	"github.com/kick-project/kick/internal/resources/client/plumb"
 	"github.com/kick-project/kick/internal/resources/errs"
+	"github.com/kick-project/kick/internal/resources/logger"
+	"github.com/kick-project/kick/internal/resources/model"
 	"github.com/kick-project/kick/internal/resources/vcs"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// DefaultWorkers number of concurrent fetches made by FetchAll when
+// Options.Workers is not set
+const DefaultWorkers = 6
+
 // Client
 type Client struct {
 	err            errs.HandlerIface
+	http           *http.Client
+	indexDir       string
+	log            logger.OutputIface
+	offline        bool
+	orm            *gorm.DB
+	ormMu          *sync.Mutex
 	plumbRepos     callbacks.MakePlumb
 	plumbTemplates callbacks.MakePlumb
+	refresh        bool
+	stderr         io.Writer
 	stdout         io.Writer
+	timeout        time.Duration
+	ttl            time.Duration
 	vcs            *vcs.VCS
+	workers        int
 }
 
 // Options for New function
@@ -25,8 +49,18 @@ type Options struct {
 	CallPlumbRepos     callbacks.MakePlumb `validate:"required"`
 	CallPlumbTemplates callbacks.MakePlumb `validate:"required"`
 	Err                errs.HandlerIface   `validate:"required"`
+	HTTP               *http.Client        // HTTP client used to fetch repo indexes. Defaults to http.DefaultClient
+	IndexDir           string              `validate:"required"` // Cache of repo indexes
+	Log                logger.OutputIface  `validate:"required"`
+	Offline            bool                // Serve templates and repos from local clones only
+	ORM                *gorm.DB            `validate:"-"` // Records fetch times. Templates are always fetched if nil
+	Refresh            bool                // Fetch templates even if they are fresh
+	Stderr             io.Writer           `validate:"required"` // Progress of FetchAll
 	Stdout             io.Writer           `validate:"required"`
+	Timeout            time.Duration       // Time limit of each fetch made by FetchAll. 0 is no limit
+	TTL                time.Duration       // Time a fetched template is fresh for
 	VCS                *vcs.VCS            `validate:"required"`
+	Workers            int                 // Concurrent fetches made by FetchAll. Defaults to DefaultWorkers
 }
 
 // New Client constructor
@@ -36,19 +70,46 @@ func New(opts *Options) *Client {
 	if err != nil {
 		panic(err)
 	}
+	httpClient := opts.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
 	return &Client{
 		err:            opts.Err,
+		http:           httpClient,
+		indexDir:       opts.IndexDir,
+		log:            opts.Log,
+		offline:        opts.Offline,
+		orm:            opts.ORM,
+		ormMu:          &sync.Mutex{},
 		plumbRepos:     opts.CallPlumbRepos,
 		plumbTemplates: opts.CallPlumbTemplates,
+		refresh:        opts.Refresh,
+		stderr:         opts.Stderr,
 		stdout:         opts.Stdout,
+		timeout:        opts.Timeout,
+		ttl:            opts.TTL,
 		vcs:            opts.VCS,
+		workers:        workers,
 	}
 }
 
 // Get get url and clone/sync to path using ref.
 // Defaults to default branch if ref is nil.
 func (c *Client) Get(url, path, ref string) error {
-	repo, err := c.vcs.Clone(url, path)
+	return c.GetContext(context.Background(), url, path, ref)
+}
+
+// GetContext same as Get. The clone or pull is aborted when ctx is done.
+func (c *Client) GetContext(ctx context.Context, url, path, ref string) error {
+	if c.offline {
+		return c.local(url, path, ref)
+	}
+	repo, err := c.vcs.CloneContext(ctx, url, path)
 	if err != nil {
 		return fmt.Errorf("get clone error: %w", err)
 	}
@@ -61,29 +122,131 @@ func (c *Client) Get(url, path, ref string) error {
 
 // GetPlumb same as get but url, path and ref are fetch from plumb.Plumb
 func (c *Client) GetPlumb(p *plumb.Plumb) error {
+	return c.getPlumb(context.Background(), p)
+}
+
+func (c *Client) getPlumb(ctx context.Context, p *plumb.Plumb) error {
 	switch p.Method() {
 	case plumb.NOOP:
 		return nil
 	case plumb.SYNC:
-		return c.Get(p.URL(), p.Path(), p.Ref())
+		return c.GetContext(ctx, p.URL(), p.Path(), p.Ref())
 	}
 	return fmt.Errorf(`Unrecognized  method %d`, p.Method())
 }
 
-// GetTemplate fetch template and store in template store
+// getFresh same as getPlumb but the local clone is used without fetching if
+// it was fetched within the TTL and refresh is not set.
+func (c *Client) getFresh(ctx context.Context, p *plumb.Plumb) error {
+	if p.Method() != plumb.SYNC || c.offline || c.refresh || !c.fresh(p.Path()) {
+		err := c.getPlumb(ctx, p)
+		if err == nil && p.Method() == plumb.SYNC && !c.offline {
+			c.fetched(p.Path())
+		}
+		return err
+	}
+	err := c.local(p.URL(), p.Path(), p.Ref())
+	if err != nil {
+		// The ref may not have been fetched
+		err = c.getPlumb(ctx, p)
+		if err == nil {
+			c.fetched(p.Path())
+		}
+	}
+	return err
+}
+
+// local checks out ref in the local clone of url at path without fetching
+func (c *Client) local(url, path, ref string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("%s has not been downloaded and can not be fetched offline", url)
+	}
+	repo, err := c.vcs.Open(path)
+	if err != nil {
+		return fmt.Errorf("get open error: %w", err)
+	}
+	err = repo.Checkout(ref)
+	if err != nil {
+		return fmt.Errorf("get checkout error: %w", err)
+	}
+	return nil
+}
+
+// fetchKey key of the model.Sync row holding the time path was last fetched
+func fetchKey(path string) string {
+	return "fetch:" + path
+}
+
+// fresh returns true if path was fetched within the TTL
+func (c *Client) fresh(path string) bool {
+	if c.orm == nil || c.ttl <= 0 {
+		return false
+	}
+	c.ormMu.Lock()
+	defer c.ormMu.Unlock()
+	syn := model.Sync{}
+	result := c.orm.Where(&model.Sync{Key: fetchKey(path)}).Limit(1).Find(&syn)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false
+	}
+	return time.Since(syn.LastUpdate) < c.ttl
+}
+
+// fetched records the time path was fetched
+func (c *Client) fetched(path string) {
+	if c.orm == nil {
+		return
+	}
+	c.ormMu.Lock()
+	defer c.ormMu.Unlock()
+	syn := model.Sync{
+		Key:        fetchKey(path),
+		LastUpdate: time.Now(),
+	}
+	result := c.orm.Clauses(clause.Insert{Modifier: "OR REPLACE"}).Create(&syn)
+	c.err.LogF("can not record fetch time of %s: %v", path, result.Error)
+}
+
+// GetTemplate fetch template and store in template store. A template fetched
+// within the TTL is served from the template store.
 func (c *Client) GetTemplate(url, ref string) (*plumb.Plumb, error) {
+	return c.GetTemplateContext(context.Background(), url, ref)
+}
+
+// GetTemplateContext same as GetTemplate. The fetch is aborted when ctx is
+// done.
+func (c *Client) GetTemplateContext(ctx context.Context, url, ref string) (*plumb.Plumb, error) {
 	p, err := c.plumbTemplates(url, ref)
 	if err != nil {
 		return nil, err
 	}
-	return p, c.GetPlumb(p)
+	return p, c.getFresh(ctx, p)
+}
+
+// Head returns the commit checked out at the local path of p. An empty string
+// is returned for local templates that are not synchronized.
+func (c *Client) Head(p *plumb.Plumb) (string, error) {
+	if p.Method() != plumb.SYNC {
+		return "", nil
+	}
+	repo, err := c.vcs.Open(p.Path())
+	if err != nil {
+		return "", err
+	}
+	return repo.Head()
 }
 
-// GetRepo fetch repo and store in repo store
+// GetRepo fetch repo and store in repo store. Repos are fetched on every call
+// unless offline.
 func (c *Client) GetRepo(url, ref string) (*plumb.Plumb, error) {
+	return c.GetRepoContext(context.Background(), url, ref)
+}
+
+// GetRepoContext same as GetRepo. The fetch is aborted when ctx is done.
+func (c *Client) GetRepoContext(ctx context.Context, url, ref string) (*plumb.Plumb, error) {
 	p, err := c.plumbRepos(url, ref)
 	if err != nil {
 		return nil, err
 	}
-	return p, c.GetPlumb(p)
+	return p, c.getPlumb(ctx, p)
 }
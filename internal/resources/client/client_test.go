@@ -1 +1,271 @@
 package client_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/kick-project/kick/internal/di"
+	"github.com/kick-project/kick/internal/resources/client"
+	"github.com/kick-project/kick/internal/resources/testtools"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_GetTemplate_Offline(t *testing.T) {
+	home := filepath.Join(testtools.TempDir(), "TestClient_GetTemplate_Offline")
+	err := os.RemoveAll(home)
+	assert.NoError(t, err)
+	inject := di.New(&di.Options{Home: home, Offline: true})
+	inject.MakeSetup().Init()
+
+	_, err = inject.MakeClient().GetTemplate("http://127.0.0.1:8080/tmpl1.git", "")
+	assert.Error(t, err)
+	assert.Contains(t, fmt.Sprint(err), "can not be fetched offline")
+}
+
+func TestClient_GetTemplate_TTL(t *testing.T) {
+	id := "TestClient_GetTemplate_TTL"
+	src, url := testtools.PushRepo(t, id, fileCommit("first"))
+	home := filepath.Join(testtools.TempDir(), id)
+	err := os.RemoveAll(home)
+	assert.NoError(t, err)
+	inject := di.New(&di.Options{Home: home})
+	inject.FetchTTL = time.Hour
+	inject.MakeSetup().Init()
+
+	c := inject.MakeClient()
+	p, err := c.GetTemplate(url, "")
+	assert.NoError(t, err)
+	first, err := c.Head(p)
+	assert.NoError(t, err)
+
+	testtools.CommitRepo(t, src, fileCommit("second"))
+	repo, err := git.PlainOpen(src)
+	assert.NoError(t, err)
+	err = repo.Push(&git.PushOptions{})
+	assert.NoError(t, err)
+
+	// Fresh. Served from the local clone
+	p, err = c.GetTemplate(url, "")
+	assert.NoError(t, err)
+	head, err := c.Head(p)
+	assert.NoError(t, err)
+	assert.Equal(t, first, head)
+
+	// Offline. Served from the local clone
+	inject.Offline = true
+	p, err = inject.MakeClient().GetTemplate(url, "")
+	assert.NoError(t, err)
+	head, err = inject.MakeClient().Head(p)
+	assert.NoError(t, err)
+	assert.Equal(t, first, head)
+
+	// Refresh. Fetched
+	inject.Offline = false
+	inject.Refresh = true
+	c = inject.MakeClient()
+	p, err = c.GetTemplate(url, "")
+	assert.NoError(t, err)
+	head, err = c.Head(p)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, head)
+}
+
+// fileCommit a commit of file.txt containing msg
+func fileCommit(msg string) testtools.Commit {
+	return testtools.Commit{Message: msg, Files: map[string]string{"file.txt": msg + "\n"}}
+}
+
+func TestClient_FetchAll(t *testing.T) {
+	id := "TestClient_FetchAll"
+	_, url := testtools.PushRepo(t, id, fileCommit("first"))
+	home := filepath.Join(testtools.TempDir(), id)
+	err := os.RemoveAll(home)
+	assert.NoError(t, err)
+	stderr := &bytes.Buffer{}
+	inject := di.New(&di.Options{Home: home, Stderr: stderr})
+	inject.MakeSetup().Init()
+
+	missing := "http://127.0.0.1:8080/" + id + "-missing/repo.git"
+	local := filepath.Join(testtools.FixtureDir(), "gitserve", "tmpl1")
+	fetched := inject.MakeClient().FetchAll(context.Background(), []client.Fetch{
+		{Kind: client.TEMPLATE, URL: url},
+		{Kind: client.TEMPLATE, URL: missing},
+		{Kind: client.TEMPLATE, URL: url},
+		{Kind: client.TEMPLATE, URL: local},
+	})
+	assert.Len(t, fetched, 4)
+	assert.NoError(t, fetched[0].Err)
+	assert.Error(t, fetched[1].Err)
+	assert.NoError(t, fetched[2].Err)
+	assert.NoError(t, fetched[3].Err)
+	assert.Equal(t, fetched[0].Plumb.Path(), fetched[2].Plumb.Path())
+	assert.DirExists(t, filepath.Join(fetched[0].Plumb.Path(), ".git"))
+	assert.Equal(t, local, fetched[3].Plumb.Path())
+
+	out := stderr.String()
+	assert.Contains(t, out, "warning. can not download "+missing)
+	assert.Contains(t, out, "1 of 2 downloads failed")
+}
+
+func TestClient_FetchAll_Refs(t *testing.T) {
+	id := "TestClient_FetchAll_Refs"
+	_, url := testtools.PushRepo(t, id, fileCommit("first"))
+	home := filepath.Join(testtools.TempDir(), id)
+	err := os.RemoveAll(home)
+	assert.NoError(t, err)
+	inject := di.New(&di.Options{Home: home, Stderr: &bytes.Buffer{}})
+	inject.MakeSetup().Init()
+
+	fetched := inject.MakeClient().FetchAll(context.Background(), []client.Fetch{
+		{Kind: client.TEMPLATE, URL: url},
+		{Kind: client.TEMPLATE, URL: url, Ref: "master"},
+		{Kind: client.TEMPLATE, URL: url, Ref: "nosuchref"},
+	})
+	assert.NoError(t, fetched[0].Err)
+	assert.NoError(t, fetched[1].Err)
+	assert.Error(t, fetched[2].Err)
+
+	// Refs share a single clone
+	assert.Equal(t, fetched[0].Plumb.Path(), fetched[1].Plumb.Path())
+	assert.Equal(t, fetched[0].Plumb.Path(), fetched[2].Plumb.Path())
+	clones, err := filepath.Glob(fetched[0].Plumb.Path() + "@*")
+	assert.NoError(t, err)
+	assert.Empty(t, clones)
+}
+
+func TestClient_FetchAll_Canceled(t *testing.T) {
+	home := filepath.Join(testtools.TempDir(), "TestClient_FetchAll_Canceled")
+	err := os.RemoveAll(home)
+	assert.NoError(t, err)
+	inject := di.New(&di.Options{Home: home, Stderr: &bytes.Buffer{}})
+	inject.MakeSetup().Init()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fetched := inject.MakeClient().FetchAll(ctx, []client.Fetch{
+		{Kind: client.REPO, URL: "http://127.0.0.1:8080/TestClient_FetchAll_Canceled.git"},
+	})
+	assert.ErrorIs(t, fetched[0].Err, context.Canceled)
+}
+
+// stalled starts a git server that holds every request until the test ends and
+// returns the URL of a repository on it and a channel that receives each
+// request.
+func stalled(t *testing.T) (string, <-chan struct{}) {
+	started := make(chan struct{}, 8)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(func() {
+		close(release)
+		srv.Close()
+	})
+	return srv.URL + "/stalled.git", started
+}
+
+func TestClient_FetchAll_CanceledInProgress(t *testing.T) {
+	home := filepath.Join(testtools.TempDir(), "TestClient_FetchAll_CanceledInProgress")
+	err := os.RemoveAll(home)
+	assert.NoError(t, err)
+	inject := di.New(&di.Options{Home: home, Stderr: &bytes.Buffer{}})
+	inject.MakeSetup().Init()
+
+	url, started := stalled(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	fetched := inject.MakeClient().FetchAll(ctx, []client.Fetch{{Kind: client.REPO, URL: url}})
+	assert.ErrorIs(t, fetched[0].Err, context.Canceled)
+}
+
+func TestClient_FetchAll_Interrupted(t *testing.T) {
+	home := filepath.Join(testtools.TempDir(), "TestClient_FetchAll_Interrupted")
+	err := os.RemoveAll(home)
+	assert.NoError(t, err)
+	inject := di.New(&di.Options{Home: home, Stderr: &bytes.Buffer{}})
+	inject.MakeSetup().Init()
+
+	url, started := stalled(t)
+	go func() {
+		<-started
+		p, err := os.FindProcess(os.Getpid())
+		assert.NoError(t, err)
+		assert.NoError(t, p.Signal(os.Interrupt))
+	}()
+	fetched := inject.MakeClient().FetchAll(context.Background(), []client.Fetch{{Kind: client.REPO, URL: url}})
+	assert.ErrorIs(t, fetched[0].Err, context.Canceled)
+
+	// The interrupt is no longer caught once the fetch is done
+	assert.False(t, signal.Ignored(os.Interrupt))
+}
+
+func TestIsIndex(t *testing.T) {
+	assert.True(t, client.IsIndex("https://example.com/kick/index.json"))
+	assert.True(t, client.IsIndex("http://example.com/index.yml?v=1"))
+	assert.False(t, client.IsIndex("https://github.com/kick-project/repo.git"))
+	assert.False(t, client.IsIndex("git@github.com:kick-project/index.json"))
+	assert.False(t, client.IsIndex("/tmp/index.json"))
+}
+
+func TestClient_GetIndex(t *testing.T) {
+	requests := 0
+	notModified := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `{"name": "idx", "description": "index repo", "templates": [
+  {"name": "tmpl1", "description": "tmpl1 template", "url": "http://127.0.0.1:8080/tmpl1.git",
+   "versions": [{"version": "7.7.7", "commit": "abc"}]}
+]}`)
+	}))
+	defer srv.Close()
+
+	home := filepath.Join(testtools.TempDir(), "TestClient_GetIndex")
+	err := os.RemoveAll(home)
+	assert.NoError(t, err)
+	inject := di.New(&di.Options{Home: home})
+	inject.MakeSetup().Init()
+	url := srv.URL + "/index.json"
+
+	index, err := inject.MakeClient().GetIndex(context.Background(), url)
+	assert.NoError(t, err)
+	assert.Equal(t, "idx", index.Name)
+	assert.Len(t, index.Templates, 1)
+	assert.Equal(t, "7.7.7", index.Templates[0].Versions[0].Version)
+
+	index, err = inject.MakeClient().GetIndex(context.Background(), url)
+	assert.NoError(t, err)
+	assert.Equal(t, "tmpl1", index.Templates[0].Name)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, 1, notModified)
+
+	inject.Offline = true
+	index, err = inject.MakeClient().GetIndex(context.Background(), url)
+	assert.NoError(t, err)
+	assert.Equal(t, "idx", index.Name)
+	assert.Equal(t, 2, requests)
+
+	_, err = inject.MakeClient().GetIndex(context.Background(), srv.URL+"/other.json")
+	assert.Contains(t, fmt.Sprint(err), "can not be fetched offline")
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/kick-project/kick/internal/resources/client/plumb"
+)
+
+const (
+	// TEMPLATE fetch a template into the template store
+	TEMPLATE = iota + 1
+	// REPO fetch a repo into the repo store
+	REPO
+)
+
+// Fetch a fetch made by FetchAll
+type Fetch struct {
+	Kind int    // TEMPLATE or REPO
+	URL  string // URL or path
+	Ref  string // Branch, tag or commit. Defaults to the default branch
+}
+
+// Fetched the result of a Fetch
+type Fetched struct {
+	Fetch
+	Plumb *plumb.Plumb // Location of the template or repo. nil if the URL is invalid
+	Err   error        // Error fetching
+}
+
+// job fetches sharing a local path
+type job struct {
+	kind    int
+	plumb   *plumb.Plumb
+	indexes []int // Indexes of the fetches
+}
+
+// FetchAll fetches templates and repos using a bounded pool of workers.
+// Fetches with the same local path are made once, whatever their ref. Results are returned in the
+// order of fetches. Progress is written to stderr. Fetches not started when
+// ctx is done, or when kick is interrupted, fail with the error of ctx.
+func (c *Client) FetchAll(ctx context.Context, fetches []Fetch) []Fetched {
+	ctx, stop := interruptible(ctx)
+	defer stop()
+	results := make([]Fetched, len(fetches))
+	jobs := []*job{}
+	byPath := map[string]*job{}
+	for i, f := range fetches {
+		results[i].Fetch = f
+		makePlumb := c.plumbTemplates
+		if f.Kind == REPO {
+			makePlumb = c.plumbRepos
+		}
+		p, err := makePlumb(f.URL, f.Ref)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Plumb = p
+		if p.Method() != plumb.SYNC {
+			continue
+		}
+		key := fmt.Sprintf("%d:%s", f.Kind, p.Path())
+		j, ok := byPath[key]
+		if !ok {
+			j = &job{kind: f.Kind, plumb: p}
+			byPath[key] = j
+			jobs = append(jobs, j)
+		}
+		j.indexes = append(j.indexes, i)
+	}
+	if len(jobs) == 0 {
+		return results
+	}
+
+	urls := make([]string, len(jobs))
+	for n, j := range jobs {
+		urls[n] = j.plumb.URL()
+	}
+	prog := newProgress(c.stderr, c.log, urls)
+	done := func(n int, err error) {
+		for _, i := range jobs[n].indexes {
+			results[i].Err = err
+			// Fetches of another ref share the clone. Check their ref exists
+			if err == nil && results[i].Ref != jobs[n].plumb.Ref() {
+				results[i].Err = c.local(results[i].URL, results[i].Plumb.Path(), results[i].Ref)
+			}
+		}
+		prog.done(n, err)
+	}
+
+	workers := c.workers
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+	ch := make(chan int)
+	wg := &sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range ch {
+				prog.fetching(n)
+				done(n, c.fetch(ctx, jobs[n]))
+			}
+		}()
+	}
+
+dispatch:
+	for n := range jobs {
+		select {
+		case ch <- n:
+		case <-ctx.Done():
+			for m := n; m < len(jobs); m++ {
+				done(m, ctx.Err())
+			}
+			break dispatch
+		}
+	}
+	close(ch)
+	wg.Wait()
+	prog.finish()
+	return results
+}
+
+// interruptible returns a context that is also done when kick is interrupted.
+// The interrupt is only caught until stop is called, or the first interrupt,
+// so that an interrupt at any other time stops kick as usual.
+func interruptible(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
+// fetch fetches j within the time limit
+func (c *Client) fetch(ctx context.Context, j *job) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	if j.kind == REPO {
+		return c.getPlumb(ctx, j.plumb)
+	}
+	return c.getFresh(ctx, j.plumb)
+}
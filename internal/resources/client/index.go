@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-playground/validator"
+	"github.com/kick-project/kick/internal/resources/marshal"
+	"github.com/kick-project/kick/internal/resources/serialize"
+	"gopkg.in/yaml.v2"
+)
+
+// indexMeta cache validators of a downloaded index
+type indexMeta struct {
+	URL          string `yaml:"url"`
+	ETag         string `yaml:"etag,omitempty"`
+	LastModified string `yaml:"last_modified,omitempty"`
+}
+
+// IsIndex returns true if u is the HTTP(S) URL of a JSON or YAML repo index
+// rather than a git repository.
+func IsIndex(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return false
+	}
+	switch strings.ToLower(path.Ext(parsed.Path)) {
+	case ".json", ".yml", ".yaml":
+		return true
+	}
+	return false
+}
+
+// GetIndex fetches the repo index at url. The index is cached and revalidated
+// with ETag and If-Modified-Since. The cached index is used when offline. The
+// request is aborted when ctx is done or kick is interrupted.
+func (c *Client) GetIndex(ctx context.Context, url string) (*serialize.RepoIndex, error) {
+	sum := fmt.Sprintf("%x", sha256.Sum256([]byte(url)))
+	bodyPath := filepath.Join(c.indexDir, sum+".index")
+	metaPath := filepath.Join(c.indexDir, sum+".yml")
+
+	meta := &indexMeta{}
+	if _, err := os.Stat(bodyPath); err == nil {
+		if err := marshal.FromFile(meta, metaPath); err != nil {
+			meta = &indexMeta{}
+		}
+	}
+	if c.offline {
+		if meta.URL == "" {
+			return nil, fmt.Errorf("%s has not been downloaded and can not be fetched offline", url)
+		}
+		return loadIndex(bodyPath, url)
+	}
+
+	ctx, stop := interruptible(ctx)
+	defer stop()
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("index request error: %w", err)
+	}
+	if meta.ETag != "" {
+		req.Header.Set("If-None-Match", meta.ETag)
+	}
+	if meta.LastModified != "" {
+		req.Header.Set("If-Modified-Since", meta.LastModified)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("index request error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && meta.URL != "":
+		return loadIndex(bodyPath, url)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("index request error: %s returned %s", url, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("index read error: %w", err)
+	}
+	index, err := parseIndex(body, url)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache
+	err = os.MkdirAll(c.indexDir, 0755)
+	if err == nil {
+		err = os.WriteFile(bodyPath, body, 0644)
+	}
+	if err == nil {
+		err = marshal.ToFile(&indexMeta{
+			URL:          url,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}, metaPath)
+	}
+	c.err.LogF("can not cache index %s: %v", url, err)
+	return index, nil
+}
+
+func loadIndex(path, url string) (*serialize.RepoIndex, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("index read error: %w", err)
+	}
+	return parseIndex(body, url)
+}
+
+// parseIndex parses a JSON or YAML index
+func parseIndex(body []byte, url string) (*serialize.RepoIndex, error) {
+	index := &serialize.RepoIndex{}
+	// JSON is a subset of YAML
+	err := yaml.Unmarshal(body, index)
+	if err != nil {
+		return nil, fmt.Errorf("can not parse index %s: %w", url, err)
+	}
+	err = validator.New().Struct(index)
+	if err != nil {
+		return nil, fmt.Errorf("invalid index %s: %w", url, err)
+	}
+	return index, nil
+}
@@ -99,7 +99,8 @@ func (p *Plumb) Local(relative string) string {
 	return filepath.Join(p.Path(), filepath.FromSlash(relative))
 }
 
-// localPath determines local path to template
+// localPath determines local path to template. Every ref of a URL shares the
+// same clone. The ref is checked out when the template is fetched.
 func (p *Plumb) localPath(u *parse.URLx) string {
 	if u.Scheme == "file" {
 		return u.Path
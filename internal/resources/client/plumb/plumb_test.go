@@ -1 +1,21 @@
 package plumb_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/kick-project/kick/internal/resources/client/plumb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlumb_Path_Ref(t *testing.T) {
+	p, err := plumb.New("/base", "http://127.0.0.1:8080/tmpl.git", "")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.FromSlash("/base/127.0.0.1/tmpl"), p.Path())
+
+	// Refs share the clone of the URL
+	p, err = plumb.New("/base", "http://127.0.0.1:8080/tmpl.git", "feature/x")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.FromSlash("/base/127.0.0.1/tmpl"), p.Path())
+	assert.Equal(t, "feature/x", p.Ref())
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/kick-project/kick/internal/resources/logger"
+)
+
+// progress displays the progress of FetchAll. On a terminal each URL has a
+// line that is updated in place. Otherwise progress is logged and failures
+// are printed as they happen.
+type progress struct {
+	mu       sync.Mutex
+	out      io.Writer
+	log      logger.OutputIface
+	tty      bool
+	urls     []string
+	status   []string
+	failures []string
+}
+
+func newProgress(out io.Writer, log logger.OutputIface, urls []string) *progress {
+	p := &progress{
+		out:    out,
+		log:    log,
+		tty:    isTerminal(out),
+		urls:   urls,
+		status: make([]string, len(urls)),
+	}
+	for n := range urls {
+		p.status[n] = "waiting"
+		if p.tty {
+			fmt.Fprintln(out, p.line(n))
+		}
+	}
+	return p
+}
+
+func (p *progress) fetching(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.set(n, "fetching")
+	if !p.tty {
+		p.log.Debugf("fetching %s", p.urls[n])
+	}
+}
+
+func (p *progress) done(n int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err == nil {
+		p.set(n, "done")
+		if !p.tty {
+			p.log.Debugf("fetched %s", p.urls[n])
+		}
+		return
+	}
+	p.set(n, "failed")
+	failure := fmt.Sprintf("%s: %v", p.urls[n], err)
+	p.failures = append(p.failures, failure)
+	if !p.tty {
+		fmt.Fprintf(p.out, "warning. can not download %s\n", failure)
+	}
+}
+
+// finish prints a summary of failures
+func (p *progress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.failures) == 0 {
+		return
+	}
+	fmt.Fprintf(p.out, "%d of %d downloads failed\n", len(p.failures), len(p.urls))
+	if !p.tty {
+		return
+	}
+	for _, f := range p.failures {
+		fmt.Fprintf(p.out, "  %s\n", f)
+	}
+}
+
+// set sets the status of line n and redraws it on a terminal
+func (p *progress) set(n int, status string) {
+	p.status[n] = status
+	if !p.tty {
+		return
+	}
+	// Move up to line n, replace it and move back below the last line
+	up := len(p.urls) - n
+	fmt.Fprintf(p.out, "\x1b[%dA\r\x1b[2K%s\x1b[%dB\r", up, p.line(n), up)
+}
+
+func (p *progress) line(n int) string {
+	return fmt.Sprintf("%-9s %s", p.status[n], p.urls[n])
+}
+
+// isTerminal returns true if w is a terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -33,6 +33,7 @@ type Template struct {
 	Template string `yaml:"template"`
 	Origin   string `yaml:"origin"`
 	URL      string `yaml:"url"`
+	Ref      string `yaml:"ref,omitempty"` // Pinned branch, tag or commit SHA
 	Desc     string `yaml:"desc"`
 }
 
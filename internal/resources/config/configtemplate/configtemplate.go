@@ -1,9 +1,319 @@
 package configtemplate
 
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
 // TemplateMain template yaml file stored as `.kick.yml` in the projects root directory
 type TemplateMain struct {
-	Name   string              `yaml:"name" validate:"required,alphanum"`
-	Desc   string              `yaml:"description" validate:"required"`
-	Envs   map[string]string   `yaml:"envs"` // Required environment variables
-	Labels map[string][]string `yaml:"label"`
+	Name       string               `yaml:"name" validate:"required,alphanum"`
+	Desc       string               `yaml:"description" validate:"required"`
+	Envs       map[string]Env       `yaml:"envs"` // Required environment variables
+	Labels     map[string][]string  `yaml:"label"`
+	Includes   []Include            `yaml:"includes"`   // Templates applied as layers before this template
+	Hooks      Hooks                `yaml:"hooks"`      // Commands run when a project is generated
+	Conditions map[string]Condition `yaml:"conditions"` // Variables that include labelled files
+	Ignore     []string             `yaml:"ignore"`     // Paths not part of a project, in gitignore syntax
+	Rules      RenderRules          `yaml:",inline"`    // Paths rendered without a modeline
+	Delims     []string             `yaml:"delims"`     // Left and right delimiters of the renderer, E.G. ["[[", "]]"]
+	Renderer   string               `yaml:"renderer"`   // Renderer of the template. Empty for the default renderer
+	Keywords   []string             `yaml:"keywords"`   // Keywords used to find the template
+	License    string               `yaml:"license"`    // SPDX license identifier of the template, E.G. MIT
+}
+
+// RenderRules paths, in gitignore syntax, that are rendered or copied when a
+// file does not have a modeline. A modeline always takes precedence.
+//
+//	render:
+//	  - "*.json"
+//	  - scripts/
+//	norender:
+//	  - testdata/
+type RenderRules struct {
+	Render   []string `yaml:"render"`
+	NoRender []string `yaml:"norender"`
+}
+
+// Match returns true if path is rendered along with the rule that matched, E.G.
+// "render: *.json". norender takes precedence over render. If no rule matches
+// render is false and rule is empty. path is slash separated and relative to
+// the template root.
+func (r RenderRules) Match(path string, isDir bool) (render bool, rule string) {
+	if p := matchPattern(r.NoRender, path, isDir); p != "" {
+		return false, "norender: " + p
+	}
+	if p := matchPattern(r.Render, path, isDir); p != "" {
+		return true, "render: " + p
+	}
+	return false, ""
+}
+
+// matchPattern returns the last pattern that matches path or an empty string if
+// no pattern matches or path is excluded by a negated pattern.
+func matchPattern(patterns []string, path string, isDir bool) string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return ""
+	}
+	parts := strings.Split(path, "/")
+	matched := ""
+	for _, p := range patterns {
+		switch gitignore.ParsePattern(p, nil).Match(parts, isDir) {
+		case gitignore.Exclude:
+			matched = p
+		case gitignore.Include:
+			matched = ""
+		}
+	}
+	return matched
+}
+
+// Condition maps variable names to the value each variable must have. A
+// condition is met when every variable has its value. In `.kick.yml` labels are
+// mapped to conditions...
+//
+//	conditions:
+//	  docker:
+//	    USE_DOCKER: "true"
+//	  github:
+//	    CI: github
+//
+// Files labelled "docker" are then only generated when USE_DOCKER is true.
+type Condition map[string]string
+
+// Met returns true if every variable returned by lookup has its value.
+func (c Condition) Met(lookup func(name string) (string, bool)) bool {
+	for name, want := range c {
+		value, ok := lookup(name)
+		if !ok || value != want {
+			return false
+		}
+	}
+	return true
+}
+
+// Hooks commands run by a shell when a project is generated. Pre generate hooks
+// run in the build directory after the template is rendered and before the
+// project is moved into place. Post generate hooks run in the project
+// directory.
+//
+//	hooks:
+//	  pre_generate:
+//	    - go mod tidy
+//	  post_generate:
+//	    - git init
+//	    - chmod +x scripts/*
+type Hooks struct {
+	PreGenerate  []string `yaml:"pre_generate"`
+	PostGenerate []string `yaml:"post_generate"`
+}
+
+// EnvNames returns the names of the required variables in sorted order.
+func (t *TemplateMain) EnvNames() []string {
+	names := []string{}
+	for k := range t.Envs {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LabelNames returns the names of the labels given to paths and the labels
+// with conditions in sorted order.
+func (t *TemplateMain) LabelNames() []string {
+	seen := map[string]bool{}
+	names := []string{}
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, labels := range t.Labels {
+		for _, l := range labels {
+			add(l)
+		}
+	}
+	for k := range t.Conditions {
+		add(k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Include a template applied as a layer underneath the including template. In
+// `.kick.yml` an include is either declared as a handle, URL or path relative to
+// the including template...
+//
+//	includes:
+//	  - editorconfig
+//	  - ../common
+//
+// or as a mapping to pin a ref or use a subdirectory of the template...
+//
+//	includes:
+//	  - template: https://github.com/example/fragments.git
+//	    ref: v1.0.0
+//	    path: ci/github
+type Include struct {
+	Template string `yaml:"template"`       // Installed handle, URL or relative path
+	Ref      string `yaml:"ref,omitempty"`  // Branch or tag to checkout
+	Path     string `yaml:"path,omitempty"` // Subdirectory of the template to use
+}
+
+// UnmarshalYAML accepts either a template string or a mapping.
+func (i *Include) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var tmpl string
+	if err := unmarshal(&tmpl); err == nil {
+		*i = Include{Template: tmpl}
+	} else {
+		type plain Include
+		p := plain{}
+		if err := unmarshal(&p); err != nil {
+			return err
+		}
+		*i = Include(p)
+	}
+	if i.Template == "" {
+		return fmt.Errorf("include has no template")
+	}
+	return nil
+}
+
+// MarshalYAML marshals an Include that only holds a template as a string.
+func (i Include) MarshalYAML() (interface{}, error) {
+	if i.Ref == "" && i.Path == "" {
+		return i.Template, nil
+	}
+	type plain Include
+	return plain(i), nil
+}
+
+// Variable types supported by Env.Type
+const (
+	TypeString = "string"
+	TypeBool   = "bool"
+)
+
+// Env a required variable. In `.kick.yml` a variable is either declared as a
+// description...
+//
+//	envs:
+//	  AUTHOR: project author
+//
+// or as a mapping to declare how the answer is typed and validated...
+//
+//	envs:
+//	  LICENSE:
+//	    description: project license
+//	    default: MIT
+//	    choices: [MIT, Apache-2.0]
+//	  EMAIL:
+//	    description: maintainer email
+//	    regex: '^[^@]+@[^@]+$'
+//	  USE_DOCKER:
+//	    description: include docker support
+//	    type: bool
+type Env struct {
+	Desc    string   `yaml:"description"`
+	Default string   `yaml:"default,omitempty"`
+	Choices []string `yaml:"choices,omitempty"`
+	Regex   string   `yaml:"regex,omitempty"`
+	Type    string   `yaml:"type,omitempty"` // "string" (default) or "bool"
+}
+
+// UnmarshalYAML accepts either a description string or a mapping.
+func (e *Env) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var desc string
+	if err := unmarshal(&desc); err == nil {
+		*e = Env{Desc: desc}
+		return nil
+	}
+	type plain Env
+	p := plain{}
+	if err := unmarshal(&p); err != nil {
+		return err
+	}
+	*e = Env(p)
+	switch e.Type {
+	case "", TypeString, TypeBool:
+	default:
+		return fmt.Errorf(`unknown variable type "%s"`, e.Type)
+	}
+	if e.Regex != "" {
+		if _, err := regexp.Compile(e.Regex); err != nil {
+			return fmt.Errorf(`invalid regex "%s": %w`, e.Regex, err)
+		}
+	}
+	return nil
+}
+
+// MarshalYAML marshals an Env that only holds a description as a string.
+func (e Env) MarshalYAML() (interface{}, error) {
+	if e.Default == "" && len(e.Choices) == 0 && e.Regex == "" && e.Type == "" {
+		return e.Desc, nil
+	}
+	type plain Env
+	return plain(e), nil
+}
+
+// IsBool returns true if the variable is a boolean.
+func (e Env) IsBool() bool {
+	return e.Type == TypeBool
+}
+
+// Sample returns a value of the variable name that passes Validate. The
+// default, the first choice, true for booleans or sample-<name> otherwise.
+// Regular expressions are not taken into account.
+func (e Env) Sample(name string) string {
+	switch {
+	case e.Default != "":
+		return e.Default
+	case len(e.Choices) > 0:
+		return e.Choices[0]
+	case e.IsBool():
+		return "true"
+	}
+	return "sample-" + strings.ToLower(name)
+}
+
+// Validate validates value against the declaration and returns the
+// normalized value. Boolean answers are normalized to "true" or "false".
+func (e Env) Validate(value string) (string, error) {
+	if e.IsBool() {
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "y", "yes", "true", "1", "on":
+			return "true", nil
+		case "n", "no", "false", "0", "off":
+			return "false", nil
+		}
+		return "", fmt.Errorf(`"%s" is not a boolean, expected yes or no`, value)
+	}
+	if len(e.Choices) > 0 {
+		found := false
+		for _, c := range e.Choices {
+			if c == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", fmt.Errorf(`"%s" is not one of %s`, value, strings.Join(e.Choices, ", "))
+		}
+	}
+	if e.Regex != "" {
+		re, err := regexp.Compile(e.Regex)
+		if err != nil {
+			return "", fmt.Errorf(`invalid regex "%s": %w`, e.Regex, err)
+		}
+		if !re.MatchString(value) {
+			return "", fmt.Errorf(`"%s" does not match %s`, value, e.Regex)
+		}
+	}
+	return value, nil
 }
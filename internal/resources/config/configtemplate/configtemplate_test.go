@@ -0,0 +1,15 @@
+package configtemplate_test
+
+import (
+	"testing"
+
+	"github.com/kick-project/kick/internal/resources/config/configtemplate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnv_Sample(t *testing.T) {
+	assert.Equal(t, "MIT", configtemplate.Env{Default: "MIT", Choices: []string{"Apache-2.0"}}.Sample("LICENSE"))
+	assert.Equal(t, "Apache-2.0", configtemplate.Env{Choices: []string{"Apache-2.0"}}.Sample("LICENSE"))
+	assert.Equal(t, "true", configtemplate.Env{Type: configtemplate.TypeBool}.Sample("USE_DOCKER"))
+	assert.Equal(t, "sample-author", configtemplate.Env{}.Sample("AUTHOR"))
+}
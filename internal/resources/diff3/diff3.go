@@ -0,0 +1,172 @@
+// Package diff3 merges the changes made to two copies of a file relative to a
+// common base, in the same way as "diff3 -m" and "git merge-file".
+package diff3
+
+import (
+	"strings"
+)
+
+// Conflict markers written around conflicting lines
+const (
+	MarkerOurs   = "<<<<<<<"
+	MarkerSep    = "======="
+	MarkerTheirs = ">>>>>>>"
+)
+
+// Merge performs a three-way merge of the lines of ours and theirs against
+// base. Changes made to only one side are applied. Where both sides change the
+// same lines differently the conflicting lines are written between conflict
+// markers labelled with oursLabel and theirsLabel. conflicts is the number of
+// conflicting hunks.
+func Merge(base, ours, theirs, oursLabel, theirsLabel string) (merged string, conflicts int) {
+	o := splitLines(base)
+	a := splitLines(ours)
+	b := splitLines(theirs)
+	ma := matches(o, a)
+	mb := matches(o, b)
+
+	out := &strings.Builder{}
+	i, x, y := 0, 0, 0
+	for i < len(o) || x < len(a) || y < len(b) {
+		// Stable lines are unchanged on both sides
+		k := 0
+		for i+k < len(o) && ma[i+k] == x+k && mb[i+k] == y+k {
+			k++
+		}
+		if k > 0 {
+			write(out, o[i:i+k])
+			i, x, y = i+k, x+k, y+k
+			continue
+		}
+
+		// Unstable lines end at the next base line that both sides kept
+		end := i
+		for end < len(o) && (ma[end] < 0 || mb[end] < 0) {
+			end++
+		}
+		xe, ye := len(a), len(b)
+		if end < len(o) {
+			xe, ye = ma[end], mb[end]
+		}
+		oc, ac, bc := o[i:end], a[x:xe], b[y:ye]
+		switch {
+		case equal(ac, oc):
+			write(out, bc)
+		case equal(bc, oc), equal(ac, bc):
+			write(out, ac)
+		default:
+			conflicts++
+			writeLine(out, MarkerOurs+" "+oursLabel)
+			write(out, ac)
+			writeLine(out, MarkerSep)
+			write(out, bc)
+			writeLine(out, MarkerTheirs+" "+theirsLabel)
+		}
+		i, x, y = end, xe, ye
+	}
+	return out.String(), conflicts
+}
+
+// splitLines splits s into lines that keep their line endings
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func write(out *strings.Builder, lines []string) {
+	for _, l := range lines {
+		out.WriteString(l)
+	}
+}
+
+// writeLine writes a marker on a line of its own
+func writeLine(out *strings.Builder, line string) {
+	s := out.String()
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		out.WriteString("\n")
+	}
+	out.WriteString(line + "\n")
+}
+
+// matches returns, for each line of a, the index of the matching line in b or
+// -1. Matches are found from a shortest edit script using Myers' algorithm.
+func matches(a, b []string) []int {
+	m := make([]int, len(a))
+	for i := range m {
+		m[i] = -1
+	}
+	n, l := len(a), len(b)
+	max := n + l
+	if max == 0 {
+		return m
+	}
+	offset := max
+	v := make([]int, 2*max+2)
+	trace := [][]int{}
+	found := false
+	for d := 0; d <= max && !found; d++ {
+		snapshot := make([]int, len(v))
+		copy(snapshot, v)
+		trace = append(trace, snapshot)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < l && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= l {
+				found = true
+				break
+			}
+		}
+	}
+
+	// Backtrack through the trace recording diagonal moves as matches
+	x, y := n, l
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			m[x] = y
+		}
+		if d > 0 {
+			x, y = prevX, prevY
+		}
+	}
+	return m
+}
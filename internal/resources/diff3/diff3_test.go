@@ -0,0 +1,85 @@
+package diff3_test
+
+import (
+	"testing"
+
+	"github.com/kick-project/kick/internal/resources/diff3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	base := "one\ntwo\nthree\nfour\nfive\n"
+	tests := []struct {
+		name      string
+		ours      string
+		theirs    string
+		merged    string
+		conflicts int
+	}{
+		{
+			name:   "unchanged",
+			ours:   base,
+			theirs: base,
+			merged: base,
+		},
+		{
+			name:   "ours",
+			ours:   "one\nTWO\nthree\nfour\nfive\n",
+			theirs: base,
+			merged: "one\nTWO\nthree\nfour\nfive\n",
+		},
+		{
+			name:   "theirs",
+			ours:   base,
+			theirs: "one\ntwo\nthree\nfour\nfive\nsix\n",
+			merged: "one\ntwo\nthree\nfour\nfive\nsix\n",
+		},
+		{
+			name:   "both",
+			ours:   "one\nTWO\nthree\nfour\nfive\n",
+			theirs: "one\ntwo\nthree\nFOUR\nfive\n",
+			merged: "one\nTWO\nthree\nFOUR\nfive\n",
+		},
+		{
+			name:   "same change",
+			ours:   "one\ntwo\nTHREE\nfour\nfive\n",
+			theirs: "one\ntwo\nTHREE\nfour\nfive\n",
+			merged: "one\ntwo\nTHREE\nfour\nfive\n",
+		},
+		{
+			name:   "delete",
+			ours:   "one\ntwo\nfour\nfive\n",
+			theirs: "zero\none\ntwo\nthree\nfour\nfive\n",
+			merged: "zero\none\ntwo\nfour\nfive\n",
+		},
+		{
+			name:      "conflict",
+			ours:      "one\ntwo\nmine\nfour\nfive\n",
+			theirs:    "one\ntwo\nyours\nfour\nfive\n",
+			merged:    "one\ntwo\n<<<<<<< ours\nmine\n=======\nyours\n>>>>>>> theirs\nfour\nfive\n",
+			conflicts: 1,
+		},
+		{
+			name:      "no trailing newline",
+			ours:      "one\ntwo\nthree\nfour\nmine",
+			theirs:    "one\ntwo\nthree\nfour\nyours",
+			merged:    "one\ntwo\nthree\nfour\n<<<<<<< ours\nmine\n=======\nyours\n>>>>>>> theirs\n",
+			conflicts: 1,
+		},
+	}
+	for _, tt := range tests {
+		merged, conflicts := diff3.Merge(base, tt.ours, tt.theirs, "ours", "theirs")
+		assert.Equal(t, tt.merged, merged, tt.name)
+		assert.Equal(t, tt.conflicts, conflicts, tt.name)
+	}
+}
+
+func TestMerge_EmptyBase(t *testing.T) {
+	merged, conflicts := diff3.Merge("", "a\n", "b\n", "ours", "theirs")
+	assert.Equal(t, 1, conflicts)
+	assert.Equal(t, "<<<<<<< ours\na\n=======\nb\n>>>>>>> theirs\n", merged)
+
+	merged, conflicts = diff3.Merge("", "a\n", "a\n", "ours", "theirs")
+	assert.Equal(t, 0, conflicts)
+	assert.Equal(t, "a\n", merged)
+}
@@ -0,0 +1,221 @@
+package file
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Conflict policies used by MergeAll when a file already exists in the
+// destination and its content differs from the source.
+const (
+	// ConflictSkip keep the existing file.
+	ConflictSkip = "skip"
+	// ConflictOverwrite replace the existing file.
+	ConflictOverwrite = "overwrite"
+	// ConflictKeepBoth keep the existing file and write the source next to it
+	// with the KeepBothSuffix suffix. See KeepBothPath.
+	ConflictKeepBoth = "keep-both"
+	// ConflictPrompt ask a ConflictFunc for the policy of each conflict.
+	ConflictPrompt = "prompt"
+)
+
+// KeepBothSuffix is appended to the source file name when the conflict policy
+// is ConflictKeepBoth.
+const KeepBothSuffix = ".kick-new"
+
+// ErrInvalidPolicy the conflict policy is not recognized.
+var ErrInvalidPolicy = errors.New("invalid conflict policy")
+
+// ValidPolicy returns an error if policy is not a conflict policy.
+func ValidPolicy(policy string) error {
+	switch policy {
+	case ConflictSkip, ConflictOverwrite, ConflictKeepBoth, ConflictPrompt:
+		return nil
+	}
+	return fmt.Errorf(`%w "%s": valid policies are %s, %s, %s and %s`, ErrInvalidPolicy, policy,
+		ConflictSkip, ConflictOverwrite, ConflictKeepBoth, ConflictPrompt)
+}
+
+// ConflictFunc returns the policy to apply to the conflicting path, which is
+// relative to the destination. It must not return ConflictPrompt.
+type ConflictFunc func(path string) string
+
+// MergeResult paths, relative to the destination, affected by MergeAll.
+type MergeResult struct {
+	Created     []string // Files and directories that did not exist
+	Overwritten []string // Files replaced by the source
+	Skipped     []string // Files that differ but were kept
+	Conflicts   []string // Files that differ, the source written to KeepBothPath
+	Unchanged   []string // Files with the same content as the source
+}
+
+// MergeAll recursively copies src into the existing directory dst and removes
+// src. Files that exist in dst with different content are resolved using
+// policy. prompt is only called when policy is ConflictPrompt.
+func MergeAll(src, dst, policy string, prompt ConflictFunc) (*MergeResult, error) {
+	if err := ValidPolicy(policy); err != nil {
+		return nil, err
+	}
+	if policy == ConflictPrompt && prompt == nil {
+		return nil, fmt.Errorf("merge %s %s: no prompt for conflicts", src, dst)
+	}
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return nil, fmt.Errorf("merge %s %s: %w", src, dst, err)
+	}
+	if !srcInfo.IsDir() {
+		return nil, fmt.Errorf("merge %s %s: source is not a directory", src, dst)
+	}
+	if _, err := os.Stat(dst); errors.Is(err, os.ErrNotExist) {
+		err = MoveAll(src, dst)
+		if err != nil {
+			return nil, err
+		}
+		return &MergeResult{Created: []string{"."}}, nil
+	}
+
+	m := &merger{
+		src:    src,
+		dst:    dst,
+		policy: policy,
+		prompt: prompt,
+		result: &MergeResult{},
+	}
+	err = filepath.Walk(src, m.walk)
+	if err != nil {
+		return m.result, err
+	}
+	return m.result, os.RemoveAll(src)
+}
+
+type merger struct {
+	src    string
+	dst    string
+	policy string
+	prompt ConflictFunc
+	result *MergeResult
+}
+
+func (m *merger) walk(srcPath string, srcInfo os.FileInfo, err error) error {
+	if err != nil {
+		return err
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(srcPath, m.src), string(filepath.Separator))
+	if rel == "" {
+		return nil
+	}
+	dstPath := filepath.Join(m.dst, rel)
+	dstInfo, statErr := os.Lstat(dstPath)
+	exists := statErr == nil
+	if statErr != nil && !errors.Is(statErr, os.ErrNotExist) {
+		return statErr
+	}
+
+	switch {
+	case srcInfo.IsDir() && exists && !dstInfo.IsDir():
+		return fmt.Errorf("merge %s: destination is not a directory", dstPath)
+	case srcInfo.IsDir() && exists:
+		return nil
+	case srcInfo.IsDir():
+		m.result.Created = append(m.result.Created, rel)
+		return walkFunc(srcPath, dstPath, srcPath, srcInfo, nil)
+	case exists && dstInfo.IsDir():
+		return fmt.Errorf("merge %s: destination is a directory", dstPath)
+	case !exists:
+		m.result.Created = append(m.result.Created, rel)
+		return walkFunc(srcPath, dstPath, srcPath, srcInfo, nil)
+	}
+
+	same, err := sameContent(srcPath, dstPath)
+	if err != nil {
+		return err
+	}
+	if same {
+		m.result.Unchanged = append(m.result.Unchanged, rel)
+		return nil
+	}
+
+	policy := m.policy
+	if policy == ConflictPrompt {
+		policy = m.prompt(rel)
+	}
+	switch policy {
+	case ConflictSkip:
+		m.result.Skipped = append(m.result.Skipped, rel)
+		return nil
+	case ConflictOverwrite:
+		m.result.Overwritten = append(m.result.Overwritten, rel)
+		if err := os.Remove(dstPath); err != nil {
+			return err
+		}
+		return walkFunc(srcPath, dstPath, srcPath, srcInfo, nil)
+	case ConflictKeepBoth:
+		m.result.Conflicts = append(m.result.Conflicts, rel)
+		keepPath, err := KeepBothPath(dstPath)
+		if err != nil {
+			return err
+		}
+		return walkFunc(srcPath, keepPath, srcPath, srcInfo, nil)
+	}
+	return fmt.Errorf("merge %s: %w \"%s\"", dstPath, ErrInvalidPolicy, policy)
+}
+
+// KeepBothPath returns the path the source of the conflicting file path is
+// written to by ConflictKeepBoth. path with KeepBothSuffix, followed by the
+// first free number when that file already exists, E.G. "file.txt.kick-new.1".
+func KeepBothPath(path string) (string, error) {
+	keepPath := path + KeepBothSuffix
+	for i := 1; ; i++ {
+		_, err := os.Lstat(keepPath)
+		if errors.Is(err, os.ErrNotExist) {
+			return keepPath, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		keepPath = fmt.Sprintf("%s%s.%d", path, KeepBothSuffix, i)
+	}
+}
+
+// sameContent returns true if both files have the same content or both are
+// symlinks with the same target.
+func sameContent(a, b string) (bool, error) {
+	aInfo, err := os.Lstat(a)
+	if err != nil {
+		return false, err
+	}
+	bInfo, err := os.Lstat(b)
+	if err != nil {
+		return false, err
+	}
+	if aInfo.Mode()&os.ModeSymlink != 0 && bInfo.Mode()&os.ModeSymlink != 0 {
+		aLink, err := os.Readlink(a)
+		if err != nil {
+			return false, err
+		}
+		bLink, err := os.Readlink(b)
+		if err != nil {
+			return false, err
+		}
+		return aLink == bLink, nil
+	}
+	if !aInfo.Mode().IsRegular() || !bInfo.Mode().IsRegular() {
+		return false, nil
+	}
+	if aInfo.Size() != bInfo.Size() {
+		return false, nil
+	}
+	aBytes, err := os.ReadFile(a)
+	if err != nil {
+		return false, err
+	}
+	bBytes, err := os.ReadFile(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(aBytes, bBytes), nil
+}
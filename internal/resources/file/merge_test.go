@@ -0,0 +1,107 @@
+package file_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kick-project/kick/internal/resources/file"
+	"github.com/kick-project/kick/internal/resources/testtools"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeAll(t *testing.T) {
+	for _, tc := range []struct {
+		policy string
+		want   string
+		result file.MergeResult
+	}{
+		{file.ConflictSkip, "existing", file.MergeResult{Created: []string{"dir", "dir/new.txt"}, Skipped: []string{"conflict.txt"}, Unchanged: []string{"same.txt"}}},
+		{file.ConflictOverwrite, "template", file.MergeResult{Created: []string{"dir", "dir/new.txt"}, Overwritten: []string{"conflict.txt"}, Unchanged: []string{"same.txt"}}},
+		{file.ConflictKeepBoth, "existing", file.MergeResult{Created: []string{"dir", "dir/new.txt"}, Conflicts: []string{"conflict.txt"}, Unchanged: []string{"same.txt"}}},
+	} {
+		src, dst := mergeDirs(t)
+		result, err := file.MergeAll(src, dst, tc.policy, nil)
+		assert.NoError(t, err, tc.policy)
+		assert.Equal(t, tc.result, *result, tc.policy)
+		assert.NoDirExists(t, src)
+		assert.FileExists(t, filepath.Join(dst, "dir", "new.txt"))
+		assert.FileExists(t, filepath.Join(dst, "untouched.txt"))
+		b, _ := os.ReadFile(filepath.Join(dst, "conflict.txt"))
+		assert.Equal(t, tc.want, string(b), tc.policy)
+		if tc.policy == file.ConflictKeepBoth {
+			b, _ = os.ReadFile(filepath.Join(dst, "conflict.txt"+file.KeepBothSuffix))
+			assert.Equal(t, "template", string(b))
+		}
+	}
+}
+
+func TestMergeAll_Prompt(t *testing.T) {
+	src, dst := mergeDirs(t)
+	asked := []string{}
+	result, err := file.MergeAll(src, dst, file.ConflictPrompt, func(path string) string {
+		asked = append(asked, path)
+		return file.ConflictOverwrite
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"conflict.txt"}, asked)
+	assert.Equal(t, []string{"conflict.txt"}, result.Overwritten)
+}
+
+func TestMergeAll_KeepBothExisting(t *testing.T) {
+	src, dst := mergeDirs(t)
+	writeFiles(t, dst, map[string]string{"conflict.txt" + file.KeepBothSuffix: "previous"})
+	result, err := file.MergeAll(src, dst, file.ConflictKeepBoth, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"conflict.txt"}, result.Conflicts)
+	b, _ := os.ReadFile(filepath.Join(dst, "conflict.txt"+file.KeepBothSuffix))
+	assert.Equal(t, "previous", string(b))
+	b, _ = os.ReadFile(filepath.Join(dst, "conflict.txt"+file.KeepBothSuffix+".1"))
+	assert.Equal(t, "template", string(b))
+}
+
+func TestMergeAll_DirMode(t *testing.T) {
+	src, dst := mergeDirs(t)
+	err := os.Chmod(filepath.Join(src, "dir"), 0750)
+	assert.NoError(t, err)
+	_, err = file.MergeAll(src, dst, file.ConflictSkip, nil)
+	assert.NoError(t, err)
+	info, err := os.Stat(filepath.Join(dst, "dir"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0750), info.Mode().Perm())
+}
+
+func TestMergeAll_InvalidPolicy(t *testing.T) {
+	src, dst := mergeDirs(t)
+	_, err := file.MergeAll(src, dst, "invalid", nil)
+	assert.ErrorIs(t, err, file.ErrInvalidPolicy)
+}
+
+// mergeDirs creates a source and an existing destination directory.
+func mergeDirs(t *testing.T) (src, dst string) {
+	src, err := os.MkdirTemp(testtools.TempDir(), "TestMergeAll-Source-*")
+	assert.NoError(t, err)
+	dst, err = os.MkdirTemp(testtools.TempDir(), "TestMergeAll-Target-*")
+	assert.NoError(t, err)
+	writeFiles(t, src, map[string]string{
+		"conflict.txt": "template",
+		"same.txt":     "same",
+		"dir/new.txt":  "new",
+	})
+	writeFiles(t, dst, map[string]string{
+		"conflict.txt":  "existing",
+		"same.txt":      "same",
+		"untouched.txt": "untouched",
+	})
+	return src, dst
+}
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for p, content := range files {
+		p = filepath.Join(root, filepath.FromSlash(p))
+		err := os.MkdirAll(filepath.Dir(p), 0755)
+		assert.NoError(t, err)
+		err = os.WriteFile(p, []byte(content), 0644)
+		assert.NoError(t, err)
+	}
+}
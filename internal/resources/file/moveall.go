@@ -63,7 +63,11 @@ func walkFunc(src, dst, srcPath string, srcPathInfo os.FileInfo, err error) erro
 		return err
 	case srcMode.IsDir():
 		err = os.MkdirAll(dstPath, os.ModePerm)
-		return err
+		if err != nil {
+			return err
+		}
+		// Owner keeps write access so the contents can be copied
+		return os.Chmod(dstPath, srcMode.Perm()|0700)
 	case srcMode.IsRegular():
 		_, err := copyFile(srcPath, dstPath)
 		return err
@@ -78,7 +82,8 @@ func walkFunc(src, dst, srcPath string, srcPathInfo os.FileInfo, err error) erro
 	return fmt.Errorf("move %s %s: unsupported file type", srcPath, dstPath)
 }
 
-// copyFile copy src file to dst, returns the number of bytes that were copied.
+// copyFile copy src file to dst preserving its permissions, returns the number
+// of bytes that were copied.
 func copyFile(src, dst string) (int64, error) {
 	sourceFileStat, err := os.Stat(src)
 	if err != nil {
@@ -101,5 +106,8 @@ func copyFile(src, dst string) (int64, error) {
 	}
 	defer destination.Close()
 	nBytes, err := io.Copy(destination, source)
-	return nBytes, err
+	if err != nil {
+		return nBytes, err
+	}
+	return nBytes, destination.Chmod(sourceFileStat.Mode().Perm())
 }
@@ -78,3 +78,29 @@ func TestMoveAll_Recursive(t *testing.T) {
 	assert.DirExists(t, dest)
 	assert.NoFileExists(t, src)
 }
+
+func TestMoveAll_Mode(t *testing.T) {
+	src, err := os.MkdirTemp(testtools.TempDir(), "TestMove-Mode-*")
+	assert.Nil(t, err)
+	err = os.WriteFile(filepath.Join(src, "run.sh"), []byte("#!/bin/sh\n"), 0755)
+	assert.Nil(t, err)
+	err = os.WriteFile(filepath.Join(src, "secret"), []byte("secret"), 0600)
+	assert.Nil(t, err)
+	err = os.Mkdir(filepath.Join(src, "private"), 0750)
+	assert.Nil(t, err)
+
+	dest := filepath.Join(testtools.TempDir(), "TestMove-Mode-Target")
+	os.RemoveAll(dest) // nolint
+	err = file.MoveAll(src, dest)
+	assert.Nil(t, err)
+	for p, want := range map[string]os.FileMode{
+		"run.sh":  0755,
+		"secret":  0600,
+		"private": 0750 | os.ModeDir,
+	} {
+		info, err := os.Stat(filepath.Join(dest, p))
+		if assert.Nil(t, err, p) {
+			assert.Equal(t, want, info.Mode(), p)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+// Package fts maintains the full text search index of templates. The index is
+// an SQLite FTS5 table when SQLite is built with FTS5, using the sqlite_fts5
+// build tag, otherwise it is an FTS4 table. Both are ranked using BM25.
+package fts
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Table name of the index
+const Table = "template_fts"
+
+// column an indexed column and its weight when ranking
+type column struct {
+	name   string
+	weight float64
+}
+
+// columns indexed columns in table order. template_id is not indexed.
+var columns = []column{
+	{"template_id", 0},
+	{"name", 10},
+	{"description", 4},
+	{"repo", 2},
+	{"labels", 3},
+	{"variables", 2},
+	{"versions", 1},
+	{"url", 1},
+}
+
+// BM25 parameters, the same as the FTS5 bm25 function
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Term a term of a query
+type Term struct {
+	Text   string // Word or phrase
+	Phrase bool   // Text is a phrase of words that must appear in order
+	Prefix bool   // Text is the prefix of a word
+}
+
+// Result a template matching a query
+type Result struct {
+	TemplateID uint    // ID of the template
+	Score      float64 // BM25 score. Higher is a better match
+}
+
+// Exists returns true if the index has been built
+func Exists(db *gorm.DB) bool {
+	var count int64
+	db.Raw(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, Table).Scan(&count)
+	return count > 0
+}
+
+// isFTS5 returns true if the index is an FTS5 table
+func isFTS5(db *gorm.DB) bool {
+	var sql string
+	db.Raw(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?`, Table).Scan(&sql)
+	return strings.Contains(strings.ToLower(sql), "fts5")
+}
+
+// Rebuild drops and rebuilds the index from the template, template_meta, repo
+// and versions tables. Keywords are indexed with labels.
+func Rebuild(db *gorm.DB) error {
+	quiet := db.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)})
+	names := []string{}
+	for _, c := range columns {
+		names = append(names, c.name)
+	}
+
+	err := db.Exec(`DROP TABLE IF EXISTS ` + Table).Error
+	if err != nil {
+		return fmt.Errorf("can not drop search index: %w", err)
+	}
+	fts5 := fmt.Sprintf(`CREATE VIRTUAL TABLE %s USING fts5(%s UNINDEXED, %s)`,
+		Table, names[0], strings.Join(names[1:], ", "))
+	if quiet.Exec(fts5).Error != nil {
+		// SQLite built without FTS5
+		fts4 := fmt.Sprintf(`CREATE VIRTUAL TABLE %s USING fts4(%s, notindexed=%s)`,
+			Table, strings.Join(names, ", "), names[0])
+		err = db.Exec(fts4).Error
+		if err != nil {
+			return fmt.Errorf("can not create search index: %w", err)
+		}
+	}
+
+	err = db.Exec(`INSERT INTO ` + Table + ` (` + strings.Join(names, ", ") + `)
+SELECT
+	template.id,
+	template.name,
+	IFNULL(template.desc, ''),
+	IFNULL((SELECT group_concat(repo.name || ' ' || IFNULL(repo.desc, ''), ' ')
+		FROM repo_template JOIN repo ON (repo_template.repo_id = repo.id)
+		WHERE repo_template.template_id = template.id), ''),
+	TRIM(IFNULL(template.labels, '') || ' ' || IFNULL(template_meta.keywords, '')),
+	IFNULL(template.vars, ''),
+	IFNULL((SELECT group_concat(versions.version, ' ')
+		FROM versions WHERE versions.template_id = template.id AND versions.deleted_at IS NULL), ''),
+	template.url
+FROM template LEFT JOIN template_meta ON (template_meta.template_id = template.id)
+WHERE template.deleted_at IS NULL`).Error
+	if err != nil {
+		return fmt.Errorf("can not build search index: %w", err)
+	}
+	return nil
+}
+
+// Match returns the templates matching every term, best match first
+func Match(db *gorm.DB, terms []Term) ([]Result, error) {
+	if isFTS5(db) {
+		return match5(db, terms)
+	}
+	return match4(db, terms)
+}
+
+func match5(db *gorm.DB, terms []Term) ([]Result, error) {
+	weights := []string{}
+	for _, c := range columns {
+		weights = append(weights, fmt.Sprintf("%g", c.weight))
+	}
+	rows, err := db.Raw(fmt.Sprintf(`SELECT template_id, bm25(%s, %s) AS score FROM %s WHERE %s MATCH ? ORDER BY score, template_id`,
+		Table, strings.Join(weights, ", "), Table, Table), expr(terms, true)).Rows()
+	if err != nil {
+		return nil, fmt.Errorf("search error: %w", err)
+	}
+	defer rows.Close()
+	results := []Result{}
+	for rows.Next() {
+		r := Result{}
+		err = rows.Scan(&r.TemplateID, &r.Score)
+		if err != nil {
+			return nil, fmt.Errorf("search error: %w", err)
+		}
+		// bm25 returns better matches as lower negative numbers
+		r.Score = -r.Score
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+func match4(db *gorm.DB, terms []Term) ([]Result, error) {
+	rows, err := db.Raw(fmt.Sprintf(`SELECT template_id, matchinfo(%s, 'pcnalx') FROM %s WHERE %s MATCH ?`,
+		Table, Table, Table), expr(terms, false)).Rows()
+	if err != nil {
+		return nil, fmt.Errorf("search error: %w", err)
+	}
+	defer rows.Close()
+	results := []Result{}
+	for rows.Next() {
+		var (
+			r    Result
+			info []byte
+		)
+		err = rows.Scan(&r.TemplateID, &info)
+		if err != nil {
+			return nil, fmt.Errorf("search error: %w", err)
+		}
+		r.Score = bm25(info)
+		results = append(results, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("search error: %w", err)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].TemplateID < results[j].TemplateID
+	})
+	return results, nil
+}
+
+// bm25 calculates the BM25 score of an FTS4 matchinfo blob in the 'pcnalx'
+// format. See https://www.sqlite.org/fts3.html#matchinfo
+func bm25(info []byte) float64 {
+	v := make([]float64, len(info)/4)
+	for i := range v {
+		v[i] = float64(binary.LittleEndian.Uint32(info[i*4:]))
+	}
+	p, c, n := int(v[0]), int(v[1]), v[2]
+	avg := v[3 : 3+c]
+	length := v[3+c : 3+2*c]
+	x := v[3+2*c:]
+
+	score := 0.0
+	for i := 0; i < p; i++ {
+		for j := 0; j < c && j < len(columns); j++ {
+			w := columns[j].weight
+			tf := x[3*(j+i*c)]
+			docs := x[3*(j+i*c)+2]
+			if w == 0 || tf == 0 {
+				continue
+			}
+			idf := math.Log((n - docs + 0.5) / (docs + 0.5))
+			if idf <= 0 {
+				idf = 1e-6
+			}
+			ratio := 1.0
+			if avg[j] > 0 {
+				ratio = length[j] / avg[j]
+			}
+			score += w * idf * (tf * (k1 + 1)) / (tf + k1*(1-b+b*ratio))
+		}
+	}
+	return score
+}
+
+// expr returns the MATCH expression of terms. Every term must match.
+func expr(terms []Term, fts5 bool) string {
+	parts := []string{}
+	for _, t := range terms {
+		text := strings.ReplaceAll(t.Text, `"`, "")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		switch {
+		case t.Prefix && fts5:
+			parts = append(parts, `"`+text+`"*`)
+		case t.Prefix:
+			parts = append(parts, `"`+text+`*"`)
+		default:
+			parts = append(parts, `"`+text+`"`)
+		}
+	}
+	return strings.Join(parts, " ")
+}
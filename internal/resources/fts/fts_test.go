@@ -0,0 +1,48 @@
+package fts_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kick-project/kick/internal/resources/fts"
+	"github.com/kick-project/kick/internal/resources/model"
+	"github.com/kick-project/kick/internal/resources/testtools"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebuild(t *testing.T) {
+	path := filepath.Join(testtools.TempDir(), "fts_test.db")
+	if _, err := os.Stat(path); err == nil {
+		os.Remove(path)
+	}
+	db := model.CreateModel(&model.Options{File: path})
+	for _, tmpl := range []model.Template{
+		{Name: "gocli", URL: "http://127.0.0.1:8080/gocli.git", Desc: "Go command line application", Labels: "go"},
+		{Name: "goweb", URL: "http://127.0.0.1:8080/goweb.git", Desc: "Go web service using the go standard library"},
+		{Name: "python", URL: "http://127.0.0.1:8080/python.git", Desc: "Python package"},
+	} {
+		assert.NoError(t, db.Create(&tmpl).Error)
+	}
+
+	assert.False(t, fts.Exists(db))
+	assert.NoError(t, fts.Rebuild(db))
+	assert.True(t, fts.Exists(db))
+	// Rebuilding an existing index replaces it
+	assert.NoError(t, fts.Rebuild(db))
+
+	results, err := fts.Match(db, []fts.Term{{Text: "go"}})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, uint(1), results[0].TemplateID)
+		assert.Greater(t, results[0].Score, results[1].Score)
+	}
+
+	results, err = fts.Match(db, []fts.Term{{Text: "py", Prefix: true}})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	results, err = fts.Match(db, []fts.Term{{Text: "standard library", Phrase: true}})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+}
@@ -41,7 +41,7 @@ func (h *Handle) Handle2Path(handle string) (string, error) {
 	if t == nil {
 		return "", ErrNoHandle
 	}
-	p, err := h.plumb(t.URL, "")
+	p, err := h.plumb(t.URL, t.Ref)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,83 @@
+// Package kickignore matches template files that are not part of a generated
+// project. Patterns are read from `.kickignore` in the template root and the
+// `ignore` list in `.kick.yml`, both using gitignore syntax.
+package kickignore
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/kick-project/kick/internal/resources/config/configtemplate"
+	"github.com/kick-project/kick/internal/resources/marshal"
+)
+
+// File name of the ignore file in the template root
+const File = ".kickignore"
+
+// Matcher matches ignored paths
+type Matcher struct {
+	matcher gitignore.Matcher
+}
+
+// New returns a matcher for patterns in gitignore syntax. Empty patterns and
+// comments are skipped.
+func New(patterns []string) *Matcher {
+	ps := []gitignore.Pattern{}
+	for _, p := range patterns {
+		if strings.HasPrefix(p, "#") || strings.TrimSpace(p) == "" {
+			continue
+		}
+		ps = append(ps, gitignore.ParsePattern(p, nil))
+	}
+	return &Matcher{
+		matcher: gitignore.NewMatcher(ps),
+	}
+}
+
+// Load returns a matcher for the template in root. Patterns in `.kickignore`
+// are followed by patterns in the `ignore` list of `.kick.yml`.
+func Load(root string) (*Matcher, error) {
+	patterns := []string{}
+
+	p := filepath.Join(root, File)
+	f, err := os.Open(p)
+	switch {
+	case err == nil:
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			patterns = append(patterns, scanner.Text())
+		}
+		if err = scanner.Err(); err != nil {
+			return nil, fmt.Errorf("can not read %s: %w", p, err)
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("can not open %s: %w", p, err)
+	}
+
+	p = filepath.Join(root, ".kick.yml")
+	if _, err = os.Stat(p); err == nil {
+		conf := &configtemplate.TemplateMain{}
+		err = marshal.FromFile(conf, p)
+		if err != nil {
+			return nil, fmt.Errorf("can not load %s: %w", p, err)
+		}
+		patterns = append(patterns, conf.Ignore...)
+	}
+
+	return New(patterns), nil
+}
+
+// Match returns true if path is ignored. path is slash separated and relative
+// to the template root.
+func (m *Matcher) Match(path string, isDir bool) bool {
+	path = strings.Trim(path, "/")
+	if path == "" || path == "." {
+		return false
+	}
+	return m.matcher.Match(strings.Split(path, "/"), isDir)
+}
@@ -0,0 +1,45 @@
+package kickignore_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/kick-project/kick/internal/resources/kickignore"
+	"github.com/kick-project/kick/internal/resources/testtools"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	m, err := kickignore.Load(filepath.Join(testtools.FixtureDir(), "ignoretemplate"))
+	assert.NoError(t, err)
+	for _, tc := range []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"README.md", false, false},
+		{"AUTHORING.md", false, true},
+		{"sub/AUTHORING.md", false, false},
+		{"logo.bin", false, true},
+		{"build", true, true},
+		{"build/out.txt", false, true},
+		{"notes/a.txt", false, true},
+		{"notes/keep.md", false, false},
+		{".", true, false},
+	} {
+		assert.Equal(t, tc.ignored, m.Match(tc.path, tc.isDir), tc.path)
+	}
+}
+
+func TestLoad_NoFiles(t *testing.T) {
+	m, err := kickignore.Load(filepath.Join(testtools.FixtureDir(), "gotemplate"))
+	assert.NoError(t, err)
+	assert.False(t, m.Match("go.mod", false))
+}
+
+func TestNew(t *testing.T) {
+	m := kickignore.New([]string{"# comment", "", "*.log", "!keep.log"})
+	assert.True(t, m.Match("debug.log", false))
+	assert.False(t, m.Match("keep.log", false))
+	assert.False(t, m.Match("# comment", false))
+}
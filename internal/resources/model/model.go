@@ -32,6 +32,8 @@ type Template struct {
 	Name     string
 	URL      string `gorm:"index:,unique"`
 	Desc     string
+	Labels   string // Space separated labels declared by the template
+	Vars     string // Space separated variables declared by the template
 	Repo     []Repo `gorm:"many2many:repo_template"`
 	Versions []Versions
 }
@@ -57,6 +59,27 @@ type Sync struct {
 	LastUpdate time.Time `gorm:"index;column:lastupdate"`
 }
 
+// TemplateMeta metadata of a template copied from its repository
+type TemplateMeta struct {
+	gorm.Model
+	ID         uint `gorm:"primaryKey;not null"`
+	TemplateID uint `gorm:"index:,unique"`
+	Renderer   string
+	Keywords   string // Space separated keywords declared by the template
+	License    string // SPDX license identifier
+	Readme     string // Excerpt of the README
+}
+
+// Variable a variable declared by a template
+type Variable struct {
+	gorm.Model
+	ID         uint   `gorm:"primaryKey;not null"`
+	TemplateID uint   `gorm:"index:idx_variable_template,unique"`
+	Name       string `gorm:"index:idx_variable_template,unique"`
+	Desc       string
+	Default    string
+}
+
 // Versions template versions
 type Versions struct {
 	gorm.Model
@@ -124,13 +147,7 @@ func CreateModel(opts *Options) (db *gorm.DB) {
 
 	errs.FatalF("Can not initialize an ORM database: %v", err)
 
-	err = db.AutoMigrate(
-		&Repo{},
-		&Versions{},
-		&Template{},
-		&Installed{},
-		&Sync{},
-	)
+	err = Migrate(db)
 	errs.FatalF("can not migrate database: %v", err)
 
 	// Insert base repo
@@ -147,6 +164,19 @@ func CreateModel(opts *Options) (db *gorm.DB) {
 	return db
 }
 
+// Migrate creates or updates the tables of the metadata database
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&Repo{},
+		&Versions{},
+		&Template{},
+		&TemplateMeta{},
+		&Variable{},
+		&Installed{},
+		&Sync{},
+	)
+}
+
 // CreateModelTemporary
 func CreateModelTemporary(opts Options) (db *gorm.DB) {
 	dia := sqlite.Open(opts.File)
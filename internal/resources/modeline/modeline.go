@@ -1,6 +1,10 @@
 package modeline
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/kick-project/kick/internal/resources/file"
 	"github.com/kick-project/kick/internal/resources/modeline/parser"
 )
@@ -11,6 +15,7 @@ func Parse(path string, input any, lines int) (*ModeLine, error) {
 	ml := ModeLine{
 		options_uniq: map[string]any{},
 		labels_uniq:  map[string]any{},
+		values:       map[string]string{},
 	}
 	src, err := file.ReadLines(path, input, lines)
 	if err != nil {
@@ -25,6 +30,10 @@ func Parse(path string, input any, lines int) (*ModeLine, error) {
 				empty = false
 			}
 		}
+		if i.Type == parser.VALUE {
+			ml.values[i.Key] = i.Value
+			empty = false
+		}
 		if i.Type == parser.LABEL {
 			if _, ok := ml.labels_uniq[i.Value]; !ok {
 				ml.labels = append(ml.labels, i.Value)
@@ -36,14 +45,48 @@ func Parse(path string, input any, lines int) (*ModeLine, error) {
 	if empty {
 		return nil, nil
 	}
+	if err := ml.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
 	return &ml, nil
 }
 
+// Lint returns an error if the mode line in input is invalid. Unknown options
+// and illegal characters, which Parse ignores, are reported as well as invalid
+// values. A nil error is returned if input does not have a mode line.
+func Lint(path string, input any, lines int) error {
+	src, err := file.ReadLines(path, input, lines)
+	if err != nil {
+		return err
+	}
+	if err := parser.Lint(path, string(src), lines); err != nil {
+		return err
+	}
+	_, err = Parse(path, src, lines)
+	return err
+}
+
+// validate checks the values of key=value options
+func (m ModeLine) validate() error {
+	if v, ok := m.values["delims"]; ok {
+		if _, _, ok := m.Delims(); !ok {
+			return fmt.Errorf(`modeline error: invalid delims "%s", expected left,right`, v)
+		}
+	}
+	if v, ok := m.values["target"]; ok {
+		if v == "." || v == ".." || strings.ContainsAny(v, `/\`) {
+			return fmt.Errorf(`modeline error: invalid target "%s", expected a file name`, v)
+		}
+	}
+	return nil
+}
+
 type ModeLine struct {
 	options      []string
 	options_uniq map[string]any
 	labels       []string
 	labels_uniq  map[string]any
+	values       map[string]string
 }
 
 func (m ModeLine) GetOptions() []string {
@@ -63,3 +106,46 @@ func (m ModeLine) Label(label string) bool {
 	_, ok := m.labels_uniq[label]
 	return ok
 }
+
+// Value returns the value of a key=value option
+func (m ModeLine) Value(key string) (string, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+// GetValues returns the key=value options in the form "key=value" sorted by
+// key.
+func (m ModeLine) GetValues() []string {
+	out := []string{}
+	for k, v := range m.values {
+		out = append(out, k+"="+v)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Engine returns the renderer selected with the "engine" option, E.G.
+// "kick:render engine=mustache". Returns an empty string if not set.
+func (m ModeLine) Engine() string {
+	return m.values["engine"]
+}
+
+// Delims returns the delimiters set with the "delims" option, E.G.
+// "kick:render delims=[[,]]". ok is false if the option is not set.
+func (m ModeLine) Delims() (left, right string, ok bool) {
+	v, set := m.values["delims"]
+	if !set {
+		return "", "", false
+	}
+	d := strings.Split(v, ",")
+	if len(d) != 2 || d[0] == "" || d[1] == "" {
+		return "", "", false
+	}
+	return d[0], d[1], true
+}
+
+// Target returns the name of the output file set with the "target" option,
+// E.G. "kick:render target=values.yaml". Returns an empty string if not set.
+func (m ModeLine) Target() string {
+	return m.values["target"]
+}
@@ -18,3 +18,52 @@ func TestParser(t *testing.T) {
 	assert.True(t, ml.Label("core"))
 	assert.True(t, ml.Label("editor"))
 }
+
+func TestParser_Value(t *testing.T) {
+	ml, err := modeline.Parse(parseFile, `# kick:render engine=mustache label=ci`, 1)
+	assert.NoError(t, err)
+	assert.True(t, ml.Option("render"))
+	assert.Equal(t, "mustache", ml.Engine())
+	assert.True(t, ml.Label("ci"))
+	v, ok := ml.Value("engine")
+	assert.True(t, ok)
+	assert.Equal(t, "mustache", v)
+}
+
+func TestParser_Options(t *testing.T) {
+	ml, err := modeline.Parse(parseFile, `# kick:render engine=text delims=[[,]] target=name.ext`, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "text", ml.Engine())
+	left, right, ok := ml.Delims()
+	assert.True(t, ok)
+	assert.Equal(t, "[[", left)
+	assert.Equal(t, "]]", right)
+	assert.Equal(t, "name.ext", ml.Target())
+	assert.Equal(t, []string{"delims=[[,]]", "engine=text", "target=name.ext"}, ml.GetValues())
+}
+
+func TestParser_InvalidOptions(t *testing.T) {
+	for text, want := range map[string]string{
+		`# kick:render delims=[[`:          `invalid delims "[["`,
+		`# kick:render target=../name.ext`: `invalid target "../name.ext"`,
+	} {
+		_, err := modeline.Parse(parseFile, text, 1)
+		if assert.Error(t, err, text) {
+			assert.Contains(t, err.Error(), want, text)
+		}
+	}
+}
+
+func TestLint(t *testing.T) {
+	assert.NoError(t, modeline.Lint(parseFile, parseTest, 1))
+	assert.NoError(t, modeline.Lint(parseFile, "no modeline", 1))
+	for text, want := range map[string]string{
+		`# kick:rendr`:                     `unknown option: rendr`,
+		`# kick:render target=../name.ext`: `invalid target "../name.ext"`,
+	} {
+		err := modeline.Lint(parseFile, text, 1)
+		if assert.Error(t, err, text) {
+			assert.Contains(t, err.Error(), want, text)
+		}
+	}
+}
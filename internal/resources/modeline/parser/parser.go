@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"unicode"
@@ -19,6 +20,13 @@ var options = map[string]any{
 	"ignore": struct{}{},
 }
 
+// Known keys of key=value options
+var values = map[string]any{
+	"engine": struct{}{}, // Renderer
+	"delims": struct{}{}, // Left and right delimiters separated by a comma
+	"target": struct{}{}, // Name of the output file
+}
+
 const (
 	modeLine = "kick:"
 	eof      = -1
@@ -30,11 +38,13 @@ const (
 	OPTION // "render" "ignore"
 	RHS    // type=
 	LABEL  // type value
+	VALUE  // value of a key=value option
 )
 
 // Item represents a token returned by the scanner
 type Item struct {
 	Type  Token
+	Key   string // Key of a VALUE item
 	Value string
 }
 
@@ -53,6 +63,7 @@ type lexer struct {
 	width int       // width of last rune read.
 	items chan Item // channel of scanned item.
 	lines int       // maximum number of lines to scan.
+	key   string    // key of the key=value option being scanned.
 }
 
 // next returns the next rune in the input.
@@ -81,7 +92,7 @@ func (l *lexer) backup() {
 
 // emit passes an item back to the client.
 func (l *lexer) emit(t Token) {
-	l.items <- Item{t, l.input[l.start:l.pos]}
+	l.items <- Item{Type: t, Value: l.input[l.start:l.pos]}
 	l.start = l.pos
 }
 
@@ -94,7 +105,7 @@ func (l *lexer) ignore() {
 // back a nil pointer that will be the next state, terminating l.nextItem.
 func (l *lexer) errorf(format string, args ...interface{}) stateFn {
 	lineno := strings.Count(l.input[:l.pos], "\n") + 1
-	l.items <- Item{ILLEGAL, fmt.Sprintf(l.name+":"+fmt.Sprint(lineno)+":"+format, args...)}
+	l.items <- Item{Type: ILLEGAL, Value: fmt.Sprintf(l.name+":"+fmt.Sprint(lineno)+":"+format, args...)}
 	return nil
 }
 
@@ -191,9 +202,33 @@ func lexRHS(l *lexer) stateFn {
 		l.ignore()
 		return lexLABEL
 	}
+	if _, ok := values[id]; ok && l.peek() == '=' {
+		l.next()
+		l.ignore()
+		l.key = id
+		return lexVALUE
+	}
 	return nil
 }
 
+// lexVALUE lex the value of a key=value option. The value ends at a space or
+// the end of the line.
+func lexVALUE(l *lexer) stateFn {
+	for {
+		r := l.peek()
+		if r == ' ' || r == '\n' || r == eof {
+			break
+		}
+		l.next()
+	}
+	if l.pos == l.start {
+		return l.errorf("modeline error: empty value for %s", l.key)
+	}
+	l.items <- Item{Type: VALUE, Key: l.key, Value: l.input[l.start:l.pos]}
+	l.start = l.pos
+	return lexMLDATA
+}
+
 func lexLABEL(l *lexer) stateFn {
 	var r rune
 	var p rune
@@ -290,3 +325,18 @@ func Parse(file, input string, lines int) (items []Item) {
 	}
 	return
 }
+
+// Lint returns an error describing the first illegal token of the mode line in
+// input. Parse stops at an illegal token without reporting it.
+func Lint(file, input string, lines int) error {
+	l := lex(file, input, lines)
+	for {
+		item := l.nextItem()
+		switch item.Type {
+		case 0, END:
+			return nil
+		case ILLEGAL:
+			return errors.New(item.Value)
+		}
+	}
+}
@@ -28,3 +28,12 @@ func TestParse_OutOfBounds(t *testing.T) {
 	items := Parse(parseFile, parseTestOutOfRange, 3)
 	assert.Empty(t, items)
 }
+
+func TestLint(t *testing.T) {
+	assert.NoError(t, Lint(parseFile, parseTest, 5))
+	assert.NoError(t, Lint(parseFile, parseTestNoML, 5))
+	err := Lint(parseFile, "# kick:rendr", 5)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "unknown option: rendr")
+	}
+}
@@ -0,0 +1,140 @@
+// Package output encodes listings as a table, JSON, YAML or TSV. Commands build
+// a Table holding both the rows displayed by the table and TSV encoders and
+// the records encoded by the JSON and YAML encoders.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"gopkg.in/yaml.v2"
+)
+
+// Output formats
+const (
+	TABLE = "table"
+	JSON  = "json"
+	YAML  = "yaml"
+	TSV   = "tsv"
+)
+
+// Formats supported output formats
+var Formats = []string{TABLE, JSON, YAML, TSV}
+
+// Table output of a command
+type Table struct {
+	Header  []string    // Column headings of the table and TSV formats
+	Rows    [][]string  // Cells of the table and TSV formats
+	Records interface{} // Value encoded by the JSON and YAML formats
+	NoWrap  bool        // Do not wrap long cells of the table format
+}
+
+// Encoder writes a Table to w in an output format
+type Encoder interface {
+	// Encode writes t to w
+	Encode(w io.Writer, t *Table) error
+	// Format returns the name of the output format
+	Format() string
+}
+
+// New returns the encoder of format
+func New(format string) (Encoder, error) {
+	switch format {
+	case TABLE, "":
+		return &TableEncoder{}, nil
+	case JSON:
+		return &JSONEncoder{}, nil
+	case YAML:
+		return &YAMLEncoder{}, nil
+	case TSV:
+		return &TSVEncoder{}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %s. must be one of %s", format, strings.Join(Formats, ", "))
+}
+
+// TableEncoder encodes a table for display in a terminal
+type TableEncoder struct{}
+
+// Encode writes t to w
+func (e *TableEncoder) Encode(w io.Writer, t *Table) error {
+	writer := tablewriter.NewWriter(w)
+	writer.SetAlignment(tablewriter.ALIGN_LEFT)
+	writer.SetAutoWrapText(!t.NoWrap)
+	writer.SetHeader(t.Header)
+	for _, v := range t.Rows {
+		writer.Append(v)
+	}
+	writer.Render()
+	return nil
+}
+
+// Format returns the name of the output format
+func (e *TableEncoder) Format() string {
+	return TABLE
+}
+
+// JSONEncoder encodes records as indented JSON
+type JSONEncoder struct{}
+
+// Encode writes t to w
+func (e *JSONEncoder) Encode(w io.Writer, t *Table) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(t.Records)
+	if err != nil {
+		return fmt.Errorf("can not encode json: %w", err)
+	}
+	return nil
+}
+
+// Format returns the name of the output format
+func (e *JSONEncoder) Format() string {
+	return JSON
+}
+
+// YAMLEncoder encodes records as YAML
+type YAMLEncoder struct{}
+
+// Encode writes t to w
+func (e *YAMLEncoder) Encode(w io.Writer, t *Table) error {
+	out, err := yaml.Marshal(t.Records)
+	if err != nil {
+		return fmt.Errorf("can not encode yaml: %w", err)
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// Format returns the name of the output format
+func (e *YAMLEncoder) Format() string {
+	return YAML
+}
+
+// TSVEncoder encodes a header line and rows as tab separated values. Tabs,
+// newlines and backslashes in cells are escaped as \t, \n and \\.
+type TSVEncoder struct{}
+
+var tsvEscape = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// Encode writes t to w
+func (e *TSVEncoder) Encode(w io.Writer, t *Table) error {
+	for _, row := range append([][]string{t.Header}, t.Rows...) {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = tsvEscape.Replace(cell)
+		}
+		_, err := fmt.Fprintln(w, strings.Join(cells, "\t"))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Format returns the name of the output format
+func (e *TSVEncoder) Format() string {
+	return TSV
+}
@@ -0,0 +1,83 @@
+package output_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kick-project/kick/internal/resources/output"
+	"github.com/stretchr/testify/assert"
+)
+
+type record struct {
+	Name string `json:"name" yaml:"name"`
+	Desc string `json:"description" yaml:"description"`
+}
+
+func table() *output.Table {
+	return &output.Table{
+		Header: []string{"Name", "Description"},
+		Rows: [][]string{
+			{"go", "Go\tproject"},
+			{"web", "Web\nsite"},
+		},
+		Records: []record{
+			{Name: "go", Desc: "Go\tproject"},
+			{Name: "web", Desc: "Web\nsite"},
+		},
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, format := range output.Formats {
+		enc, err := output.New(format)
+		assert.NoError(t, err)
+		assert.Equal(t, format, enc.Format())
+	}
+	enc, err := output.New("")
+	assert.NoError(t, err)
+	assert.Equal(t, output.TABLE, enc.Format())
+
+	_, err = output.New("xml")
+	assert.Error(t, err)
+}
+
+func TestTableEncoder(t *testing.T) {
+	out := &bytes.Buffer{}
+	assert.NoError(t, (&output.TableEncoder{}).Encode(out, table()))
+	assert.Regexp(t, `\|\s+NAME\s+\|\s+DESCRIPTION\s+\|`, out.String())
+	assert.Regexp(t, `\|\s+go\s+\|`, out.String())
+}
+
+func TestJSONEncoder(t *testing.T) {
+	out := &bytes.Buffer{}
+	assert.NoError(t, (&output.JSONEncoder{}).Encode(out, table()))
+	assert.Equal(t, `[
+  {
+    "name": "go",
+    "description": "Go\tproject"
+  },
+  {
+    "name": "web",
+    "description": "Web\nsite"
+  }
+]
+`, out.String())
+}
+
+func TestYAMLEncoder(t *testing.T) {
+	out := &bytes.Buffer{}
+	assert.NoError(t, (&output.YAMLEncoder{}).Encode(out, table()))
+	assert.Equal(t, `- name: go
+  description: "Go\tproject"
+- name: web
+  description: |-
+    Web
+    site
+`, out.String())
+}
+
+func TestTSVEncoder(t *testing.T) {
+	out := &bytes.Buffer{}
+	assert.NoError(t, (&output.TSVEncoder{}).Encode(out, table()))
+	assert.Equal(t, "Name\tDescription\ngo\tGo\\tproject\nweb\tWeb\\nsite\n", out.String())
+}
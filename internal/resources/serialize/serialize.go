@@ -9,8 +9,80 @@ type RepoMain struct {
 
 // RepoTemplateFile file written to a repo as `template/${TEMPLATE}.yml`
 type RepoTemplateFile struct {
-	Name     string   `yaml:"name" validate:"required,alphanum"`
-	Desc     string   `yaml:"description" validate:"required"`
-	URL      string   `yaml:"url" validate:"required,url"`
-	Versions []string `yaml:"versions"`
+	Name             string   `yaml:"name" validate:"required,alphanum"`
+	Desc             string   `yaml:"description" validate:"required"`
+	URL              string   `yaml:"url" validate:"required,url"`
+	Versions         []string `yaml:"versions"`
+	RepoTemplateMeta `yaml:",inline"`
+}
+
+// RepoTemplateMeta metadata of a template copied from its .kick.yml and README
+// by "kick repo build"
+type RepoTemplateMeta struct {
+	Labels    []string       `json:"labels,omitempty" yaml:"labels,omitempty"`       // Labels declared in .kick.yml
+	Variables []RepoVariable `json:"variables,omitempty" yaml:"variables,omitempty"` // Variables declared in .kick.yml
+	Renderer  string         `json:"renderer,omitempty" yaml:"renderer,omitempty"`   // Renderer. Empty for the default renderer
+	Keywords  []string       `json:"keywords,omitempty" yaml:"keywords,omitempty"`   // Keywords declared in .kick.yml
+	License   string         `json:"license,omitempty" yaml:"license,omitempty"`     // SPDX license identifier
+	Readme    string         `json:"readme,omitempty" yaml:"readme,omitempty"`       // Excerpt of the README
+}
+
+// RepoVariable a variable declared in .kick.yml
+type RepoVariable struct {
+	Name    string `json:"name" yaml:"name"`
+	Desc    string `json:"description,omitempty" yaml:"description,omitempty"`
+	Default string `json:"default,omitempty" yaml:"default,omitempty"`
+}
+
+// UnmarshalYAML accepts either a variable name or a mapping.
+func (v *RepoVariable) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err == nil {
+		*v = RepoVariable{Name: name}
+		return nil
+	}
+	type plain RepoVariable
+	p := plain{}
+	if err := unmarshal(&p); err != nil {
+		return err
+	}
+	*v = RepoVariable(p)
+	return nil
+}
+
+// VariableNames returns the names of the variables
+func (m *RepoTemplateMeta) VariableNames() []string {
+	names := []string{}
+	for _, v := range m.Variables {
+		names = append(names, v.Name)
+	}
+	return names
+}
+
+// IndexFile name of the index written to the root of a repo by "kick repo build"
+const IndexFile = "index.json"
+
+// RepoIndex index of a repo and its templates written to a repo as
+// `index.json`. A repo URL pointing to an index is fetched over HTTP(S)
+// instead of being cloned.
+type RepoIndex struct {
+	Name      string              `json:"name" yaml:"name" validate:"required,alphanum"`
+	Desc      string              `json:"description" yaml:"description" validate:"required"`
+	Templates []RepoIndexTemplate `json:"templates" yaml:"templates" validate:"dive"`
+}
+
+// RepoIndexTemplate template in RepoIndex
+type RepoIndexTemplate struct {
+	Name             string             `json:"name" yaml:"name" validate:"required,alphanum"`
+	Desc             string             `json:"description" yaml:"description" validate:"required"`
+	URL              string             `json:"url" yaml:"url" validate:"required,url"`
+	Checksum         string             `json:"checksum" yaml:"checksum"` // SHA256 checksum of the template's .kick.yml
+	Versions         []RepoIndexVersion `json:"versions" yaml:"versions"`
+	RepoTemplateMeta `yaml:",inline"`
+}
+
+// RepoIndexVersion version of a template in RepoIndex
+type RepoIndexVersion struct {
+	Version string `json:"version" yaml:"version"`
+	Commit  string `json:"commit" yaml:"commit"` // Commit SHA of the version tag
 }
@@ -26,9 +26,9 @@ envs:
 	assert.Equal(t, `goms`, tmpl.Name)
 	assert.Equal(t, `Go micro services template`, tmpl.Desc)
 
-	data := map[string]string{
-		"GOSERVER": "E.G. Github",
-		"GOGROUP":  "Go group",
+	data := map[string]configtemplate.Env{
+		"GOSERVER": {Desc: "E.G. Github"},
+		"GOGROUP":  {Desc: "Go group"},
 	}
 	assert.Equal(t, data, tmpl.Envs)
 }
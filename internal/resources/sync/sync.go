@@ -3,6 +3,7 @@
 package sync
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"path/filepath"
@@ -30,6 +31,7 @@ type Sync struct {
 	orm                *gorm.DB
 	config             *config.File
 	configTemplatePath string
+	ctx                context.Context
 	log                logger.OutputIface
 	stderr             io.Writer
 	stdout             io.Writer
@@ -40,6 +42,7 @@ type Options struct {
 	Client             *client.Client     `validate:"required"`
 	Config             *config.File       `validate:"required"`
 	ConfigTemplatePath string             `validate:"required"`
+	Context            context.Context    `validate:"required"` // Cancels fetches
 	Log                logger.OutputIface `validate:"required"`
 	ORM                *gorm.DB           `validate:"required"`
 	Stderr             io.Writer          `validate:"required"`
@@ -52,6 +55,7 @@ func New(opts *Options) *Sync {
 		client:             opts.Client,
 		config:             opts.Config,
 		configTemplatePath: opts.ConfigTemplatePath,
+		ctx:                opts.Context,
 		log:                opts.Log,
 		orm:                opts.ORM,
 		stderr:             opts.Stderr,
@@ -88,11 +92,16 @@ func (s *Sync) processRepos() (repos []*model.Repo) {
 }
 
 func (s *Sync) processTemplates(repos []*model.Repo) {
-	for _, repo := range repos {
-		path, err := s.downloadRepo(repo.URL)
-		if err != nil {
+	fetches := make([]client.Fetch, len(repos))
+	for i, repo := range repos {
+		fetches[i] = client.Fetch{Kind: client.REPO, URL: repo.URL}
+	}
+	fetched := s.client.FetchAll(s.ctx, fetches)
+	for i, repo := range repos {
+		if fetched[i].Err != nil {
 			continue
 		}
+		path := fetched[i].Plumb.Path()
 
 		repoPath := filepath.Clean(fmt.Sprintf("%s/%s", path, "repo.yml"))
 		repoSerialize, err := s.loadRepo(repoPath)
@@ -121,16 +130,6 @@ func (s *Sync) processTemplates(repos []*model.Repo) {
 	}
 }
 
-// downloadRepo downloads repo repo
-func (s *Sync) downloadRepo(url string) (path string, err error) {
-	p, err := s.client.GetRepo(url, "")
-	if errs.LogF("warning. can not download %s: %v\n", url, err) {
-		return
-	}
-	path = p.Path()
-	return
-}
-
 // loadRepo loads from a repo YAML file
 func (s *Sync) loadRepo(path string) (repo serialize.RepoMain, err error) {
 	err = marshal.FromFile(&repo, path)
@@ -182,16 +181,18 @@ func (s *Sync) Files() {
 	errs.Panic(err)
 	t := time.Now()
 	ts := t.Format("2006-01-02T15:04:05")
+	fetches := make([]client.Fetch, len(s.config.Templates))
+	for i, item := range s.config.Templates {
+		fetches[i] = client.Fetch{Kind: client.TEMPLATE, URL: item.URL, Ref: item.Ref}
+	}
+	s.client.FetchAll(s.ctx, fetches)
 	for _, item := range s.config.Templates {
-		_, err := s.client.GetTemplate(item.URL, "")
-		if err != nil {
-			fmt.Fprintf(s.stderr, "warning. can not download %s: %s\n", item.URL, err.Error())
-		}
 		inst := model.Installed{
 			Handle:   item.Handle,
 			Template: item.Template,
 			Origin:   item.Origin,
 			URL:      item.URL,
+			VcsRef:   item.Ref,
 			Desc:     item.Desc,
 			Time:     t,
 		}
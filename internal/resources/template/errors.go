@@ -0,0 +1,45 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FileError a file of the template that failed to render. Returned by Check.
+type FileError struct {
+	File string // File as named in missing variable reports. See renderer.Missing
+	Path string // Local path of the file
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// ModelineError an invalid modeline or a modeline option that can not be
+// applied, E.G. an unknown engine.
+type ModelineError struct {
+	Path string // Local path of the file
+	Line int    // Line of the modeline
+	Err  error
+}
+
+func (e *ModelineError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.Path, e.Line, e.Err)
+}
+
+func (e *ModelineError) Unwrap() error {
+	return e.Err
+}
+
+// unprefixed removes the empty path, and line, that modeline.Parse and
+// modeline.Lint put in front of errors when they are not given a path.
+func unprefixed(err error) error {
+	msg := strings.TrimPrefix(err.Error(), ":1:")
+	return errors.New(strings.TrimPrefix(msg, ": "))
+}
@@ -0,0 +1,130 @@
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/kick-project/kick/internal/resources/logger"
+)
+
+// Hook stages
+const (
+	// HookPreGenerate hooks run in the build directory before the project is
+	// moved into place.
+	HookPreGenerate = "pre_generate"
+	// HookPostGenerate hooks run in the project directory.
+	HookPostGenerate = "post_generate"
+)
+
+// hook a command declared in the `.kick.yml` of a layer
+type hook struct {
+	layer string // Name of the layer that declared the hook
+	cmd   string // Command run by the shell
+}
+
+// hooks returns the commands for stage in layer order. Hooks of included
+// layers are only returned when enabled with SetIncludeHooks, otherwise the
+// number skipped is returned.
+func (t *Template) hooks(stage string) (hooks []hook, skipped int) {
+	for i, l := range t.layers {
+		cmds := []string{}
+		switch stage {
+		case HookPreGenerate:
+			cmds = l.hooks.PreGenerate
+		case HookPostGenerate:
+			cmds = l.hooks.PostGenerate
+		}
+		// The template itself is the last layer
+		if i != len(t.layers)-1 && !t.includeHooks {
+			skipped += len(cmds)
+			continue
+		}
+		for _, c := range cmds {
+			hooks = append(hooks, hook{layer: l.name, cmd: c})
+		}
+	}
+	return hooks, skipped
+}
+
+// runHooks runs the hooks for stage inside dir with the template variables
+// exported. Output is streamed through the logger. Stops at the first hook to
+// fail.
+func (t *Template) runHooks(stage, dir string) error {
+	if t.noHooks {
+		return nil
+	}
+	env := os.Environ()
+	if t.vars != nil {
+		env = append(env, t.vars.Environ()...)
+	}
+	hooks, skipped := t.hooks(stage)
+	if skipped > 0 {
+		t.log.Printf("%s: skipping %d hooks of included templates. use --include-hooks to run them\n", stage, skipped)
+	}
+	for _, h := range hooks {
+		t.log.Printf("%s: [%s] %s\n", stage, h.layer, h.cmd)
+		w := &lineWriter{log: t.log, prefix: stage + ": "}
+		cmd := exec.Command("sh", "-c", h.cmd) // nolint
+		cmd.Dir = dir
+		cmd.Env = env
+		cmd.Stdout = w
+		cmd.Stderr = w
+		err := cmd.Run()
+		w.Flush()
+		if err != nil {
+			return fmt.Errorf(`%s hook "%s" failed: %w`, stage, h.cmd, err)
+		}
+	}
+	return nil
+}
+
+// rollback removes what Run created in the destination. When merging only
+// files and directories created by the merge are removed.
+func (t *Template) rollback() {
+	t.log.Printf("rolling back %s\n", t.dest)
+	if t.merge == "" {
+		err := os.RemoveAll(t.dest)
+		t.errs.LogF("can not remove %s: %v", t.dest, err)
+		return
+	}
+	if t.mergeResult == nil {
+		return
+	}
+	created := t.mergeResult.Created
+	for i := len(created) - 1; i >= 0; i-- {
+		p := filepath.Join(t.dest, created[i])
+		err := os.RemoveAll(p)
+		t.errs.LogF("can not remove %s: %v", p, err)
+	}
+}
+
+// lineWriter writes each line written to it to a logger
+type lineWriter struct {
+	log    logger.OutputIface
+	prefix string
+	buf    []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.log.Printf("%s%s\n", w.prefix, w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes any remaining partial line
+func (w *lineWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.log.Printf("%s%s\n", w.prefix, w.buf)
+		w.buf = nil
+	}
+}
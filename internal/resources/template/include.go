@@ -0,0 +1,160 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kick-project/kick/internal/resources/config/configtemplate"
+	"github.com/kick-project/kick/internal/resources/kickignore"
+	"github.com/kick-project/kick/internal/resources/marshal"
+	"github.com/kick-project/kick/internal/resources/template/renderer"
+)
+
+// ErrIncludeCycle a template includes itself directly or through other
+// includes.
+var ErrIncludeCycle = errors.New("include cycle")
+
+// layer a template directory applied to the build directory. Layers are
+// applied in order, files from later layers replace files from earlier ones.
+type layer struct {
+	name       string // Handle, URL or path used to reference the template
+	path       string // Local path to the template
+	renderer   string // Renderer of the template. Empty for the default renderer
+	hooks      configtemplate.Hooks
+	conditions map[string]configtemplate.Condition
+	ignore     *kickignore.Matcher
+	rules      configtemplate.RenderRules
+	delims     []string // Left and right delimiters. Empty for the renderer defaults
+}
+
+// resolveLayers returns the layers for the template l. Includes, and their
+// includes, are resolved depth first in the order they are listed so that l is
+// the last layer. A template included more than once is only applied the first
+// time.
+func (t *Template) resolveLayers(l layer, stack []layer, seen map[string]bool) ([]layer, error) {
+	for i, s := range stack {
+		if s.path != l.path {
+			continue
+		}
+		names := []string{}
+		for _, c := range stack[i:] {
+			names = append(names, c.name)
+		}
+		names = append(names, l.name)
+		return nil, fmt.Errorf("%w: %s", ErrIncludeCycle, strings.Join(names, " -> "))
+	}
+	if seen[l.path] {
+		return nil, nil
+	}
+
+	conf := configtemplate.TemplateMain{}
+	confPath := filepath.Join(l.path, ".kick.yml")
+	if _, err := os.Stat(confPath); err == nil {
+		err = marshal.FromFile(&conf, confPath)
+		if err != nil {
+			return nil, fmt.Errorf("can not load %s: %w", confPath, err)
+		}
+	}
+
+	if len(conf.Delims) != 0 && len(conf.Delims) != 2 {
+		return nil, fmt.Errorf("%s: delims must be a list of a left and a right delimiter", confPath)
+	}
+	if len(conf.Delims) == 2 {
+		name := l.renderer
+		if name == "" {
+			name = t.renderDefault
+		}
+		if r, ok := t.renderersAvail.Get(name); ok {
+			if _, ok := r.(renderer.Delimited); !ok {
+				return nil, fmt.Errorf("%s: renderer %s does not support delims", confPath, name)
+			}
+		}
+	}
+
+	ignore, err := kickignore.Load(l.path)
+	if err != nil {
+		return nil, err
+	}
+	l.hooks = conf.Hooks
+	l.conditions = conf.Conditions
+	l.ignore = ignore
+	l.rules = conf.Rules
+	l.delims = conf.Delims
+	stack = append(stack, l)
+	out := []layer{}
+	for _, inc := range conf.Includes {
+		il, err := t.includeLayer(l.path, inc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", l.name, err)
+		}
+		sub, err := t.resolveLayers(il, stack, seen)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sub...)
+	}
+	seen[l.path] = true
+	return append(out, l), nil
+}
+
+// includeLayer fetches the included template. inc.Template is looked up as an
+// installed handle first. Paths starting with "./" or "../" are relative to
+// dir, the template that holds the include.
+func (t *Template) includeLayer(dir string, inc configtemplate.Include) (layer, error) {
+	url := inc.Template
+	found := false
+	for _, tconf := range t.config.Templates {
+		if tconf.Handle == inc.Template {
+			url = tconf.URL
+			found = true
+			break
+		}
+	}
+	if !found && (strings.HasPrefix(url, "./") || strings.HasPrefix(url, "../")) {
+		url = filepath.Join(dir, filepath.FromSlash(url))
+	}
+
+	p, err := t.client.GetTemplate(url, inc.Ref)
+	if err != nil {
+		return layer{}, fmt.Errorf(`can not get include "%s": %w`, inc.Template, err)
+	}
+	localpath := filepath.Clean(p.Path())
+	if inc.Path != "" {
+		sub := filepath.Join(localpath, filepath.FromSlash(inc.Path))
+		if sub != localpath && !strings.HasPrefix(sub, localpath+string(filepath.Separator)) {
+			return layer{}, fmt.Errorf(`include "%s": path "%s" is outside of the template`, inc.Template, inc.Path)
+		}
+		localpath = sub
+	}
+	stat, err := os.Stat(localpath)
+	if err != nil {
+		return layer{}, fmt.Errorf(`include "%s": %w`, inc.Template, err)
+	}
+	if !stat.IsDir() {
+		return layer{}, fmt.Errorf(`include "%s": %s is not a directory`, inc.Template, localpath)
+	}
+
+	confPath := filepath.Join(localpath, ".kick.yml")
+	t.chkvars(confPath)
+	c := &templateConf{}
+	if _, err := os.Stat(confPath); err == nil {
+		err = marshal.FromFile(c, confPath)
+		if err != nil {
+			return layer{}, fmt.Errorf("can not load %s: %w", confPath, err)
+		}
+	}
+	if c.Renderer != "" {
+		if _, ok := t.renderersAvail.Get(c.Renderer); !ok {
+			return layer{}, fmt.Errorf(`include "%s": no such renderer %s`, inc.Template, c.Renderer)
+		}
+	}
+
+	return layer{
+		name:     inc.Template,
+		path:     localpath,
+		renderer: c.Renderer,
+	}, nil
+}
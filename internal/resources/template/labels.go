@@ -0,0 +1,58 @@
+package template
+
+import (
+	"github.com/kick-project/kick/internal/resources/config/configtemplate"
+)
+
+// layerLabels returns the labels of the labelled files in a layer, keyed by
+// slash separated paths relative to the layer. Returns nil if the layer does
+// not need to be filtered.
+func (t *Template) layerLabels(l layer) (map[string][]string, error) {
+	if len(t.labels) == 0 && len(l.conditions) == 0 {
+		return nil, nil
+	}
+	for _, label := range t.labels {
+		if label == "all" {
+			return nil, nil
+		}
+	}
+	err := t.scan.Run(l.path, int(t.modeLineLen))
+	if err != nil {
+		return nil, err
+	}
+	return t.scan.Labels(l.path)
+}
+
+// included returns true if a file with labels is generated. Files without
+// labels are always generated. Otherwise a file is generated if any of its
+// labels are active.
+//
+// A label is active when it was selected by SetLabels or its condition is met.
+// If no labels were selected, labels without a condition are also active.
+func (t *Template) included(l layer, labels []string) bool {
+	if len(labels) == 0 {
+		return true
+	}
+	for _, label := range labels {
+		if t.activeLabel(l.conditions, label) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Template) activeLabel(conditions map[string]configtemplate.Condition, label string) bool {
+	for _, selected := range t.labels {
+		if selected == label {
+			return true
+		}
+	}
+	cond, ok := conditions[label]
+	if !ok {
+		return len(t.labels) == 0
+	}
+	if t.vars == nil {
+		return false
+	}
+	return cond.Met(t.vars.Lookup)
+}
@@ -0,0 +1,105 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kick-project/kick/internal/resources/checksum"
+	"github.com/kick-project/kick/internal/resources/marshal"
+)
+
+// ManifestFile is the file written to the root of a generated project that
+// links the project back to the template it was generated from. It is used by
+// "kick project update" to regenerate the project from a newer template.
+const ManifestFile = ".kick-project.yml"
+
+// Manifest the contents of ManifestFile
+type Manifest struct {
+	Handle    string            `yaml:"handle"`           // Handle of the installed template
+	URL       string            `yaml:"url"`              // Template URL or path
+	Ref       string            `yaml:"ref,omitempty"`    // Ref the template is pinned to
+	Commit    string            `yaml:"commit,omitempty"` // Commit the project was generated from
+	Renderer  string            `yaml:"renderer"`         // Default renderer of the template
+	Labels    []string          `yaml:"labels,omitempty"` // Labels selected with --label
+	Project   map[string]string `yaml:"project"`          // Project variables, E.G. NAME
+	Variables map[string]string `yaml:"variables"`        // Template variables
+	Files     map[string]string `yaml:"files"`            // SHA256 checksum of each generated file by slash separated path
+}
+
+// LoadManifest loads the manifest of the project in dir
+func LoadManifest(dir string) (*Manifest, error) {
+	m := &Manifest{}
+	err := marshal.FromFile(m, filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("can not load project manifest: %w", err)
+	}
+	return m, nil
+}
+
+// Save saves the manifest to the project in dir
+func (m *Manifest) Save(dir string) error {
+	return marshal.ToFile(m, filepath.Join(dir, ManifestFile))
+}
+
+// Checksums returns the SHA256 checksum of each regular file in dir by slash
+// separated path. ManifestFile is not included.
+func Checksums(dir string) (map[string]string, error) {
+	sums := map[string]string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel := filepath.ToSlash(strings.TrimPrefix(strings.TrimPrefix(path, dir), string(filepath.Separator)))
+		if rel == ManifestFile {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		sum, err := checksum.Sha256Sum(f)
+		if err != nil {
+			return err
+		}
+		sums[rel] = fmt.Sprintf("%x", sum)
+		return nil
+	})
+	return sums, err
+}
+
+// saveManifest writes ManifestFile to the build directory
+func (t *Template) saveManifest() error {
+	files, err := Checksums(t.builddir)
+	if err != nil {
+		return fmt.Errorf("can not save project manifest: %w", err)
+	}
+	m := &Manifest{
+		Handle:    t.src,
+		URL:       t.srcConf.URL,
+		Ref:       t.srcConf.Ref,
+		Commit:    t.commit,
+		Renderer:  t.renderDefault,
+		Labels:    t.labels,
+		Project:   map[string]string{},
+		Variables: map[string]string{},
+		Files:     files,
+	}
+	if t.vars != nil {
+		m.Project = t.vars.Project
+		m.Variables = t.vars.Vars
+	}
+	if len(t.layers) > 0 && t.layers[len(t.layers)-1].renderer != "" {
+		m.Renderer = t.layers[len(t.layers)-1].renderer
+	}
+	err = m.Save(t.builddir)
+	if err != nil {
+		return fmt.Errorf("can not save project manifest: %w", err)
+	}
+	return nil
+}
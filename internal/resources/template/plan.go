@@ -0,0 +1,30 @@
+package template
+
+// Actions performed on a destination path
+const (
+	// ActionMkdir directory is created.
+	ActionMkdir = "mkdir"
+	// ActionCopy file is copied verbatim.
+	ActionCopy = "copy"
+	// ActionRender file is rendered as a template.
+	ActionRender = "render"
+	// ActionIgnore file is ignored by an instruction in the template.
+	ActionIgnore = "ignore"
+	// ActionSkip file is known to kick and is not part of the project.
+	ActionSkip = "skip"
+	// ActionExclude file has labels that were not selected.
+	ActionExclude = "exclude"
+	// ActionSymlink symlink is recreated with its target rendered.
+	ActionSymlink = "symlink"
+)
+
+// PlanEntry describes what happens to a single destination path when a
+// project is generated.
+type PlanEntry struct {
+	Path     string // Destination path
+	Action   string // One of ActionMkdir, ActionCopy, ActionRender, ActionIgnore, ActionSkip, ActionExclude, ActionSymlink
+	Renderer string // Renderer used when Action is ActionRender
+	Layer    string // Handle, URL or path of the template the entry comes from
+	Content  string // Rendered content. Only populated during a dry run with content enabled
+	Conflict bool   // Path exists in the destination and is not a directory being created. Only populated during a dry run
+}
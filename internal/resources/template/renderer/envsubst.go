@@ -6,11 +6,11 @@ import (
 	"regexp"
 
 	"github.com/drone/envsubst"
-	"github.com/kick-project/kick/internal/resources/file"
 	"github.com/kick-project/kick/internal/resources/template/variables"
 )
 
-// RenderEnv renders using environment variables
+// RenderEnv renders using envsubst. Variables are looked up with
+// variables.Variables.Lookup, the process environment is not used.
 type RenderEnv struct {
 	Renderer
 }
@@ -28,27 +28,12 @@ func (r *RenderEnv) File2File(src, dst string, vars *variables.Variables, nounse
 
 // Text2File takes template text text and outputs to dst file
 func (r *RenderEnv) Text2File(text, dst string, vars *variables.Variables, nounset, noempty bool) (err error) {
-	td := os.Getenv("TEMP")
-	f, err := os.CreateTemp(td, "kick-*")
-	if err != nil {
-		return fmt.Errorf("Text2File: %w", err)
-	}
-
 	result, err := r.Text2String(text, vars, nounset, noempty)
 	if err != nil {
 		return fmt.Errorf("Text2File: %w", err)
 	}
 
-	_, err = f.WriteString(result)
-	if err != nil {
-		return fmt.Errorf("Text2File: %w", err)
-	}
-
-	err = f.Close()
-	if err != nil {
-		return fmt.Errorf("Text2File: %w", err)
-	}
-	err = file.MoveAll(f.Name(), dst)
+	err = writeFile(result, dst)
 	if err != nil {
 		return fmt.Errorf("Text2File: %w", err)
 	}
@@ -56,8 +41,25 @@ func (r *RenderEnv) Text2File(text, dst string, vars *variables.Variables, nouns
 }
 
 // Text2String renders input text and returns result as a string.
+// If nounset is true unset variables are an error. If noempty is true unset
+// and empty variables are an error. A *MissingError lists every variable that
+// breaks these rules.
 func (r *RenderEnv) Text2String(text string, vars *variables.Variables, nounset, noempty bool) (result string, err error) {
-	result, err = envsubst.EvalEnv(text)
+	if vars == nil {
+		vars = &variables.Variables{}
+	}
+	missing, err := envMissing(text, vars.Lookup, nounset, noempty)
+	if err != nil {
+		return
+	}
+	err = missingError(missing)
+	if err != nil {
+		return
+	}
+	result, err = envsubst.Eval(text, func(name string) string {
+		value, _ := vars.Lookup(name)
+		return value
+	})
 	return
 }
 
@@ -0,0 +1,36 @@
+package renderer_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/kick-project/kick/internal/resources/template/renderer"
+	"github.com/kick-project/kick/internal/resources/template/variables"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderEnv_Variables(t *testing.T) {
+	t.Setenv("RENDERENV_USER", "jdoe")
+	t.Setenv("PROJECT_NAME", "fromenv")
+	text := "${PROJECT_NAME} ${AUTHOR:-nobody} ${LICENSE,,} ${RENDERENV_USER}"
+
+	one := variables.New()
+	one.ProjectVariable("NAME", "one")
+	one.SetVariable("LICENSE", "MIT")
+	two := variables.New()
+	two.ProjectVariable("NAME", "two")
+	two.SetVariable("AUTHOR", "alice")
+
+	r := &renderer.RenderEnv{}
+	out, err := r.Text2String(text, one, false, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "one nobody mit jdoe", out)
+
+	out, err = r.Text2String(text, two, false, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "two alice  jdoe", out)
+
+	assert.Equal(t, "fromenv", os.Getenv("PROJECT_NAME"))
+	_, ok := os.LookupEnv("LICENSE")
+	assert.False(t, ok)
+}
@@ -0,0 +1,216 @@
+package renderer
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	tt "text/template"
+	"time"
+	"unicode"
+
+	"github.com/kick-project/kick/internal/resources/template/variables"
+)
+
+// Func describes a function available to text/template templates
+type Func struct {
+	Name        string // Name used in templates
+	Usage       string // Example usage
+	Description string // One line description
+}
+
+// Funcs the functions available to text/template templates
+var Funcs = []Func{
+	{"snake", `{{snake .Project.NAME}}`, "Convert to snake_case"},
+	{"kebab", `{{kebab .Project.NAME}}`, "Convert to kebab-case"},
+	{"camel", `{{camel .Project.NAME}}`, "Convert to camelCase"},
+	{"pascal", `{{pascal .Project.NAME}}`, "Convert to PascalCase"},
+	{"title", `{{title .Project.NAME}}`, "Convert to space separated Title Case"},
+	{"lower", `{{lower .Project.NAME}}`, "Convert to lower case"},
+	{"upper", `{{upper .Project.NAME}}`, "Convert to upper case"},
+	{"replace", `{{replace "-" "_" .Project.NAME}}`, "Replace every occurrence of a string"},
+	{"default", `{{default "MIT" .Vars.LICENSE}}`, "Use a default if the value is empty"},
+	{"required", `{{required "AUTHOR is required" .Vars.AUTHOR}}`, "Fail with a message if the value is empty"},
+	{"env", `{{env "USER"}}`, "Value of a template or environment variable"},
+	{"now", `{{now}}`, "Current time"},
+	{"date", `{{date "2006-01-02" now}}`, "Format a time using a Go time layout"},
+	{"uuid", `{{uuid}}`, "Random version 4 UUID"},
+	{"sha256", `{{sha256 .Project.NAME}}`, "Hex encoded SHA256 checksum"},
+	{"indent", `{{indent 4 .Vars.TEXT}}`, "Indent every line by a number of spaces"},
+	{"nindent", `{{nindent 4 .Vars.TEXT}}`, "Indent every line and add a leading newline"},
+	{"join", `{{join .Vars.KEYWORDS ", "}}`, "Join a list, or the words of a string, with a separator"},
+}
+
+// FuncMap returns the functions listed in Funcs for use with text/template.
+// env looks up variables in vars.
+func FuncMap(vars *variables.Variables) tt.FuncMap {
+	return tt.FuncMap{
+		"snake":  func(s string) string { return strings.Join(lowerWords(s), "_") },
+		"kebab":  func(s string) string { return strings.Join(lowerWords(s), "-") },
+		"camel":  camel,
+		"pascal": pascal,
+		"title":  title,
+		"lower":  strings.ToLower,
+		"upper":  strings.ToUpper,
+		"replace": func(old, new, s string) string {
+			return strings.ReplaceAll(s, old, new)
+		},
+		"default": func(def string, value interface{}) string {
+			s := toString(value)
+			if s == "" {
+				return def
+			}
+			return s
+		},
+		"required": func(msg string, value interface{}) (string, error) {
+			s := toString(value)
+			if s == "" {
+				return "", errors.New(msg)
+			}
+			return s, nil
+		},
+		"env": func(name string) string {
+			if vars == nil {
+				return ""
+			}
+			value, _ := vars.Lookup(name)
+			return value
+		},
+		"now": time.Now,
+		"date": func(layout string, t time.Time) string {
+			return t.Format(layout)
+		},
+		"uuid":    uuid,
+		"sha256":  func(s string) string { sum := sha256.Sum256([]byte(s)); return hex.EncodeToString(sum[:]) },
+		"indent":  indent,
+		"nindent": func(n int, s string) string { return "\n" + indent(n, s) },
+		"join":    join,
+	}
+}
+
+// words splits s into words on non alphanumeric characters and on changes of
+// case. "myHTTPServer-v2" is split into "my", "HTTP", "Server" and "v2".
+func words(s string) []string {
+	out := []string{}
+	runes := []rune(s)
+	start := -1
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				out = append(out, string(runes[start:i]))
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+			continue
+		}
+		prev := runes[i-1]
+		split := unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev))
+		split = split || (unicode.IsUpper(r) && unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]))
+		if split {
+			out = append(out, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start >= 0 {
+		out = append(out, string(runes[start:]))
+	}
+	return out
+}
+
+func lowerWords(s string) []string {
+	w := words(s)
+	for i := range w {
+		w[i] = strings.ToLower(w[i])
+	}
+	return w
+}
+
+func capitalize(s string) string {
+	runes := []rune(strings.ToLower(s))
+	if len(runes) == 0 {
+		return s
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func pascal(s string) string {
+	w := words(s)
+	for i := range w {
+		w[i] = capitalize(w[i])
+	}
+	return strings.Join(w, "")
+}
+
+func camel(s string) string {
+	w := words(s)
+	for i := range w {
+		if i == 0 {
+			w[i] = strings.ToLower(w[i])
+			continue
+		}
+		w[i] = capitalize(w[i])
+	}
+	return strings.Join(w, "")
+}
+
+func title(s string) string {
+	w := words(s)
+	for i := range w {
+		w[i] = capitalize(w[i])
+	}
+	return strings.Join(w, " ")
+}
+
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+// join joins the elements of list with sep. A string list is split into
+// whitespace separated words, E.G. a variable holding "go cli".
+func join(list interface{}, sep string) string {
+	switch l := list.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.Join(strings.Fields(l), sep)
+	case []string:
+		return strings.Join(l, sep)
+	case []interface{}:
+		s := make([]string, len(l))
+		for i, v := range l {
+			s[i] = toString(v)
+		}
+		return strings.Join(s, sep)
+	}
+	return toString(list)
+}
+
+func uuid() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// toString converts a template value to a string. Missing map entries are
+// passed to functions as nil.
+func toString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,89 @@
+package renderer_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	tt "text/template"
+
+	"github.com/kick-project/kick/internal/resources/template/renderer"
+	"github.com/kick-project/kick/internal/resources/template/variables"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuncMap(t *testing.T) {
+	vars := variables.New()
+	vars.SetVariable("LICENSE", "")
+	vars.SetVariable("TEXT", "a\nb")
+	vars.SetVariable("KEYWORDS", "go  cli\tapp")
+	vars.Env["FUNCMAP_USER"] = "jdoe"
+	for _, tc := range []struct {
+		text string
+		want string
+	}{
+		{`{{snake "myHTTPServer v2"}}`, "my_http_server_v2"},
+		{`{{kebab "My Cool_project"}}`, "my-cool-project"},
+		{`{{camel "my-cool-project"}}`, "myCoolProject"},
+		{`{{pascal "my_cool_project"}}`, "MyCoolProject"},
+		{`{{title "my-cool-project"}}`, "My Cool Project"},
+		{`{{lower "ABC"}}{{upper "def"}}`, "abcDEF"},
+		{`{{replace "-" "_" "a-b-c"}}`, "a_b_c"},
+		{`{{default "MIT" .Vars.LICENSE}}`, "MIT"},
+		{`{{default "MIT" .Vars.MISSING}}`, "MIT"},
+		{`{{default "MIT" "BSD"}}`, "BSD"},
+		{`{{env "FUNCMAP_USER"}}`, "jdoe"},
+		{`{{env "LICENSE"}}`, ""},
+		{`{{date "2006" now | len}}`, "4"},
+		{`{{sha256 "kick"}}`, "0db10f2c2f332cd27cf1407fa16c686337b2b23f46125d6e17740dbfc6df427e"},
+		{`{{indent 2 .Vars.TEXT}}`, "  a\n  b"},
+		{`{{nindent 2 .Vars.TEXT}}`, "\n  a\n  b"},
+		{`{{join .Vars.KEYWORDS ", "}}`, "go, cli, app"},
+		{`{{join .Vars.MISSING ", "}}`, ""},
+	} {
+		got, err := execute(tc.text, vars)
+		assert.NoError(t, err, tc.text)
+		assert.Equal(t, tc.want, got, tc.text)
+	}
+}
+
+func TestFuncMap_UUID(t *testing.T) {
+	got, err := execute(`{{uuid}}`, variables.New())
+	assert.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), got)
+}
+
+func TestFuncMap_Required(t *testing.T) {
+	_, err := execute(`{{required "AUTHOR is required" .Vars.AUTHOR}}`, variables.New())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "AUTHOR is required")
+}
+
+func TestFuncMap_Join(t *testing.T) {
+	tmpl, err := tt.New("test").Funcs(renderer.FuncMap(nil)).Parse(`{{join .Strings "/"}} {{join .Values "-"}}`)
+	assert.NoError(t, err)
+	out := &strings.Builder{}
+	err = tmpl.Execute(out, map[string]interface{}{
+		"Strings": []string{"a", "b"},
+		"Values":  []interface{}{"c", 1, true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "a/b c-1-true", out.String())
+}
+
+func TestFuncMap_Funcs(t *testing.T) {
+	funcs := renderer.FuncMap(nil)
+	assert.Len(t, funcs, len(renderer.Funcs))
+	for _, f := range renderer.Funcs {
+		assert.Contains(t, funcs, f.Name)
+	}
+}
+
+func execute(text string, vars *variables.Variables) (string, error) {
+	tmpl, err := tt.New("test").Funcs(renderer.FuncMap(vars)).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	out := &strings.Builder{}
+	err = tmpl.Execute(out, vars)
+	return out.String(), err
+}
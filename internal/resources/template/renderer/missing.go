@@ -0,0 +1,185 @@
+package renderer
+
+import (
+	"fmt"
+	"strings"
+	"text/template/parse"
+
+	envparse "github.com/drone/envsubst/parse"
+	"github.com/kick-project/kick/internal/resources/template/variables"
+)
+
+// Missing a variable used by a template that is not set or is empty
+type Missing struct {
+	File  string // Template file. Set by the caller of the renderer
+	Line  int    // Line number within the file
+	Name  string // Variable name as written in the template
+	Empty bool   // true if the variable is set but empty
+}
+
+func (m Missing) String() string {
+	state := "is not set"
+	if m.Empty {
+		state = "is empty"
+	}
+	if m.File == "" {
+		return fmt.Sprintf("%d: %s %s", m.Line, m.Name, state)
+	}
+	return fmt.Sprintf("%s:%d: %s %s", m.File, m.Line, m.Name, state)
+}
+
+// MissingError is returned when rendering with nounset or noempty and
+// variables are not set or are empty. Nothing is written.
+type MissingError struct {
+	Missing []Missing
+}
+
+func (e *MissingError) Error() string {
+	lines := []string{fmt.Sprintf("%d missing variables", len(e.Missing))}
+	for _, m := range e.Missing {
+		lines = append(lines, "  "+m.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// missingError returns a *MissingError if missing is not empty
+func missingError(missing []Missing) error {
+	if len(missing) == 0 {
+		return nil
+	}
+	return &MissingError{Missing: missing}
+}
+
+// check returns a Missing if value breaks the nounset or noempty rules
+func check(name, value string, ok, nounset, noempty bool) (Missing, bool) {
+	switch {
+	case !ok && (nounset || noempty):
+		return Missing{Name: name}, true
+	case ok && value == "" && noempty:
+		return Missing{Name: name, Empty: true}, true
+	}
+	return Missing{}, false
+}
+
+//
+// envsubst
+//
+
+// envMissing returns the variables in text that break the nounset or noempty
+// rules. Substitutions with a default value, E.G. ${VAR:-default}, are not
+// checked.
+func envMissing(text string, lookup func(string) (string, bool), nounset, noempty bool) ([]Missing, error) {
+	if !nounset && !noempty {
+		return nil, nil
+	}
+	tree, err := envparse.Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	missing := []Missing{}
+	line := 1
+	var walk func(n envparse.Node)
+	walk = func(n envparse.Node) {
+		switch node := n.(type) {
+		case *envparse.TextNode:
+			line += strings.Count(node.Value, "\n")
+		case *envparse.ListNode:
+			for _, c := range node.Nodes {
+				walk(c)
+			}
+		case *envparse.FuncNode:
+			switch node.Name {
+			case "-", ":-", "=", ":=":
+			default:
+				value, ok := lookup(node.Param)
+				if m, bad := check(node.Param, value, ok, nounset, noempty); bad {
+					m.Line = line
+					missing = append(missing, m)
+				}
+			}
+			for _, c := range node.Args {
+				walk(c)
+			}
+		}
+	}
+	walk(tree.Root)
+	return missing, nil
+}
+
+//
+// text/template
+//
+
+// textMissing returns the fields of .Project, .Vars and .Env used in tree that
+// break the nounset or noempty rules. Fields used as conditions of if, with
+// and range or passed to the default function are not checked.
+func textMissing(text string, tree *parse.Tree, vars *variables.Variables, nounset, noempty bool) []Missing {
+	if (!nounset && !noempty) || tree == nil || tree.Root == nil {
+		return nil
+	}
+	if vars == nil {
+		vars = &variables.Variables{}
+	}
+	missing := []Missing{}
+	var walk func(n parse.Node)
+	walk = func(n parse.Node) {
+		switch node := n.(type) {
+		case *parse.ListNode:
+			if node == nil {
+				return
+			}
+			for _, c := range node.Nodes {
+				walk(c)
+			}
+		case *parse.ActionNode:
+			walk(node.Pipe)
+		case *parse.PipeNode:
+			if node == nil {
+				return
+			}
+			for _, c := range node.Cmds {
+				walk(c)
+			}
+		case *parse.CommandNode:
+			if len(node.Args) > 0 {
+				if id, ok := node.Args[0].(*parse.IdentifierNode); ok && id.Ident == "default" {
+					return
+				}
+			}
+			for _, c := range node.Args {
+				walk(c)
+			}
+		case *parse.IfNode:
+			walk(node.List)
+			walk(node.ElseList)
+		case *parse.WithNode:
+			walk(node.List)
+			walk(node.ElseList)
+		case *parse.RangeNode:
+			walk(node.List)
+			walk(node.ElseList)
+		case *parse.FieldNode:
+			if len(node.Ident) != 2 {
+				return
+			}
+			var values map[string]string
+			switch node.Ident[0] {
+			case "Project":
+				values = vars.Project
+			case "Vars":
+				values = vars.Vars
+			case "Env":
+				values = vars.Env
+			default:
+				return
+			}
+			value, ok := values[node.Ident[1]]
+			if m, bad := check(node.String(), value, ok, nounset, noempty); bad {
+				m.Line = 1 + strings.Count(text[:node.Position()], "\n")
+				missing = append(missing, m)
+			}
+		}
+	}
+	walk(tree.Root)
+	return missing
+}
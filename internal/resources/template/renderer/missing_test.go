@@ -0,0 +1,62 @@
+package renderer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kick-project/kick/internal/resources/template/renderer"
+	"github.com/kick-project/kick/internal/resources/template/variables"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderText_Missing(t *testing.T) {
+	vars := variables.New()
+	vars.ProjectVariable("NAME", "myproject")
+	vars.SetVariable("EMPTY", "")
+	text := "{{.Project.NAME}}\n{{.Vars.UNSET}}\n{{default \"x\" .Vars.DEFAULTED}}{{if .Vars.COND}}{{end}}\n{{.Vars.EMPTY}}\n"
+
+	r := &renderer.RenderText{}
+	out, err := r.Text2String(text, vars, false, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "myproject\n<no value>\nx\n\n", out)
+
+	_, err = r.Text2String(text, vars, true, false)
+	assert.Equal(t, []renderer.Missing{{Line: 2, Name: ".Vars.UNSET"}}, missing(t, err))
+
+	_, err = r.Text2String(text, vars, false, true)
+	assert.Equal(t, []renderer.Missing{{Line: 2, Name: ".Vars.UNSET"}, {Line: 4, Name: ".Vars.EMPTY", Empty: true}}, missing(t, err))
+}
+
+func TestRenderEnv_Missing(t *testing.T) {
+	vars := variables.New()
+	vars.SetVariable("MISSING_SET", "value")
+	vars.SetVariable("MISSING_EMPTY", "")
+	text := "${MISSING_SET}\n${MISSING_UNSET}\n${MISSING_DEFAULT:-x} ${MISSING_EMPTY}\n"
+
+	r := &renderer.RenderEnv{}
+	out, err := r.Text2String(text, vars, false, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "value\n\nx \n", out)
+
+	_, err = r.Text2String(text, vars, true, false)
+	assert.Equal(t, []renderer.Missing{{Line: 2, Name: "MISSING_UNSET"}}, missing(t, err))
+
+	_, err = r.Text2String(text, vars, true, true)
+	assert.Equal(t, []renderer.Missing{{Line: 2, Name: "MISSING_UNSET"}, {Line: 3, Name: "MISSING_EMPTY", Empty: true}}, missing(t, err))
+}
+
+func missing(t *testing.T, err error) []renderer.Missing {
+	var merr *renderer.MissingError
+	if !errors.As(err, &merr) {
+		t.Fatalf("expected *MissingError, got %v", err)
+	}
+	return merr.Missing
+}
+
+func TestRenderText_Error(t *testing.T) {
+	r := &renderer.RenderText{}
+	_, err := r.Text2String("{{if}}\n", variables.New(), false, false)
+	assert.Error(t, err)
+	_, err = r.Text2String(`{{required "AUTHOR" ""}}`, variables.New(), false, false)
+	assert.Error(t, err)
+}
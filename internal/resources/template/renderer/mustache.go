@@ -0,0 +1,264 @@
+package renderer
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/kick-project/kick/internal/resources/template/variables"
+)
+
+//
+// RenderMustache
+//
+
+// RenderMustache renders Mustache compatible templates. Variables are looked
+// up with variables.Variables.Lookup, E.G. {{PROJECT_NAME}}. Supported tags
+// are variables, unescaped variables, sections, inverted sections, comments
+// and set delimiter tags. Partials are not supported. A section is rendered
+// when its variable is set, is not empty and is not "false".
+type RenderMustache struct {
+	Renderer
+	Left  string // Left delimiter. Defaults to "{{"
+	Right string // Right delimiter. Defaults to "}}"
+}
+
+// Delims returns a copy of the renderer that uses the left and right
+// delimiters.
+func (r *RenderMustache) Delims(left, right string) Renderer {
+	return &RenderMustache{Left: left, Right: right}
+}
+
+func (r *RenderMustache) delims() (string, string) {
+	if r.Left == "" || r.Right == "" {
+		return "{{", "}}"
+	}
+	return r.Left, r.Right
+}
+
+// File2File takes a src file populates a dst file with the results of the
+// template populated with variables
+func (r *RenderMustache) File2File(src, dst string, vars *variables.Variables, nounset, noempty bool) error {
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return r.Text2File(string(b), dst, vars, nounset, noempty)
+}
+
+// Text2File takes template text text and outputs to dst file
+func (r *RenderMustache) Text2File(text, dst string, vars *variables.Variables, nounset, noempty bool) error {
+	result, err := r.Text2String(text, vars, nounset, noempty)
+	if err != nil {
+		return fmt.Errorf("Text2File: %w", err)
+	}
+	err = writeFile(result, dst)
+	if err != nil {
+		return fmt.Errorf("Text2File: %w", err)
+	}
+	return nil
+}
+
+// Text2String renders input text and returns result as a string.
+//
+// If nounset is true unset variables are an error. If noempty is true unset
+// and empty variables are an error. A *MissingError lists every variable that
+// breaks these rules. Section names are not checked.
+func (r *RenderMustache) Text2String(text string, vars *variables.Variables, nounset, noempty bool) (string, error) {
+	if vars == nil {
+		vars = &variables.Variables{}
+	}
+	left, right := r.delims()
+	nodes, err := parseMustache(text, left, right)
+	if err != nil {
+		return "", err
+	}
+	err = missingError(mustacheMissing(nodes, vars, nounset, noempty))
+	if err != nil {
+		return "", err
+	}
+	b := &strings.Builder{}
+	renderMustache(nodes, vars, b)
+	return b.String(), nil
+}
+
+// RenderDirRegexp returns the regex to match directory names that should be rendered.
+func (r *RenderMustache) RenderDirRegexp() *regexp.Regexp {
+	left, right := r.delims()
+	return regexp.MustCompile(regexp.QuoteMeta(left) + `.+?` + regexp.QuoteMeta(right))
+}
+
+// mustache node kinds
+const (
+	mText      = iota // Text
+	mVar              // {{name}}
+	mRaw              // {{{name}}} or {{&name}}
+	mSection          // {{#name}}...{{/name}}
+	mInverted         // {{^name}}...{{/name}}
+	mEndOfList        // {{/name}}. Only used while parsing
+)
+
+type mustacheNode struct {
+	kind  int
+	value string // Text or variable name
+	line  int
+	nodes []mustacheNode
+}
+
+// parseMustache parses text into a tree of nodes
+func parseMustache(text, left, right string) ([]mustacheNode, error) {
+	type frame struct {
+		node  mustacheNode
+		nodes []mustacheNode
+	}
+	stack := []frame{{}}
+	add := func(n mustacheNode) {
+		top := &stack[len(stack)-1]
+		top.nodes = append(top.nodes, n)
+	}
+	pos := 0
+	for {
+		i := strings.Index(text[pos:], left)
+		if i < 0 {
+			if pos < len(text) {
+				add(mustacheNode{kind: mText, value: text[pos:]})
+			}
+			break
+		}
+		start := pos + i
+		line := 1 + strings.Count(text[:start], "\n")
+		inner := start + len(left)
+		closing := right
+		if strings.HasPrefix(text[inner:], "{") && left == "{{" {
+			closing = "}" + right
+		}
+		j := strings.Index(text[inner:], closing)
+		if j < 0 {
+			return nil, fmt.Errorf("line %d: unclosed tag", line)
+		}
+		end := inner + j + len(closing)
+		tag := text[inner : inner+j]
+
+		kind := mVar
+		name := strings.TrimSpace(tag)
+		sigil := byte(0)
+		if name != "" {
+			sigil = name[0]
+		}
+		switch sigil {
+		case '{', '&':
+			kind = mRaw
+			name = strings.TrimSpace(name[1:])
+		case '#':
+			kind = mSection
+			name = strings.TrimSpace(name[1:])
+		case '^':
+			kind = mInverted
+			name = strings.TrimSpace(name[1:])
+		case '/':
+			kind = mEndOfList
+			name = strings.TrimSpace(name[1:])
+		case '!', '=':
+			kind = mText
+		case '>':
+			return nil, fmt.Errorf("line %d: partials are not supported", line)
+		}
+		if name == "" {
+			return nil, fmt.Errorf("line %d: empty tag", line)
+		}
+
+		// Tags other than variables on a line of their own do not leave an empty line
+		lead := text[pos:start]
+		if kind != mVar && kind != mRaw {
+			lineStart := strings.LastIndex(text[:start], "\n") + 1
+			rest := text[end:]
+			nl := strings.IndexByte(rest, '\n')
+			tail := rest
+			if nl >= 0 {
+				tail = rest[:nl+1]
+			}
+			if lineStart >= pos && strings.TrimSpace(text[lineStart:start]) == "" && strings.TrimSpace(tail) == "" {
+				lead = text[pos:lineStart]
+				end += len(tail)
+			}
+		}
+		if lead != "" {
+			add(mustacheNode{kind: mText, value: lead})
+		}
+		pos = end
+
+		switch {
+		case sigil == '!':
+		case sigil == '=':
+			d := strings.Fields(strings.TrimSuffix(strings.TrimSpace(name[1:]), "="))
+			if len(d) != 2 {
+				return nil, fmt.Errorf("line %d: invalid set delimiter tag", line)
+			}
+			left, right = d[0], d[1]
+		case kind == mSection || kind == mInverted:
+			stack = append(stack, frame{node: mustacheNode{kind: kind, value: name, line: line}})
+		case kind == mEndOfList:
+			top := stack[len(stack)-1]
+			if len(stack) == 1 || top.node.value != name {
+				return nil, fmt.Errorf("line %d: unexpected end of section %s", line, name)
+			}
+			stack = stack[:len(stack)-1]
+			top.node.nodes = top.nodes
+			add(top.node)
+		default:
+			add(mustacheNode{kind: kind, value: name, line: line})
+		}
+	}
+	if len(stack) > 1 {
+		top := stack[len(stack)-1].node
+		return nil, fmt.Errorf("line %d: section %s is not closed", top.line, top.value)
+	}
+	return stack[0].nodes, nil
+}
+
+func renderMustache(nodes []mustacheNode, vars *variables.Variables, b *strings.Builder) {
+	for _, n := range nodes {
+		value, ok := vars.Lookup(n.value)
+		truthy := ok && value != "" && value != "false"
+		switch n.kind {
+		case mText:
+			b.WriteString(n.value)
+		case mVar:
+			b.WriteString(html.EscapeString(value))
+		case mRaw:
+			b.WriteString(value)
+		case mSection:
+			if truthy {
+				renderMustache(n.nodes, vars, b)
+			}
+		case mInverted:
+			if !truthy {
+				renderMustache(n.nodes, vars, b)
+			}
+		}
+	}
+}
+
+// mustacheMissing returns the variables in nodes that break the nounset or
+// noempty rules.
+func mustacheMissing(nodes []mustacheNode, vars *variables.Variables, nounset, noempty bool) []Missing {
+	if !nounset && !noempty {
+		return nil
+	}
+	missing := []Missing{}
+	for _, n := range nodes {
+		switch n.kind {
+		case mVar, mRaw:
+			value, ok := vars.Lookup(n.value)
+			if m, bad := check(n.value, value, ok, nounset, noempty); bad {
+				m.Line = n.line
+				missing = append(missing, m)
+			}
+		case mSection, mInverted:
+			missing = append(missing, mustacheMissing(n.nodes, vars, nounset, noempty)...)
+		}
+	}
+	return missing
+}
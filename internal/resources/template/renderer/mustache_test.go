@@ -0,0 +1,86 @@
+package renderer_test
+
+import (
+	"testing"
+
+	"github.com/kick-project/kick/internal/resources/template/renderer"
+	"github.com/kick-project/kick/internal/resources/template/variables"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderMustache(t *testing.T) {
+	vars := variables.New()
+	vars.ProjectVariable("NAME", "myproject")
+	vars.SetVariable("HTML", "<b>")
+	vars.SetVariable("ON", "true")
+	vars.SetVariable("OFF", "false")
+	for _, tc := range []struct {
+		text string
+		want string
+	}{
+		{"{{PROJECT_NAME}}", "myproject"},
+		{"{{ PROJECT_NAME }}", "myproject"},
+		{"{{HTML}} {{{HTML}}} {{&HTML}}", "&lt;b&gt; <b> <b>"},
+		{"{{#ON}}on{{/ON}}{{#OFF}}off{{/OFF}}{{#UNSET}}unset{{/UNSET}}", "on"},
+		{"{{^OFF}}not off{{/OFF}}", "not off"},
+		{"a{{! comment }}b", "ab"},
+		{"list:\n  {{#ON}}\n  - on\n  {{/ON}}\nend\n", "list:\n  - on\nend\n"},
+		{"{{=<% %>=}}<% PROJECT_NAME %> {{PROJECT_NAME}}", "myproject {{PROJECT_NAME}}"},
+	} {
+		out, err := (&renderer.RenderMustache{}).Text2String(tc.text, vars, false, false)
+		assert.NoError(t, err, tc.text)
+		assert.Equal(t, tc.want, out, tc.text)
+	}
+}
+
+func TestRenderMustache_Errors(t *testing.T) {
+	r := &renderer.RenderMustache{}
+	for text, want := range map[string]string{
+		"{{#A}}":         "line 1: section A is not closed",
+		"{{#A}}\n{{/B}}": "line 2: unexpected end of section B",
+		"{{A":            "line 1: unclosed tag",
+		"{{> partial}}":  "line 1: partials are not supported",
+	} {
+		_, err := r.Text2String(text, variables.New(), false, false)
+		if assert.Error(t, err, text) {
+			assert.Equal(t, want, err.Error(), text)
+		}
+	}
+}
+
+func TestRenderMustache_Missing(t *testing.T) {
+	vars := variables.New()
+	_, err := (&renderer.RenderMustache{}).Text2String("{{#UNSET_SECTION}}\n{{/UNSET_SECTION}}\n{{UNSET_VAR}}\n", vars, true, false)
+	assert.Equal(t, []renderer.Missing{{Line: 3, Name: "UNSET_VAR"}}, missing(t, err))
+}
+
+func TestRenderer_Delims(t *testing.T) {
+	vars := variables.New()
+	vars.ProjectVariable("NAME", "myproject")
+	for name, r := range map[string]renderer.Renderer{
+		"texttemplate": (&renderer.RenderText{}).Delims("[[", "]]"),
+		"mustache":     (&renderer.RenderMustache{}).Delims("[[", "]]"),
+	} {
+		text := map[string]string{
+			"texttemplate": "[[.Project.NAME]] {{.Values}}",
+			"mustache":     "[[PROJECT_NAME]] {{.Values}}",
+		}[name]
+		out, err := r.Text2String(text, vars, false, false)
+		assert.NoError(t, err, name)
+		assert.Equal(t, "myproject {{.Values}}", out, name)
+		assert.True(t, r.RenderDirRegexp().MatchString("dir/[[x]]"), name)
+		assert.False(t, r.RenderDirRegexp().MatchString("dir/{{x}}"), name)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := renderer.DefaultRegistry()
+	assert.Equal(t, []string{"envsubst", "mustache", "texttemplate"}, r.Names())
+	custom := &renderer.RenderEnv{}
+	r.Register("custom", custom)
+	got, ok := r.Get("custom")
+	assert.True(t, ok)
+	assert.Same(t, custom, got)
+	_, ok = r.Get("nosuchrenderer")
+	assert.False(t, ok)
+}
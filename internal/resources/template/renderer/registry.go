@@ -0,0 +1,77 @@
+package renderer
+
+import (
+	"sort"
+)
+
+// Default name of the renderer used by templates that do not set one
+const Default = "envsubst"
+
+// Delimited is implemented by renderers with configurable delimiters
+type Delimited interface {
+	// Delims returns a copy of the renderer that uses the left and right
+	// delimiters.
+	Delims(left, right string) Renderer
+}
+
+// Registry renderers available to templates by name. A template selects a
+// renderer with "renderer" in `.kick.yml` and a file with the "engine" modeline
+// option.
+type Registry struct {
+	renderers map[string]Renderer
+	aliases   map[string]string
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		renderers: map[string]Renderer{},
+		aliases:   map[string]string{},
+	}
+}
+
+// DefaultRegistry returns a registry with the renderers built into kick
+//
+//	envsubst      ${VAR} substitution. The default renderer. Alias env
+//	texttemplate  Go text/template. Alias text
+//	mustache      Mustache compatible templates
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.Register(Default, &RenderEnv{})
+	r.Register("texttemplate", &RenderText{})
+	r.Register("mustache", &RenderMustache{})
+	r.Alias("env", "envsubst")
+	r.Alias("text", "texttemplate")
+	return r
+}
+
+// Register adds a renderer under name. A renderer already registered under
+// name is replaced.
+func (r *Registry) Register(name string, renderer Renderer) {
+	r.renderers[name] = renderer
+}
+
+// Alias makes the renderer registered under name available as alias
+func (r *Registry) Alias(alias, name string) {
+	r.aliases[alias] = name
+}
+
+// Get returns the renderer registered under name or an alias of name
+func (r *Registry) Get(name string) (Renderer, bool) {
+	if n, ok := r.aliases[name]; ok {
+		name = n
+	}
+	renderer, ok := r.renderers[name]
+	return renderer, ok
+}
+
+// Names returns the names of the registered renderers in sorted order.
+// Aliases are not included.
+func (r *Registry) Names() []string {
+	names := []string{}
+	for name := range r.renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -1,8 +1,10 @@
 package renderer
 
 import (
+	"os"
 	"regexp"
 
+	"github.com/kick-project/kick/internal/resources/file"
 	"github.com/kick-project/kick/internal/resources/template/variables"
 )
 
@@ -13,3 +15,22 @@ type Renderer interface {
 	Text2String(text string, vars *variables.Variables, nounset, noempty bool) (string, error)
 	RenderDirRegexp() *regexp.Regexp
 }
+
+// writeFile writes content to a temporary file and moves it to dst
+func writeFile(content, dst string) error {
+	td := os.Getenv("TEMP")
+	f, err := os.CreateTemp(td, "kick-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(content)
+	if err != nil {
+		f.Close() // nolint
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+	return file.MoveAll(f.Name(), dst)
+}
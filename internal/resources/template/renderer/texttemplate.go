@@ -17,6 +17,14 @@ import (
 // RenderText render using text/template
 type RenderText struct {
 	Renderer
+	Left  string // Left delimiter. Defaults to "{{"
+	Right string // Right delimiter. Defaults to "}}"
+}
+
+// Delims returns a copy of the renderer that uses the left and right
+// delimiters.
+func (r *RenderText) Delims(left, right string) Renderer {
+	return &RenderText{Left: left, Right: right}
 }
 
 // File2File takes a src file populates a dst file with the results of the
@@ -24,22 +32,35 @@ type RenderText struct {
 func (r *RenderText) File2File(src, dst string, vars *variables.Variables, nounset, noempty bool) error {
 	b, err := os.ReadFile(src)
 	errs.LogF("Can not open template file %s for reading: %v", src, err)
-	err = r.Text2File(string(b), dst, vars, nounset, noempty)
-	errs.Panic(err)
-	return err
+	return r.Text2File(string(b), dst, vars, nounset, noempty)
 }
 
 // Text2File takes template text text and outputs to dst file
+//
+// If nounset is true unset variables are an error. If noempty is true unset
+// and empty variables are an error. A *MissingError lists every variable that
+// breaks these rules. Templates that can not be parsed or executed return an
+// error and nothing is written.
 func (r *RenderText) Text2File(text, dst string, vars *variables.Variables, nounset, noempty bool) error {
+	t, err := tt.New("texttemplate").Delims(r.Left, r.Right).Funcs(FuncMap(vars)).Parse(text)
+	if err != nil {
+		return err
+	}
+	err = missingError(textMissing(text, t.Tree, vars, nounset, noempty))
+	if err != nil {
+		return err
+	}
+
 	td := os.Getenv("TEMP")
 	f, err := os.CreateTemp(td, "kick-*")
 	errs.PanicF("Error creating tempfile %v", err)
 
-	t := tt.Must(tt.New("texttemplate").Parse(text))
-
-	errs.PanicF("Error parsing variables: %v", err)
 	err = t.Execute(f, vars)
-	errs.PanicF("Error executing template: %v", err)
+	if err != nil {
+		f.Close()           // nolint
+		os.Remove(f.Name()) // nolint
+		return err
+	}
 	err = f.Close()
 	errs.PanicF("Error closing tempfile: %v", err)
 	err = file.MoveAll(f.Name(), dst)
@@ -52,17 +73,22 @@ func (r *RenderText) Text2String(text string, vars *variables.Variables, nounset
 	td := os.Getenv("TEMP")
 	f, err := os.CreateTemp(td, "kick-*")
 	errs.PanicF("Error creating tempfile %v", err)
-	f.Close() // nolint
+	f.Close()                 // nolint
+	defer os.Remove(f.Name()) // nolint
 	err = r.Text2File(text, f.Name(), vars, nounset, noempty)
-	errs.Panic(err)
+	if err != nil {
+		return "", err
+	}
 	b, err := os.ReadFile(f.Name())
 	errs.LogF("Can not open template file %s for reading: %v", f.Name(), err)
-	os.Remove(f.Name()) // nolint
 	return string(b), err
 }
 
 // RenderDirRegexp returns the regex to match directory names that should be rendered.
 func (r *RenderText) RenderDirRegexp() *regexp.Regexp {
+	if r.Left != "" && r.Right != "" {
+		return regexp.MustCompile(regexp.QuoteMeta(r.Left) + `.+?` + regexp.QuoteMeta(r.Right))
+	}
 	regex := regexp.MustCompile(`{{[^}}]+}}`)
 	return regex
 }
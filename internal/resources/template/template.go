@@ -16,16 +16,24 @@ import (
 	"github.com/kick-project/kick/internal/resources/checkvars"
 	"github.com/kick-project/kick/internal/resources/client"
 	"github.com/kick-project/kick/internal/resources/config"
+	"github.com/kick-project/kick/internal/resources/config/configtemplate"
 	"github.com/kick-project/kick/internal/resources/errs"
 	"github.com/kick-project/kick/internal/resources/exit"
 	"github.com/kick-project/kick/internal/resources/file"
+	"github.com/kick-project/kick/internal/resources/kickignore"
 	"github.com/kick-project/kick/internal/resources/logger"
 	"github.com/kick-project/kick/internal/resources/marshal"
 	"github.com/kick-project/kick/internal/resources/modeline"
 	"github.com/kick-project/kick/internal/resources/template/renderer"
 	"github.com/kick-project/kick/internal/resources/template/variables"
+	"github.com/kick-project/kick/internal/resources/templatescan"
 )
 
+// AnswersFile is the file written to the root of a generated project that
+// holds the template variables used to generate it. It can be passed back to
+// "kick start --vars-file" to reproduce the project.
+const AnswersFile = ".kick-answers.yml"
+
 const (
 	// MLnone file does not have a mode line.
 	MLnone = iota
@@ -48,34 +56,58 @@ type Template struct {
 	modeLineLen    uint8
 	nounset        bool
 	noempty        bool
+	delims         []string
 	renderCurrent  string
-	renderersAvail map[string]renderer.Renderer
+	renderDefault  string
+	renderersAvail *renderer.Registry
+	scan           *templatescan.Scan
 	stderr         io.Writer
+	stdin          *bufio.Reader
 	stdout         io.Writer
 	templateDir    string
 	vars           *variables.Variables
 	builddir       string
+	check          bool
+	commit         string
 	dest           string
+	dryrun         bool
+	failures       []error
+	layers         []layer
 	localpath      string
+	labels         []string
+	merge          string
+	missing        []renderer.Missing
+	noHooks        bool
+	includeHooks   bool
+	mergeResult    *file.MergeResult
+	plan           []PlanEntry
+	ref            string
+	samples        bool
+	showContent    bool
 	src            string
+	srcConf        config.Template
+	strict         bool
 }
 
 // Options options to constructor
 type Options struct {
-	Checkvars      *checkvars.Check             // Not required
-	Client         *client.Client               `validate:"required"`
-	Config         *config.File                 `validate:"required"`
-	Errs           *errs.Handler                `validate:"required"`
-	Exit           *exit.Handler                `validate:"required"`
-	Log            logger.OutputIface           `validate:"required"`
-	NoUnset        bool                         // No unset variables
-	NoEmpty        bool                         // No empty variables
-	RenderCurrent  string                       `validate:"required"`
-	RenderersAvail map[string]renderer.Renderer `validate:"required"`
-	Stderr         io.Writer                    `validate:"required"`
-	Stdout         io.Writer                    `validate:"required"`
-	TemplateDir    string                       `validate:"required"`
-	Variables      *variables.Variables         // Not required
+	Checkvars      *checkvars.Check     // Not required
+	Client         *client.Client       `validate:"required"`
+	Config         *config.File         `validate:"required"`
+	Errs           *errs.Handler        `validate:"required"`
+	Exit           *exit.Handler        `validate:"required"`
+	Log            logger.OutputIface   `validate:"required"`
+	NoUnset        bool                 // No unset variables
+	NoEmpty        bool                 // No empty variables
+	RenderCurrent  string               `validate:"required"`
+	RenderersAvail *renderer.Registry   `validate:"required"`
+	Samples        bool                 // Give declared variables that are not set sample values instead of prompting
+	Scan           *templatescan.Scan   `validate:"required,structonly"`
+	Stderr         io.Writer            `validate:"required"`
+	Stdin          io.Reader            // Not required. Defaults to os.Stdin
+	Stdout         io.Writer            `validate:"required"`
+	TemplateDir    string               `validate:"required"`
+	Variables      *variables.Variables // Not required
 	ModeLineLen    uint8
 }
 
@@ -94,6 +126,10 @@ func New(opts *Options) *Template {
 	} else {
 		modeLineLen = opts.ModeLineLen
 	}
+	stdin := opts.Stdin
+	if stdin == nil {
+		stdin = os.Stdin
+	}
 	return &Template{
 		checkvars:      opts.Checkvars,
 		client:         opts.Client,
@@ -105,8 +141,12 @@ func New(opts *Options) *Template {
 		nounset:        opts.NoUnset,
 		noempty:        opts.NoEmpty,
 		renderCurrent:  opts.RenderCurrent,
+		renderDefault:  opts.RenderCurrent,
 		renderersAvail: opts.RenderersAvail,
+		samples:        opts.Samples,
+		scan:           opts.Scan,
 		stderr:         opts.Stderr,
+		stdin:          bufio.NewReader(stdin),
 		stdout:         opts.Stdout,
 		templateDir:    opts.TemplateDir,
 		vars:           opts.Variables,
@@ -122,25 +162,55 @@ func (t *Template) chkvars(fp string) {
 		}
 	}
 
+	if t.vars == nil {
+		t.vars = variables.New()
+	}
+	if t.samples {
+		t.sampleVars(fp)
+		return
+	}
+
 	f, err := os.Open(fp)
 	t.errs.FatalF(`error opening %s: %w`, fp, err)
-	ok, err := t.checkvars.Check(f)
+	defer f.Close()
+	if t.dryrun {
+		// A dry run does not prompt. Variables that are not set get a sample value
+		err = t.checkvars.Validate(f, t.vars)
+		t.errs.FatalF(`error checking vars: %w`, err)
+		t.sampleVars(fp)
+		return
+	}
+	ok, err := t.checkvars.Prompt(f, t.vars)
 	t.errs.FatalF(`error checking vars: %w`, err)
 	if !ok {
 		t.exit.Exit(-1)
 	}
 }
 
+// sampleVars gives the variables declared in the `.kick.yml` at fp that are
+// not set a sample value. See configtemplate.Env.Sample.
+func (t *Template) sampleVars(fp string) {
+	conf := configtemplate.TemplateMain{}
+	err := marshal.FromFile(&conf, fp)
+	t.errs.FatalF(`error checking vars: %w`, err)
+	for _, name := range conf.EnvNames() {
+		if v, ok := t.vars.Lookup(name); ok && v != "" {
+			continue
+		}
+		t.vars.SetVariable(name, conf.Envs[name].Sample(name))
+	}
+}
+
 // SetRender set rendering engine
 func (t *Template) SetRender(renderer string) {
 	if renderer == "" {
 		t.log.Error("No renderer provided\n")
-		exit.Exit(255)
+		t.exit.Exit(255)
 	}
 
-	if _, ok := t.renderersAvail[t.renderCurrent]; !ok {
-		t.log.Errorf("No such renderer %s. Valid options are...\n", t.renderCurrent)
-		for r := range t.renderersAvail {
+	if _, ok := t.renderersAvail.Get(renderer); !ok {
+		t.log.Errorf("No such renderer %s. Valid options are...\n", renderer)
+		for _, r := range t.renderersAvail.Names() {
 			fmt.Fprintln(t.stdout, r)
 		}
 		t.exit.Exit(255)
@@ -152,20 +222,101 @@ func (t *Template) SetVars(vars *variables.Variables) {
 	t.vars = vars
 }
 
+// SetDryRun when dryrun is true Run produces a plan without writing the
+// destination. If content is true the plan includes the rendered content of
+// each rendered file. A dry run does not prompt for variables, variables that
+// are not set are given a sample value, and does not abort when the
+// destination exists. Files that exist are marked as conflicts in the plan.
+// Call before SetSrcDest, which prompts for variables.
+func (t *Template) SetDryRun(dryrun, content bool) {
+	t.dryrun = dryrun
+	t.showContent = content
+}
+
+// Plan returns the plan produced by the last call to Run.
+func (t *Template) Plan() []PlanEntry {
+	return t.plan
+}
+
+// SetMerge sets the conflict policy used to render into an existing
+// destination. See the file.Conflict* constants for valid policies. An empty
+// policy disables merging and Run aborts if the destination exists.
+func (t *Template) SetMerge(policy string) {
+	t.merge = policy
+}
+
+// MergeResult returns the files affected by the last call to Run when merging
+// into an existing destination.
+func (t *Template) MergeResult() *file.MergeResult {
+	return t.mergeResult
+}
+
+// SetNoHooks when true hooks declared in `.kick.yml` are not run.
+func (t *Template) SetNoHooks(nohooks bool) {
+	t.noHooks = nohooks
+}
+
+// SetStrict when true generation fails if a rendered file or path uses a
+// variable that is not set or is empty. Every such variable is reported.
+// Modelines with an unknown option or an illegal character, which are
+// otherwise ignored, also fail the generation.
+func (t *Template) SetStrict(strict bool) {
+	if strict {
+		t.nounset = true
+		t.noempty = true
+		t.strict = true
+	}
+}
+
+// SetIncludeHooks when true hooks declared by included templates are run as
+// well as the hooks of the template itself. Included templates may come from
+// third parties so their hooks are skipped by default.
+func (t *Template) SetIncludeHooks(include bool) {
+	t.includeHooks = include
+}
+
+// SetRef generate from ref instead of the ref the template is pinned to. An
+// empty ref uses the pinned ref. Must be called before SetSrc.
+func (t *Template) SetRef(ref string) {
+	t.ref = ref
+}
+
+// SetLabels only generate files without labels or with one of labels. The
+// label "all" generates every file. See templatescan for how files are
+// labelled.
+func (t *Template) SetLabels(labels []string) {
+	t.labels = labels
+}
+
 func (t *Template) renderer() renderer.Renderer {
 	if t.renderCurrent == "" {
 		panic("no render")
 	}
 
-	render, ok := t.renderersAvail[t.renderCurrent]
-	if ok {
+	render, err := t.engine(t.renderCurrent)
+	if err == nil {
 		return render
 	}
-	t.log.Printf("No such renderer %s\n", t.renderCurrent)
+	t.log.Printf("%v\n", err)
 	t.exit.Exit(255)
 	return nil
 }
 
+// engine returns the renderer registered as name using the delimiters of the
+// current layer. Layers with delimiters are checked to use a renderer.Delimited
+// renderer when they are resolved. Other renderers, selected with the engine
+// modeline option, keep their own delimiters.
+func (t *Template) engine(name string) (renderer.Renderer, error) {
+	render, ok := t.renderersAvail.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("no such renderer %s", name)
+	}
+	if d, ok := render.(renderer.Delimited); ok && len(t.delims) == 2 {
+		render = d.Delims(t.delims[0], t.delims[1])
+	}
+	return render, nil
+}
+
 func (t *Template) buildDir(id string) {
 	d, err := os.MkdirTemp(os.Getenv("TEMP"), fmt.Sprintf("kick-%s-", id))
 	t.errs.PanicF("build error: %v", err)
@@ -182,7 +333,6 @@ func (t *Template) SetSrcDest(src, dest string) {
 // SetSrc sets the source template "name". "name" is defined
 // in *config.Config.TemplateURLs. *config.Config is provided as an Option to New.
 func (t *Template) SetSrc(name string) {
-	t.buildDir(name)
 	var tmpl config.Template
 	for _, tconf := range t.config.Templates {
 		if tconf.Handle == name {
@@ -190,10 +340,23 @@ func (t *Template) SetSrc(name string) {
 			break
 		}
 	}
-	p, err := t.client.GetTemplate(tmpl.URL, "")
+	if t.ref != "" {
+		tmpl.Ref = t.ref
+	}
+	p, err := t.client.GetTemplate(tmpl.URL, tmpl.Ref)
 	t.errs.FatalF(`handle "%s" not found: %v`, name, err)
-	localpath := p.Path()
+	commit, err := t.client.Head(p)
+	t.errs.LogF(`can not read commit of handle "%s": %v`, name, err)
+
+	t.SetSrcPath(name, p.Path())
+	t.srcConf = tmpl
+	t.commit = commit
+}
 
+// SetSrcPath sets the source template to the template at localpath, E.G. a
+// checkout of a template that is not installed. name stands for the template
+// in messages and the plan.
+func (t *Template) SetSrcPath(name, localpath string) {
 	// Check for missing variables
 	y := filepath.Join(localpath, `.kick.yml`)
 	t.chkvars(y)
@@ -210,8 +373,20 @@ func (t *Template) SetSrc(name string) {
 		t.exit.Exit(-1)
 	}
 
+	// Resolve included templates
+	layers, err := t.resolveLayers(layer{
+		name:     name,
+		path:     filepath.Clean(localpath),
+		renderer: t.renderCurrent,
+	}, []layer{}, map[string]bool{})
+	t.errs.FatalF(`include error: %v`, err)
+
+	t.buildDir(name)
 	t.src = name
+	t.srcConf = config.Template{}
+	t.commit = ""
 	t.localpath = localpath
+	t.layers = layers
 }
 
 // SetDest sets the destination path
@@ -219,49 +394,314 @@ func (t *Template) SetDest(dest string) {
 	t.dest = dest
 }
 
-// Run generates the target directory structure
+// Run generates the target directory structure. Included templates are
+// applied first in the order they are listed, followed by the template itself.
+// Files from later layers replace files from earlier layers.
 func (t *Template) Run() int {
-	path := t.localpath
-	base := t.localpath
-	skipRegex, err := regexp.Compile(fmt.Sprintf(`^%s/.git(?:/|$)`, base))
+	if t.merge == "" && !t.dryrun {
+		t.checkDstExists()
+	}
+	err := t.build()
+	var merr *renderer.MissingError
+	if errors.As(err, &merr) {
+		t.log.Error(merr.Error() + "\n")
+		err = os.RemoveAll(t.builddir)
+		t.errs.LogF("can not remove build directory %s: %v", t.builddir, err)
+		return 255
+	} else if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Abort creating project: %s", err.Error())
+		return 255
+	}
+
+	if t.dryrun {
+		t.planConflicts()
+		err = os.RemoveAll(t.builddir)
+		t.errs.LogF("can not remove build directory %s: %v", t.builddir, err)
+		return 0
+	}
+
+	t.saveAnswers()
+	err = t.runHooks(HookPreGenerate, t.builddir)
+	if err == nil {
+		err = t.saveManifest()
+	}
+	if err != nil {
+		t.log.Error(err.Error())
+		err = os.RemoveAll(t.builddir)
+		t.errs.LogF("can not remove build directory %s: %v", t.builddir, err)
+		return 255
+	}
+
+	if t.merge != "" {
+		t.mergeResult, err = file.MergeAll(t.builddir, t.dest, t.merge, t.promptConflict)
+		t.errs.PanicF("build error: %v", err)
+		t.log.Printf(`merged project handle:%s -> project:%s`, t.src, t.dest)
+	} else {
+		err = file.MoveAll(t.builddir, t.dest)
+		t.errs.PanicF("build error: %v", err)
+		t.log.Printf(`created project handle:%s -> project:%s`, t.src, t.dest)
+	}
+
+	err = t.runHooks(HookPostGenerate, t.dest)
+	if err != nil {
+		t.log.Error(err.Error())
+		t.rollback()
+		return 255
+	}
+	return 0
+}
+
+// Check renders the template into the build directory as a dry run of Run
+// would, without reading the destination or running hooks. Unlike Run, a file
+// that fails to render does not abort the generation. The error of each such
+// file is returned as a *FileError, followed by a *renderer.MissingError of the
+// variables that are not set.
+func (t *Template) Check() []error {
+	t.check = true
+	defer func() {
+		t.check = false
+		err := os.RemoveAll(t.builddir)
+		t.errs.LogF("can not remove build directory %s: %v", t.builddir, err)
+	}()
+	err := t.build()
+	errs := append([]error{}, t.failures...)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// build renders every layer into the build directory. Variables that are not
+// set are returned as a *renderer.MissingError once every layer is rendered.
+func (t *Template) build() error {
+	t.plan = []PlanEntry{}
+	t.mergeResult = nil
+	t.missing = nil
+	t.failures = nil
+	for _, l := range t.layers {
+		t.renderCurrent = l.renderer
+		if t.renderCurrent == "" {
+			t.renderCurrent = t.renderDefault
+		}
+		t.delims = l.delims
+		err := t.applyLayer(l)
+		if err != nil {
+			return err
+		}
+	}
+	if len(t.missing) > 0 {
+		return &renderer.MissingError{Missing: t.missing}
+	}
+	return nil
+}
+
+// applyLayer renders the files of a layer into the build directory
+func (t *Template) applyLayer(l layer) error {
+	path := l.path
+	base := l.path
+	skipRegex, err := regexp.Compile(fmt.Sprintf(`^%s/.git(?:/|$)`, regexp.QuoteMeta(base)))
 	t.errs.PanicF("build error: %v", err)
-	t.checkDstExists()
-	errWalk := filepath.Walk(path, func(srcPath string, info os.FileInfo, err error) error {
+	labels, err := t.layerLabels(l)
+	if err != nil {
+		return fmt.Errorf("can not scan labels in %s: %w", l.path, err)
+	}
+	return filepath.Walk(path, func(srcPath string, info os.FileInfo, err error) error {
 		if skipRegex.MatchString(srcPath) {
 			return nil
 		}
 		relative := strings.Replace(srcPath, base, "", 1)
-		relative = t.renderDir(relative)
-		dstPath := filepath.Join(t.builddir, relative)
+		slashPath := filepath.ToSlash(strings.TrimPrefix(relative, string(filepath.Separator)))
 
 		if err != nil {
 			_, _ = fmt.Fprintf(os.Stderr, "Warning: skipping file %s: %s", srcPath, err.Error())
 			return nil
 		}
 
+		// Paths that are not generated are not rendered
+		if l.ignore.Match(slashPath, info.IsDir()) {
+			t.addPlan(relative, "", ActionIgnore, l.name, "")
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !t.included(l, labels[slashPath]) {
+			t.addPlan(relative, "", ActionExclude, l.name, "")
+			return nil
+		}
+
+		relative = t.renderDir(relative, t.missingFile(l, slashPath))
+		dstPath := filepath.Join(t.builddir, relative)
+		// The parent may have been excluded by its labels
+		if !info.IsDir() {
+			err = os.MkdirAll(filepath.Dir(dstPath), 0755)
+			t.errs.PanicF("build error: %v", err)
+		}
+
+		linkDst := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			linkDst, err = os.Readlink(srcPath)
+			t.errs.PanicF("build error: %v", err)
+			linkDst = t.renderDir(linkDst, t.missingFile(l, slashPath))
+		}
+
+		render, _ := l.rules.Match(slashPath, info.IsDir())
+
 		pair := filePair{
 			errs:      t.errs,
+			glob:      render,
+			linkDst:   linkDst,
+			root:      t.builddir,
 			srcInfo:   info,
 			srcPath:   srcPath,
 			dstPath:   dstPath,
 			variables: t.vars,
+			lint:      t.strict,
 			mlen:      t.modeLineLen,
+			nounset:   t.nounset,
+			noempty:   t.noempty,
+			engine:    t.renderCurrent,
+			engines:   t.engine,
 			renderer:  t.renderer(),
 		}
-		err = pair.route()
+		action, err := pair.route()
+		if t.addMissing(err, t.missingFile(l, slashPath)) {
+			return nil
+		}
+		if err != nil && t.check {
+			t.failures = append(t.failures, &FileError{File: t.missingFile(l, slashPath), Path: srcPath, Err: err})
+			return nil
+		}
 		t.errs.PanicF("build error: %v", err)
+		if pair.dstPath != dstPath {
+			// Renamed by the target modeline option
+			relative = filepath.Join(filepath.Dir(relative), filepath.Base(pair.dstPath))
+			dstPath = pair.dstPath
+		}
+		t.addPlan(relative, dstPath, action, l.name, pair.engine)
 
 		return nil
 	})
-	if errWalk != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "Abort creating project: %s", errWalk.Error())
-		return 255
+}
+
+// missingFile returns the name of a template file used to report missing
+// variables. Files of included templates are prefixed with the include.
+func (t *Template) missingFile(l layer, slashPath string) string {
+	if l.name == t.src || t.src == "" {
+		return slashPath
 	}
+	return l.name + ":" + slashPath
+}
 
-	err = file.MoveAll(t.builddir, t.dest)
-	t.errs.PanicF("build error: %v", err)
-	t.log.Printf(`created project handle:%s -> project:%s`, t.src, t.dest)
-	return 0
+// addMissing records the variables of a *renderer.MissingError against file
+// and returns true. Returns false for any other error. Variables already
+// reported in the name of a parent directory are not reported again.
+func (t *Template) addMissing(err error, file string) bool {
+	var merr *renderer.MissingError
+	if !errors.As(err, &merr) {
+		return false
+	}
+	for _, m := range merr.Missing {
+		m.File = file
+		if !t.parentMissing(m) {
+			t.missing = append(t.missing, m)
+		}
+	}
+	return true
+}
+
+func (t *Template) parentMissing(m renderer.Missing) bool {
+	for _, p := range t.missing {
+		if p.Name == m.Name && strings.HasPrefix(m.File, p.File+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// promptConflict asks which conflict policy to apply to path. The file is
+// skipped if stdin is closed.
+func (t *Template) promptConflict(path string) string {
+	for {
+		fmt.Fprintf(t.stdout, "%s exists and differs. [s]kip, [o]verwrite, [k]eep both: ", path)
+		line, err := t.stdin.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "s", "skip":
+			return file.ConflictSkip
+		case "o", "overwrite":
+			return file.ConflictOverwrite
+		case "k", "keep", "keep-both":
+			return file.ConflictKeepBoth
+		}
+		if err != nil {
+			fmt.Fprintln(t.stdout)
+			return file.ConflictSkip
+		}
+	}
+}
+
+// addPlan adds a destination path to the plan. relative is the path relative
+// to the project root and build is its location in the build directory. engine
+// is the renderer used for rendered files. An entry for a path already in the
+// plan, from an earlier layer, is replaced.
+func (t *Template) addPlan(relative, build, action, layerName, engine string) {
+	entry := PlanEntry{
+		Path:   filepath.Join(t.dest, relative),
+		Action: action,
+		Layer:  layerName,
+	}
+	if action == ActionRender {
+		entry.Renderer = engine
+		if t.dryrun && t.showContent {
+			b, err := os.ReadFile(build)
+			t.errs.LogF("can not read rendered file %s: %v", build, err)
+			entry.Content = string(b)
+		}
+	}
+	for i, e := range t.plan {
+		if e.Path != entry.Path {
+			continue
+		}
+		// Keep files written by an earlier layer that this layer does not write
+		if !t.planWrites(entry.Action) && t.planWrites(e.Action) {
+			return
+		}
+		t.plan[i] = entry
+		return
+	}
+	t.plan = append(t.plan, entry)
+}
+
+// planConflicts marks the entries of the plan that write to a path that exists
+// in the destination. Existing directories are not conflicts of mkdir.
+func (t *Template) planConflicts() {
+	for i, e := range t.plan {
+		if !t.planWrites(e.Action) {
+			continue
+		}
+		info, err := os.Lstat(e.Path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		t.errs.PanicF("build error: %v", err)
+		t.plan[i].Conflict = !(info.IsDir() && e.Action == ActionMkdir)
+	}
+}
+
+// planWrites returns true if action writes to the build directory
+func (t *Template) planWrites(action string) bool {
+	return action != ActionIgnore && action != ActionSkip && action != ActionExclude
+}
+
+// saveAnswers writes the template variables to AnswersFile in the build
+// directory so it is moved or merged with the rest of the project.
+func (t *Template) saveAnswers() {
+	if t.vars == nil || len(t.vars.Vars) == 0 {
+		return
+	}
+	p := filepath.Join(t.builddir, AnswersFile)
+	err := t.vars.SaveFile(p)
+	t.errs.LogF("can not save answers file %s: %v", p, err)
 }
 
 func (t *Template) checkDstExists() {
@@ -275,15 +715,19 @@ func (t *Template) checkDstExists() {
 	}
 }
 
-// renderDir scans directory names for template markers and renders the directory path as a template
-func (t *Template) renderDir(path string) string {
+// renderDir scans directory names for template markers and renders the directory path as a template.
+// Missing variables are reported against file.
+func (t *Template) renderDir(path, file string) string {
 	regex := t.renderer().RenderDirRegexp()
 	if !regex.MatchString(path) {
 		return path
 	}
-	path, err := t.renderer().Text2String(path, t.vars, true, true)
+	rendered, err := t.renderer().Text2String(path, t.vars, t.nounset, t.noempty)
+	if t.addMissing(err, file) {
+		return path
+	}
 	t.errs.FatalF("can not substitute path string \"%s\": %v", path, err)
-	return path
+	return rendered
 }
 
 // loadTemplateConf loads template configuration
@@ -310,13 +754,20 @@ func (t *Template) loadTempateConf(path string) {
 	if c.Renderer != "" {
 		t.SetRender(c.Renderer)
 	}
+	t.SetStrict(c.Strict)
 }
 
 // Source Destination pair
 type filePair struct {
 	dstPath   string // Destination path
+	engine    string // Name of renderer. Set by the engine modeline option
+	engines   func(name string) (renderer.Renderer, error)
+	glob      bool   // Render when there is no modeline. See configtemplate.RenderRules
+	linkDst   string // Rendered target of a symlink
+	lint      bool   // Reject modelines with unknown options or illegal characters
 	mlen      uint8  // Mode line length
 	errs      *errs.Handler
+	root      string // Project root. Symlinks must not point outside of it
 	mu        sync.Mutex
 	nounset   bool
 	noempty   bool
@@ -326,24 +777,73 @@ type filePair struct {
 	variables *variables.Variables
 }
 
-func (fp *filePair) route() error {
-	ml, lnum := fp.hasModeLine()
+// route performs the action needed to create dstPath and returns the action
+// taken.
+func (fp *filePair) route() (string, error) {
+	if fp.srcInfo.Mode()&os.ModeSymlink != 0 {
+		return ActionSymlink, fp.symlink()
+	}
+	ml, lnum, err := fp.hasModeLine()
+	if err != nil {
+		return "", err
+	}
 	switch {
 	case fp.srcInfo.IsDir():
-		return fp.mkdir()
+		return ActionMkdir, fp.mkdir()
 	case fp.skipFile():
-		return nil
+		return ActionSkip, nil
 	case lnum > 0 && ml != nil && ml.Option("render"):
-		err := fp.render(lnum)
-		fp.errs.Panic(err)
+		err := fp.modelineOptions(ml, lnum)
+		if err != nil {
+			return "", err
+		}
+		return ActionRender, fp.render(lnum)
 	case lnum > 0 && ml != nil && ml.Option("ignore"):
-		return nil
+		return ActionIgnore, nil
+	case fp.glob && fp.srcInfo.Mode().IsRegular():
+		return ActionRender, fp.render(0)
 	case fp.srcInfo.Mode().IsRegular():
-		return fp.copy()
+		return ActionCopy, fp.copy()
 	default:
 		msg := fmt.Sprintf("error FILENOTREGULAR: %s\n", fp.dstPath)
-		return errors.New(msg)
+		return "", errors.New(msg)
+	}
+}
+
+// modelineOptions applies the engine, delims and target modeline options of
+// the modeline at line lnum. A target replaces the name of dstPath and is
+// rendered like other file names.
+func (fp *filePair) modelineOptions(ml *modeline.ModeLine, lnum uint8) error {
+	if ml.Engine() != "" {
+		r, err := fp.engines(ml.Engine())
+		if err != nil {
+			return &ModelineError{Path: fp.srcPath, Line: int(lnum), Err: err}
+		}
+		fp.engine = ml.Engine()
+		fp.renderer = r
+	}
+	if left, right, ok := ml.Delims(); ok {
+		d, ok := fp.renderer.(renderer.Delimited)
+		if !ok {
+			return &ModelineError{Path: fp.srcPath, Line: int(lnum), Err: fmt.Errorf("renderer %s does not support delims", fp.engine)}
+		}
+		fp.renderer = d.Delims(left, right)
+	}
+	target := ml.Target()
+	if target == "" {
+		return nil
+	}
+	if fp.renderer.RenderDirRegexp().MatchString(target) {
+		rendered, err := fp.renderer.Text2String(target, fp.variables, fp.nounset, fp.noempty)
+		if err != nil {
+			return err
+		}
+		target = rendered
+	}
+	if target == "" || target != filepath.Base(target) {
+		return &ModelineError{Path: fp.srcPath, Line: int(lnum), Err: fmt.Errorf(`invalid target "%s"`, target)}
 	}
+	fp.dstPath = filepath.Join(filepath.Dir(fp.dstPath), target)
 	return nil
 }
 
@@ -353,6 +853,10 @@ func (fp *filePair) skipFile() bool {
 	switch {
 	case strings.HasSuffix(fp.srcPath, ".kick.yml"):
 		rvalue = true
+	case strings.HasSuffix(fp.srcPath, kickignore.File):
+		rvalue = true
+	case filepath.Base(fp.srcPath) == ManifestFile:
+		rvalue = true
 	}
 	return rvalue
 }
@@ -364,7 +868,30 @@ func (fp *filePair) mkdir() error {
 		err = os.Mkdir(fp.dstPath, 0755)
 		fp.errs.PanicF("build error: %v", err)
 	}
-	return nil
+	// Owner keeps write access so the contents can be written
+	return os.Chmod(fp.dstPath, fp.srcInfo.Mode().Perm()|0700)
+}
+
+// symlink recreates a relative symlink. Absolute links and links that resolve
+// outside of the project root are not supported as they would point outside of
+// the project.
+func (fp *filePair) symlink() error {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	if filepath.IsAbs(fp.linkDst) {
+		return fmt.Errorf("symlink %s: absolute target %s is not supported", fp.srcPath, fp.linkDst)
+	}
+	rel, err := filepath.Rel(fp.root, filepath.Join(filepath.Dir(fp.dstPath), fp.linkDst))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("symlink %s: target %s is outside of the project", fp.srcPath, fp.linkDst)
+	}
+	if _, err := os.Lstat(fp.dstPath); err == nil {
+		err = os.Remove(fp.dstPath)
+		if err != nil {
+			return err
+		}
+	}
+	return os.Symlink(fp.linkDst, fp.dstPath)
 }
 
 func (fp *filePair) copy() error {
@@ -391,7 +918,10 @@ func (fp *filePair) copy() error {
 	}
 	defer destination.Close() // nolint
 	_, err = io.Copy(destination, source)
-	return err
+	if err != nil {
+		return err
+	}
+	return fp.chmod()
 }
 
 func (fp *filePair) stripModeline(lnum uint8) string {
@@ -433,13 +963,32 @@ func (fp *filePair) render(mline uint8) error {
 	}()
 
 	err := fp.renderer.File2File(tempPath, fp.dstPath, fp.variables, fp.nounset, fp.noempty)
-	fp.errs.Panic(err)
-	return nil
+	var merr *renderer.MissingError
+	if errors.As(err, &merr) {
+		// Line numbers after the modeline are off by one
+		for i := range merr.Missing {
+			if mline > 0 && merr.Missing[i].Line >= int(mline) {
+				merr.Missing[i].Line++
+			}
+		}
+		return err
+	} else if err != nil {
+		return fmt.Errorf("%s: %w", fp.srcPath, err)
+	}
+	return fp.chmod()
 }
 
-// hasModeLine scans the first mlen lines for a modeline
-// returns MLnone, MLrender depending on defined action
-func (fp *filePair) hasModeLine() (ml *modeline.ModeLine, lnum uint8) {
+// chmod gives dstPath the permissions of the source file. Renderers write
+// through temp files so generated files would otherwise only be readable by
+// the owner.
+func (fp *filePair) chmod() error {
+	return os.Chmod(fp.dstPath, fp.srcInfo.Mode().Perm())
+}
+
+// hasModeLine scans the first mlen lines for a modeline and returns it with
+// its line number. An invalid modeline, E.G. a bad delims or target option, is
+// returned as a *ModelineError.
+func (fp *filePair) hasModeLine() (ml *modeline.ModeLine, lnum uint8, err error) {
 	len := fp.mlen
 	source, err := os.Open(fp.srcPath)
 	fp.errs.FatalF("Can not open file %s: %v", fp.srcPath, err)
@@ -449,17 +998,22 @@ func (fp *filePair) hasModeLine() (ml *modeline.ModeLine, lnum uint8) {
 	for scner.Scan() {
 		lnum++
 		line := scner.Bytes()
-		ml, err := modeline.Parse("", line, 1)
-		if ml != nil {
-			return ml, lnum
-		} else if err != nil {
-			return nil, 0
+		if fp.lint {
+			err = modeline.Lint("", line, 1)
+		}
+		if err == nil {
+			ml, err = modeline.Parse("", line, 1)
+		}
+		if err != nil {
+			return nil, 0, &ModelineError{Path: fp.srcPath, Line: int(lnum), Err: unprefixed(err)}
+		} else if ml != nil {
+			return ml, lnum, nil
 		}
 		if lnum > len {
-			return nil, 0
+			return nil, 0, nil
 		}
 	}
-	return nil, 0
+	return nil, 0, nil
 }
 
 //
@@ -468,6 +1022,7 @@ func (fp *filePair) hasModeLine() (ml *modeline.ModeLine, lnum uint8) {
 
 type templateConf struct {
 	Renderer string `yaml:"renderer"`
+	Strict   bool   `yaml:"strict"`
 }
 
 // scanLines is a split function for a Scanner that returns each line of
@@ -3,6 +3,7 @@
 package template
 
 import (
+	"github.com/kick-project/kick/internal/resources/file"
 	"github.com/kick-project/kick/internal/resources/template/variables"
 )
 
@@ -11,14 +12,55 @@ type TemplateIface interface {
 	// SetRender set rendering engine
 	SetRender(renderer string)
 	SetVars(vars *variables.Variables)
+	// SetDryRun when dryrun is true Run produces a plan without writing the
+	// destination. If content is true the plan includes the rendered content of
+	// each rendered file.
+	SetDryRun(dryrun, content bool)
+	// Plan returns the plan produced by the last call to Run.
+	Plan() []PlanEntry
+	// SetMerge sets the conflict policy used to render into an existing
+	// destination. See the file.Conflict* constants for valid policies. An empty
+	// policy disables merging and Run aborts if the destination exists.
+	SetMerge(policy string)
+	// MergeResult returns the files affected by the last call to Run when merging
+	// into an existing destination.
+	MergeResult() *file.MergeResult
+	// SetNoHooks when true hooks declared in `.kick.yml` are not run.
+	SetNoHooks(nohooks bool)
+	// SetIncludeHooks when true hooks declared by included templates are run as
+	// well as the hooks of the template itself. Included templates may come from
+	// third parties so their hooks are skipped by default.
+	SetIncludeHooks(include bool)
+	// SetStrict when true generation fails if a rendered file or path uses a
+	// variable that is not set or is empty. Every such variable is reported.
+	// Modelines with an unknown option or an illegal character, which are
+	// otherwise ignored, also fail the generation.
+	SetStrict(strict bool)
+	// SetRef generate from ref instead of the ref the template is pinned to. An
+	// empty ref uses the pinned ref. Must be called before SetSrc.
+	SetRef(ref string)
+	// SetLabels only generate files without labels or with one of labels. The
+	// label "all" generates every file. See templatescan for how files are
+	// labelled.
+	SetLabels(labels []string)
 	// SetSrcDest sets the source template and destination path where the project structure
 	// will reside.
 	SetSrcDest(src, dest string)
 	// SetSrc sets the source template "name". "name" is defined
 	// in *config.Config.TemplateURLs. *config.Config is provided as an Option to New.
 	SetSrc(name string)
+	// SetSrcPath sets the source template to the template at localpath, E.G. a
+	// checkout of a template that is not installed. name stands for the template
+	// in messages and the plan.
+	SetSrcPath(name, localpath string)
 	// SetDest sets the destination path
 	SetDest(dest string)
 	// Run generates the target directory structure
 	Run() int
+	// Check renders the template into the build directory as a dry run of Run
+	// would, without reading the destination or running hooks. Unlike Run, a file
+	// that fails to render does not abort the generation. The error of each such
+	// file is returned as a *FileError, followed by a *renderer.MissingError of the
+	// variables that are not set.
+	Check() []error
 }
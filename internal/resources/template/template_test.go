@@ -1 +1,448 @@
 package template_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kick-project/kick/internal/di"
+	"github.com/kick-project/kick/internal/resources/config"
+	"github.com/kick-project/kick/internal/resources/exit"
+	"github.com/kick-project/kick/internal/resources/file"
+	"github.com/kick-project/kick/internal/resources/kickignore"
+	"github.com/kick-project/kick/internal/resources/template"
+	"github.com/kick-project/kick/internal/resources/template/variables"
+	"github.com/kick-project/kick/internal/resources/testtools"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplate_Includes(t *testing.T) {
+	tmpl, _ := makeTemplate(t, "layerapp", filepath.Join(testtools.FixtureDir(), "layers", "app"))
+	dest := filepath.Join(mkdtemp(t), "layerproject")
+
+	vars := variables.New()
+	vars.ProjectVariable("NAME", "layerproject")
+	tmpl.SetVars(vars)
+	tmpl.SetSrcDest("layerapp", dest)
+	assert.Equal(t, 0, tmpl.Run())
+
+	for p, want := range map[string]string{
+		"README.md":     "# layerproject\n",
+		"Makefile":      "build:\n\tgo build ./...\n",
+		".editorconfig": "root = true\n",
+		"ci.yml":        "name: ci\n",
+	} {
+		b, err := os.ReadFile(filepath.Join(dest, p))
+		assert.NoError(t, err, p)
+		assert.Equal(t, want, string(b), p)
+	}
+	assert.NoFileExists(t, filepath.Join(dest, "Dockerfile"))
+
+	layers := map[string]string{}
+	for _, e := range tmpl.Plan() {
+		rel, _ := filepath.Rel(dest, e.Path)
+		layers[rel] = e.Layer
+	}
+	assert.Equal(t, "layerapp", layers["README.md"])
+	assert.Equal(t, "../base", layers["Makefile"])
+	assert.Equal(t, "../fragments", layers["ci.yml"])
+}
+
+func TestTemplate_Includes_Cycle(t *testing.T) {
+	tmpl, stderr := makeTemplate(t, "layercycle", filepath.Join(testtools.FixtureDir(), "layers", "cycle-a"))
+	assert.Panics(t, func() {
+		tmpl.SetSrcDest("layercycle", filepath.Join(testtools.TempDir(), "layercycle"))
+	})
+	assert.Contains(t, stderr.String(), "include cycle: layercycle -> ../cycle-b -> ../cycle-a")
+}
+
+// makeTemplate returns a template with the template at path installed as hdl.
+func makeTemplate(t *testing.T, hdl, path string) (*template.Template, *bytes.Buffer) {
+	home, _ := filepath.Abs(filepath.Join(testtools.TempDir(), "home"))
+	stderr := &bytes.Buffer{}
+	inject := di.New(&di.Options{
+		Home:     home,
+		ExitMode: exit.MPanic,
+		Stderr:   stderr,
+		Stdout:   &bytes.Buffer{},
+	})
+	err := inject.ConfigFile().AppendTemplate(config.Template{
+		Handle: hdl,
+		URL:    path,
+	})
+	assert.NoError(t, err)
+	return inject.MakeTemplate(), stderr
+}
+
+func TestTemplate_Hooks(t *testing.T) {
+	tmpl, stderr := makeTemplate(t, "hooksok", filepath.Join(testtools.FixtureDir(), "hooks", "ok"))
+	dest := filepath.Join(mkdtemp(t), "hookproject")
+	vars := variables.New()
+	vars.ProjectVariable("NAME", "hookproject")
+	tmpl.SetVars(vars)
+	tmpl.SetSrcDest("hooksok", dest)
+	assert.Equal(t, 0, tmpl.Run())
+
+	b, err := os.ReadFile(filepath.Join(dest, "hooked.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "hookproject\n", string(b))
+	assert.Contains(t, stderr.String(), "post_generate: line1\n")
+	assert.Contains(t, stderr.String(), "post_generate: line2\n")
+}
+
+func TestTemplate_Hooks_NoHooks(t *testing.T) {
+	tmpl, _ := makeTemplate(t, "hooksok", filepath.Join(testtools.FixtureDir(), "hooks", "ok"))
+	dest := filepath.Join(mkdtemp(t), "nohookproject")
+	tmpl.SetSrcDest("hooksok", dest)
+	tmpl.SetNoHooks(true)
+	assert.Equal(t, 0, tmpl.Run())
+
+	assert.FileExists(t, filepath.Join(dest, "README.md"))
+	assert.NoFileExists(t, filepath.Join(dest, "hooked.txt"))
+}
+
+func TestTemplate_Hooks_Includes(t *testing.T) {
+	tmpl, stderr := makeTemplate(t, "hooksinclude", filepath.Join(testtools.FixtureDir(), "hooks", "include"))
+	dest := filepath.Join(mkdtemp(t), "includehookproject")
+	tmpl.SetSrcDest("hooksinclude", dest)
+	assert.Equal(t, 0, tmpl.Run())
+
+	// Hooks of included templates are skipped by default
+	assert.FileExists(t, filepath.Join(dest, "root.txt"))
+	assert.NoFileExists(t, filepath.Join(dest, "hooked.txt"))
+	assert.Contains(t, stderr.String(), "post_generate: [hooksinclude] touch root.txt\n")
+	assert.Contains(t, stderr.String(), "post_generate: skipping 2 hooks of included templates")
+
+	tmpl, stderr = makeTemplate(t, "hooksinclude", filepath.Join(testtools.FixtureDir(), "hooks", "include"))
+	dest = filepath.Join(mkdtemp(t), "includehookproject")
+	tmpl.SetSrcDest("hooksinclude", dest)
+	tmpl.SetIncludeHooks(true)
+	assert.Equal(t, 0, tmpl.Run())
+
+	assert.FileExists(t, filepath.Join(dest, "root.txt"))
+	assert.FileExists(t, filepath.Join(dest, "hooked.txt"))
+	assert.Contains(t, stderr.String(), `post_generate: [../ok] echo "$PROJECT_NAME" > hooked.txt`)
+	assert.NotContains(t, stderr.String(), "skipping")
+}
+
+func TestTemplate_Hooks_Rollback(t *testing.T) {
+	tmpl, stderr := makeTemplate(t, "hooksfail", filepath.Join(testtools.FixtureDir(), "hooks", "fail"))
+	dest := filepath.Join(mkdtemp(t), "failproject")
+	tmpl.SetSrcDest("hooksfail", dest)
+	assert.Equal(t, 255, tmpl.Run())
+
+	assert.NoDirExists(t, dest)
+	assert.Contains(t, stderr.String(), "post_generate: failing\n")
+	assert.Contains(t, stderr.String(), `post_generate hook "echo failing >&2 && exit 3" failed: exit status 3`)
+}
+
+func mkdtemp(t *testing.T) string {
+	d, err := os.MkdirTemp(testtools.TempDir(), "TestTemplate-*")
+	assert.NoError(t, err)
+	return d
+}
+
+func TestTemplate_Labels(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		labels   []string
+		docker   string
+		included []string
+		excluded []string
+	}{
+		{"selected", []string{"ci"}, "false", []string{"README.md", ".github/workflows/ci.yml"}, []string{"Dockerfile", ".editorconfig"}},
+		{"all", []string{"all"}, "false", []string{"README.md", ".github/workflows/ci.yml", "Dockerfile", ".editorconfig"}, nil},
+		{"condition met", nil, "true", []string{"README.md", ".github/workflows/ci.yml", "Dockerfile", ".editorconfig"}, nil},
+		{"condition not met", nil, "false", []string{"README.md", ".github/workflows/ci.yml", ".editorconfig"}, []string{"Dockerfile"}},
+		{"selected and condition met", []string{"editor"}, "true", []string{"README.md", "Dockerfile", ".editorconfig"}, []string{".github/workflows/ci.yml"}},
+	} {
+		tmpl, _ := makeTemplate(t, "labeltemplate", filepath.Join(testtools.FixtureDir(), "labeltemplate"))
+		dest := filepath.Join(mkdtemp(t), "labelproject")
+		vars := variables.New()
+		vars.SetVariable("LT_DOCKER", tc.docker)
+		tmpl.SetVars(vars)
+		tmpl.SetSrcDest("labeltemplate", dest)
+		tmpl.SetLabels(tc.labels)
+		assert.Equal(t, 0, tmpl.Run(), tc.name)
+
+		for _, p := range tc.included {
+			assert.FileExists(t, filepath.Join(dest, filepath.FromSlash(p)), tc.name)
+		}
+		for _, p := range tc.excluded {
+			assert.NoFileExists(t, filepath.Join(dest, filepath.FromSlash(p)), tc.name)
+		}
+	}
+}
+
+func TestTemplate_Modes(t *testing.T) {
+	src := filepath.Join(mkdtemp(t), "modetemplate")
+	err := file.CopyAll(filepath.Join(testtools.FixtureDir(), "modetemplate"), src)
+	assert.NoError(t, err)
+	err = os.Mkdir(filepath.Join(src, "empty"), 0750)
+	assert.NoError(t, err)
+
+	tmpl, _ := makeTemplate(t, "modetemplate", src)
+	dest := filepath.Join(mkdtemp(t), "modeproject")
+	vars := variables.New()
+	vars.ProjectVariable("NAME", "modeproject")
+	tmpl.SetVars(vars)
+	tmpl.SetSrcDest("modetemplate", dest)
+	assert.Equal(t, 0, tmpl.Run())
+
+	for p, want := range map[string]os.FileMode{
+		"scripts/run.sh":   0755,
+		"scripts/build.sh": 0755,
+		"modeproject.txt":  0644,
+		"empty":            0750 | os.ModeDir,
+	} {
+		info, err := os.Lstat(filepath.Join(dest, filepath.FromSlash(p)))
+		assert.NoError(t, err, p)
+		if err == nil {
+			assert.Equal(t, want, info.Mode(), p)
+		}
+	}
+	b, err := os.ReadFile(filepath.Join(dest, "scripts", "build.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\necho modeproject\n", string(b))
+
+	for p, want := range map[string]string{
+		"latest.txt": "modeproject.txt",
+		"bin":        "scripts",
+	} {
+		link, err := os.Readlink(filepath.Join(dest, p))
+		assert.NoError(t, err, p)
+		assert.Equal(t, want, link, p)
+	}
+}
+
+func TestTemplate_Modes_AbsoluteLink(t *testing.T) {
+	src := filepath.Join(mkdtemp(t), "abslinktemplate")
+	err := os.Mkdir(src, 0755)
+	assert.NoError(t, err)
+	err = os.Symlink("/etc/hosts", filepath.Join(src, "hosts"))
+	assert.NoError(t, err)
+
+	tmpl, stderr := makeTemplate(t, "abslinktemplate", src)
+	tmpl.SetSrcDest("abslinktemplate", filepath.Join(mkdtemp(t), "abslinkproject"))
+	assert.Panics(t, func() {
+		tmpl.Run()
+	})
+	assert.Contains(t, stderr.String(), "absolute target /etc/hosts is not supported")
+}
+
+func TestTemplate_Modes_EscapingLink(t *testing.T) {
+	src := filepath.Join(mkdtemp(t), "esclinktemplate")
+	err := os.MkdirAll(filepath.Join(src, "sub"), 0755)
+	assert.NoError(t, err)
+	err = os.Symlink("../../passwd", filepath.Join(src, "sub", "passwd"))
+	assert.NoError(t, err)
+
+	tmpl, stderr := makeTemplate(t, "esclinktemplate", src)
+	tmpl.SetSrcDest("esclinktemplate", filepath.Join(mkdtemp(t), "esclinkproject"))
+	assert.Panics(t, func() {
+		tmpl.Run()
+	})
+	assert.Contains(t, stderr.String(), "target ../../passwd is outside of the project")
+}
+
+func TestTemplate_Modeline_Invalid(t *testing.T) {
+	for name, ml := range map[string]string{
+		"badtarget": "# kick:render target=sub/out.txt\n",
+		"baddelims": "# kick:render delims=<<\n",
+	} {
+		src := filepath.Join(mkdtemp(t), name+"template")
+		err := os.Mkdir(src, 0755)
+		assert.NoError(t, err)
+		err = os.WriteFile(filepath.Join(src, "file.txt"), []byte(ml+"${PROJECT_NAME}\n"), 0644)
+		assert.NoError(t, err)
+
+		tmpl, stderr := makeTemplate(t, name+"template", src)
+		dest := filepath.Join(mkdtemp(t), name+"project")
+		tmpl.SetSrcDest(name+"template", dest)
+		assert.Panics(t, func() {
+			tmpl.Run()
+		}, name)
+		assert.Contains(t, stderr.String(), "file.txt:1: modeline error: invalid", name)
+		assert.NoDirExists(t, dest, name)
+	}
+}
+
+func TestTemplate_Delims_NotSupported(t *testing.T) {
+	src := filepath.Join(mkdtemp(t), "envdelimstemplate")
+	err := os.Mkdir(src, 0755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(src, ".kick.yml"), []byte("delims: [\"<<\", \">>\"]\n"), 0644)
+	assert.NoError(t, err)
+
+	tmpl, stderr := makeTemplate(t, "envdelimstemplate", src)
+	assert.Panics(t, func() {
+		tmpl.SetSrcDest("envdelimstemplate", filepath.Join(mkdtemp(t), "envdelimsproject"))
+	})
+	assert.Contains(t, stderr.String(), "renderer envsubst does not support delims")
+}
+
+func TestTemplate_Ignore(t *testing.T) {
+	tmpl, _ := makeTemplate(t, "ignoretemplate", filepath.Join(testtools.FixtureDir(), "ignoretemplate"))
+	dest := filepath.Join(mkdtemp(t), "ignoreproject")
+	tmpl.SetSrcDest("ignoretemplate", dest)
+	assert.Equal(t, 0, tmpl.Run())
+
+	for _, p := range []string{"README.md", "sub/AUTHORING.md", "notes/keep.md"} {
+		assert.FileExists(t, filepath.Join(dest, filepath.FromSlash(p)), p)
+	}
+	for _, p := range []string{"AUTHORING.md", "logo.bin", "build/out.txt", "notes/a.txt", kickignore.File, ".kick.yml"} {
+		assert.NoFileExists(t, filepath.Join(dest, filepath.FromSlash(p)), p)
+	}
+	assert.NoDirExists(t, filepath.Join(dest, "build"))
+}
+
+func TestTemplate_RenderRules(t *testing.T) {
+	tmpl, _ := makeTemplate(t, "rendertemplate", filepath.Join(testtools.FixtureDir(), "rendertemplate"))
+	dest := filepath.Join(mkdtemp(t), "renderproject")
+	vars := variables.New()
+	vars.ProjectVariable("NAME", "renderproject")
+	tmpl.SetVars(vars)
+	tmpl.SetSrcDest("rendertemplate", dest)
+	assert.Equal(t, 0, tmpl.Run())
+
+	for p, want := range map[string]string{
+		"config.json":           "{\"name\": \"renderproject\"}\n",
+		"scripts/setup.sh":      "#!/bin/sh\n# Copyright\n# License\n# More license\n# Even more license\necho renderproject\n",
+		"testdata/fixture.json": "{\"name\": \"${PROJECT_NAME}\"}\n",
+		"testdata/modeline.txt": "renderproject\n",
+		"plain.txt":             "${PROJECT_NAME}\n",
+	} {
+		b, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(p)))
+		assert.NoError(t, err, p)
+		assert.Equal(t, want, string(b), p)
+	}
+}
+
+func TestTemplate_Functions(t *testing.T) {
+	tmpl, _ := makeTemplate(t, "functemplate", filepath.Join(testtools.FixtureDir(), "functemplate"))
+	dest := filepath.Join(mkdtemp(t), "funcproject")
+	vars := variables.New()
+	vars.ProjectVariable("NAME", "myCoolProject")
+	tmpl.SetVars(vars)
+	tmpl.SetSrcDest("functemplate", dest)
+	assert.Equal(t, 0, tmpl.Run())
+
+	for p, want := range map[string]string{
+		"my_cool_project/my-cool-project.txt": "module: my_cool_project\ndisplay: My Cool Project\nclass: MyCoolProject\nlicense: MIT\n",
+		"README.md":                           "# My Cool Project\n  indented\n",
+	} {
+		b, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(p)))
+		assert.NoError(t, err, p)
+		assert.Equal(t, want, string(b), p)
+	}
+}
+
+func TestTemplate_Strict(t *testing.T) {
+	tmpl, stderr := makeTemplate(t, "stricttemplate", filepath.Join(testtools.FixtureDir(), "stricttemplate"))
+	dest := filepath.Join(mkdtemp(t), "strictproject")
+	vars := variables.New()
+	vars.ProjectVariable("NAME", "strictproject")
+	vars.SetVariable("STRICT_EMPTY", "")
+	tmpl.SetVars(vars)
+	tmpl.SetSrcDest("stricttemplate", dest)
+	tmpl.SetStrict(true)
+	assert.Equal(t, 255, tmpl.Run())
+
+	assert.NoDirExists(t, dest)
+	assert.Contains(t, stderr.String(), "3 missing variables\n")
+	assert.Contains(t, stderr.String(), "  README.md:3: STRICT_AUTHOR is not set\n")
+	assert.Contains(t, stderr.String(), "  notes.txt:3: STRICT_EMPTY is empty\n")
+	assert.Contains(t, stderr.String(), "  ${STRICT_DIR}:1: STRICT_DIR is not set\n")
+	assert.NotContains(t, stderr.String(), "STRICT_LICENSE")
+}
+
+func TestTemplate_Strict_NotGenerated(t *testing.T) {
+	src := filepath.Join(mkdtemp(t), "strictskiptemplate")
+	for name, content := range map[string]string{
+		".kick.yml":                 "name: strictskiptemplate\nlabel:\n  \"${SKIP_LABELLED}\": [\"docs\"]\n",
+		kickignore.File:             "${SKIP_IGNORED}/\n",
+		"${SKIP_IGNORED}/file.txt":  "ignored\n",
+		"${SKIP_LABELLED}/file.txt": "labelled\n",
+		"README.md":                 "readme\n",
+	} {
+		p := filepath.Join(src, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		assert.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+
+	tmpl, stderr := makeTemplate(t, "strictskiptemplate", src)
+	dest := filepath.Join(mkdtemp(t), "strictskipproject")
+	tmpl.SetSrcDest("strictskiptemplate", dest)
+	tmpl.SetLabels([]string{"ci"})
+	tmpl.SetStrict(true)
+	assert.Equal(t, 0, tmpl.Run())
+
+	assert.FileExists(t, filepath.Join(dest, "README.md"))
+	assert.NotContains(t, stderr.String(), "SKIP_")
+}
+
+func TestTemplate_NotStrict(t *testing.T) {
+	tmpl, _ := makeTemplate(t, "stricttemplate", filepath.Join(testtools.FixtureDir(), "stricttemplate"))
+	dest := filepath.Join(mkdtemp(t), "notstrictproject")
+	tmpl.SetSrcDest("stricttemplate", dest)
+	assert.Equal(t, 0, tmpl.Run())
+	assert.FileExists(t, filepath.Join(dest, "file.txt"))
+}
+
+func TestTemplate_Engines(t *testing.T) {
+	tmpl, _ := makeTemplate(t, "enginetemplate", filepath.Join(testtools.FixtureDir(), "enginetemplate"))
+	dest := filepath.Join(mkdtemp(t), "engineproject")
+	vars := variables.New()
+	vars.ProjectVariable("NAME", "myEngine")
+	tmpl.SetVars(vars)
+	tmpl.SetSrcDest("enginetemplate", dest)
+	assert.Equal(t, 0, tmpl.Run())
+
+	for p, want := range map[string]string{
+		"my_engine.tmpl": "name: myEngine\nhelm: {{ .Values.image }}\n",
+		"chart.yaml":     "name: myEngine\ndebug: false\n",
+		"env.txt":        "myEngine\n",
+	} {
+		b, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(p)))
+		assert.NoError(t, err, p)
+		assert.Equal(t, want, string(b), p)
+	}
+
+	renderers := map[string]string{}
+	for _, e := range tmpl.Plan() {
+		renderers[filepath.Base(e.Path)] = e.Renderer
+	}
+	assert.Equal(t, "texttemplate", renderers["my_engine.tmpl"])
+	assert.Equal(t, "mustache", renderers["chart.yaml"])
+	assert.Equal(t, "envsubst", renderers["env.txt"])
+}
+
+func TestTemplate_ModelineOptions(t *testing.T) {
+	tmpl, _ := makeTemplate(t, "mixtemplate", filepath.Join(testtools.FixtureDir(), "mixtemplate"))
+	dest := filepath.Join(mkdtemp(t), "mixproject")
+	vars := variables.New()
+	vars.ProjectVariable("NAME", "mixproject")
+	tmpl.SetVars(vars)
+	tmpl.SetSrcDest("mixtemplate", dest)
+	assert.Equal(t, 0, tmpl.Run())
+
+	for p, want := range map[string]string{
+		"values.yaml":          "name: mixproject\nimage: {{ .Values.image }}\n",
+		"README-mixproject.md": "# mixproject\n",
+	} {
+		b, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(p)))
+		assert.NoError(t, err, p)
+		assert.Equal(t, want, string(b), p)
+	}
+	assert.NoFileExists(t, filepath.Join(dest, "values.tmpl"))
+	assert.NoFileExists(t, filepath.Join(dest, "readme.in"))
+
+	renderers := map[string]string{}
+	for _, e := range tmpl.Plan() {
+		if e.Action == template.ActionRender {
+			renderers[filepath.Base(e.Path)] = e.Renderer
+		}
+	}
+	assert.Equal(t, map[string]string{"values.yaml": "text", "README-mixproject.md": "envsubst"}, renderers)
+}
@@ -1,14 +1,24 @@
 package variables
 
 import (
+	"fmt"
 	"os"
+	"regexp"
+	"sort"
 	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/kick-project/kick/internal/resources/marshal"
 )
 
-// Variables consists of the variables that are to be passed to the template
+var nameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Variables consists of the variables that are to be passed to the template.
+// The process environment is read once by New and is never modified.
 type Variables struct {
-	Env     map[string]string // Environment variables
+	Env     map[string]string // Environment variables, excluding those prefixed with "PROJECT_"
 	Project map[string]string // Project variables
+	Vars    map[string]string // Variables answered or supplied for the template
 }
 
 // New sets up environment and project variables to be passed through to the text template
@@ -16,20 +26,128 @@ func New() *Variables {
 	tv := Variables{}
 	tv.genVarsEnv()
 	tv.Project = map[string]string{}
+	tv.Vars = map[string]string{}
 	return &tv
 }
 
-// ProjectVariable sets a project variable
+// ProjectVariable sets a project variable. It is looked up with the
+// "PROJECT_" prefix, E.G. NAME is looked up as PROJECT_NAME.
 func (v *Variables) ProjectVariable(name, value string) {
 	v.Project[name] = value
-	os.Setenv("PROJECT_"+name, value)
+}
+
+// SetVariable sets a template variable. The variable is also added to Env so
+// text templates can use it as .Env.NAME.
+func (v *Variables) SetVariable(name, value string) {
+	v.Vars[name] = value
+	v.Env[name] = value
+}
+
+// Lookup returns the value of a variable. Project variables prefixed with
+// "PROJECT_" are looked up first, followed by template variables and the
+// environment. ok is false if the variable is not set.
+func (v *Variables) Lookup(name string) (value string, ok bool) {
+	if strings.HasPrefix(name, "PROJECT_") {
+		if value, ok = v.Project[strings.TrimPrefix(name, "PROJECT_")]; ok {
+			return
+		}
+	}
+	if value, ok = v.Vars[name]; ok {
+		return
+	}
+	value, ok = v.Env[name]
+	return
+}
+
+// ParseVar parses a variable in the form KEY=VALUE and sets it as a template
+// variable.
+func (v *Variables) ParseVar(keyvalue string) error {
+	kv := strings.SplitN(keyvalue, "=", 2)
+	if len(kv) != 2 {
+		return fmt.Errorf(`invalid variable "%s": expected KEY=VALUE`, keyvalue)
+	}
+	if !nameRegexp.MatchString(kv[0]) {
+		return fmt.Errorf(`invalid variable name "%s"`, kv[0])
+	}
+	v.SetVariable(kv[0], kv[1])
+	return nil
+}
+
+// LoadFile loads template variables from an answers file. Files with a
+// ".json", ".yaml" or ".yml" suffix are read as a mapping of names to values.
+// Any other file is read as a dotenv file of KEY=VALUE lines.
+func (v *Variables) LoadFile(path string) error {
+	values := map[string]string{}
+	switch {
+	case strings.HasSuffix(path, ".json"), strings.HasSuffix(path, ".yaml"), strings.HasSuffix(path, ".yml"):
+		m := map[string]interface{}{}
+		err := marshal.FromFile(&m, path)
+		if err != nil {
+			return fmt.Errorf("can not load variables: %w", err)
+		}
+		for k, val := range m {
+			if val == nil {
+				values[k] = ""
+				continue
+			}
+			values[k] = fmt.Sprint(val)
+		}
+	default:
+		m, err := godotenv.Read(path)
+		if err != nil {
+			return fmt.Errorf("can not load variables from %s: %w", path, err)
+		}
+		values = m
+	}
+	for k, val := range values {
+		if !nameRegexp.MatchString(k) {
+			return fmt.Errorf(`invalid variable name "%s" in %s`, k, path)
+		}
+		v.SetVariable(k, val)
+	}
+	return nil
+}
+
+// SaveFile saves the template variables to an answers file. The format is
+// chosen by the file suffix in the same way as LoadFile.
+func (v *Variables) SaveFile(path string) error {
+	switch {
+	case strings.HasSuffix(path, ".json"), strings.HasSuffix(path, ".yaml"), strings.HasSuffix(path, ".yml"):
+		return marshal.ToFile(v.Vars, path)
+	}
+	return godotenv.Write(v.Vars, path)
+}
+
+// Environ returns the variables in the form KEY=VALUE for use as the
+// environment of a command. Project variables are prefixed with "PROJECT_".
+func (v *Variables) Environ() []string {
+	m := map[string]string{}
+	for k, val := range v.Env {
+		m[k] = val
+	}
+	for k, val := range v.Project {
+		m["PROJECT_"+k] = val
+	}
+	for k, val := range v.Vars {
+		m[k] = val
+	}
+	env := []string{}
+	for k, val := range m {
+		env = append(env, k+"="+val)
+	}
+	sort.Strings(env)
+	return env
 }
 
 func (v *Variables) genVarsEnv() map[string]string {
 	envMap := make(map[string]string)
 
 	for _, v := range os.Environ() {
-		splitVars := strings.Split(v, "=")
+		splitVars := strings.SplitN(v, "=", 2)
+		// PROJECT_ is reserved for project variables
+		if len(splitVars) != 2 || strings.HasPrefix(splitVars[0], "PROJECT_") {
+			continue
+		}
 		envMap[splitVars[0]] = splitVars[1]
 	}
 	v.Env = envMap
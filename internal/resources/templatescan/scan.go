@@ -11,6 +11,7 @@ import (
 
 	"github.com/kick-project/kick/internal/resources/config/configtemplate"
 	"github.com/kick-project/kick/internal/resources/errs"
+	"github.com/kick-project/kick/internal/resources/kickignore"
 	"github.com/kick-project/kick/internal/resources/marshal"
 	"github.com/kick-project/kick/internal/resources/model"
 	"github.com/kick-project/kick/internal/resources/modeline"
@@ -46,6 +47,26 @@ func (s Scan) Run(root string, lines int) (err error) {
 	return
 }
 
+// Labels returns the labels of every labelled file or directory below root.
+// Paths are relative to root. Run must be called before Labels.
+func (s Scan) Labels(root string) (map[string][]string, error) {
+	type Row struct {
+		Dir   string
+		Path  string
+		Label string
+	}
+	results := []Row{}
+	tx := s.DB.Raw(QueryScanLabel+" WHERE base = ? AND label IS NOT NULL", root).Scan(&results)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	labels := map[string][]string{}
+	for _, r := range results {
+		labels[r.Path] = append(labels[r.Path], r.Label)
+	}
+	return labels, nil
+}
+
 // reads the template configuration
 func (s Scan) readConf(root string) (err error) {
 	p := filepath.Join(root, ".kick.yml")
@@ -95,6 +116,11 @@ func (s Scan) scanBase(root string, lines int) error {
 		return err
 	}
 
+	ignore, err := kickignore.Load(root)
+	if err != nil {
+		return err
+	}
+
 	fileSystem := os.DirFS(root)
 	var real string
 	return fs.WalkDir(fileSystem, ".", func(path string, d fs.DirEntry, err error) error {
@@ -107,6 +133,12 @@ func (s Scan) scanBase(root string, lines int) error {
 		if path == ".git" && d.IsDir() {
 			return fs.SkipDir
 		}
+		if ignore.Match(path, d.IsDir()) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
 		mlf := s.fetchFile(mlt.ID, path)
 
 		if d.Type().IsRegular() {
@@ -123,7 +155,8 @@ func (s Scan) scanBase(root string, lines int) error {
 
 func (s Scan) updateModeInfo(ml *modeline.ModeLine, mlf *model.File) {
 	if ml != nil {
-		for _, o := range ml.GetOptions() {
+		options := append(append([]string{}, ml.GetOptions()...), ml.GetValues()...)
+		for _, o := range options {
 			err := s.many2ManyFileOption(mlf, o)
 			if err != nil {
 				errs.Fatal(err)
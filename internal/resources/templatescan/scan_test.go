@@ -61,3 +61,47 @@ func testLabels(t *testing.T, db *gorm.DB) {
 		assert.True(t, strings.HasPrefix(r.Path, ".github"))
 	}
 }
+
+func TestScan_Run_Ignore(t *testing.T) {
+	root := filepath.Join(testtools.FixtureDir(), "ignoretemplate")
+	dbfile := filepath.Join(testtools.TempDir(), "TestScan_Run_Ignore.db")
+	db := model.CreateModelTemporary(model.Options{File: dbfile})
+	s := Scan{
+		DB: db,
+	}
+	err := s.Run(root, 5)
+	assert.NoError(t, err)
+
+	files := []string{}
+	db.Raw("SELECT file.file FROM file LEFT JOIN base ON base.id = file.base_id WHERE base.base = ?", root).Scan(&files)
+	assert.Contains(t, files, "README.md")
+	assert.Contains(t, files, "sub/AUTHORING.md")
+	assert.Contains(t, files, "notes/keep.md")
+	for _, p := range []string{"AUTHORING.md", "logo.bin", "build", "build/out.txt", "notes/a.txt"} {
+		assert.NotContains(t, files, p)
+	}
+}
+
+func TestScan_Run_Values(t *testing.T) {
+	root := filepath.Join(testtools.FixtureDir(), "mixtemplate")
+	dbfile := filepath.Join(testtools.TempDir(), "TestScan_Run_Values.db")
+	db := model.CreateModelTemporary(model.Options{File: dbfile})
+	s := Scan{
+		DB: db,
+	}
+	err := s.Run(root, 5)
+	assert.NoError(t, err)
+
+	type Result struct {
+		Dir    string
+		Path   string
+		Option string
+	}
+	results := []Result{}
+	db.Raw(QueryScanOption+" WHERE file = ? ORDER BY option", "values.tmpl").Scan(&results)
+	options := []string{}
+	for _, r := range results {
+		options = append(options, r.Option)
+	}
+	assert.Equal(t, []string{"delims=[[,]]", "engine=text", "render", "target=values.yaml"}, options)
+}
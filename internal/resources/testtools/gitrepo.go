@@ -0,0 +1,88 @@
+package testtools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+// GitServer URL of the test git server started by the test setup
+const GitServer = "http://127.0.0.1:8080"
+
+// Commit the files of a repository at a commit made by PushRepo or CommitRepo
+type Commit struct {
+	Message string            // Commit message
+	Tag     string            // Tag of the commit. No tag when empty
+	Files   map[string]string // File contents by path. Files of the previous commit that are not listed are removed
+}
+
+// PushRepo creates a repository in the temp directory with a commit for each
+// of commits and pushes its branches and tags to a new repository, named after
+// id, on the test git server. Returns the path and URL of the repository.
+func PushRepo(t *testing.T, id string, commits ...Commit) (dir, url string) {
+	dir = filepath.Join(TempDir(), id+"-src")
+	err := os.RemoveAll(dir)
+	assert.NoError(t, err)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	for _, c := range commits {
+		CommitRepo(t, dir, c)
+	}
+
+	url = fmt.Sprintf("%s/%s-%d.git", GitServer, id, time.Now().UnixNano())
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{url}})
+	assert.NoError(t, err)
+	err = repo.Push(&git.PushOptions{
+		RefSpecs: []config.RefSpec{"refs/heads/*:refs/heads/*", "refs/tags/*:refs/tags/*"},
+	})
+	assert.NoError(t, err)
+	return dir, url
+}
+
+// CommitRepo commits, and tags, the files of c to the repository in dir
+func CommitRepo(t *testing.T, dir string, c Commit) {
+	repo, err := git.PlainOpen(dir)
+	assert.NoError(t, err)
+	w, err := repo.Worktree()
+	assert.NoError(t, err)
+
+	if head, err := repo.Head(); err == nil {
+		prev, err := repo.CommitObject(head.Hash())
+		assert.NoError(t, err)
+		files, err := prev.Files()
+		assert.NoError(t, err)
+		err = files.ForEach(func(f *object.File) error {
+			if _, ok := c.Files[f.Name]; ok {
+				return nil
+			}
+			_, err := w.Remove(f.Name)
+			return err
+		})
+		assert.NoError(t, err)
+	}
+	for name, content := range c.Files {
+		p := filepath.Join(dir, name)
+		err = os.MkdirAll(filepath.Dir(p), 0755)
+		assert.NoError(t, err)
+		err = os.WriteFile(p, []byte(content), 0644)
+		assert.NoError(t, err)
+	}
+	err = w.AddGlob(".")
+	assert.NoError(t, err)
+	hash, err := w.Commit(c.Message, &git.CommitOptions{
+		Author: &object.Signature{Name: "kick", Email: "kick@example.com", When: time.Now()},
+	})
+	assert.NoError(t, err)
+	if c.Tag == "" {
+		return
+	}
+	_, err = repo.CreateTag(c.Tag, hash, nil)
+	assert.NoError(t, err)
+}
@@ -1,13 +1,16 @@
 package vcs
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"regexp"
+	"strings"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/kick-project/kick/internal/resources/errs"
+	"github.com/kick-project/kick/internal/resources/versiontag"
 )
 
 // VCS package information
@@ -29,10 +32,15 @@ func New(opts *Options) *VCS {
 
 // Clone will clone a remote repository
 func (i *VCS) Clone(url, path string) (repo *Repo, err error) {
+	return i.CloneContext(context.Background(), url, path)
+}
+
+// CloneContext same as Clone. The clone or pull is aborted when ctx is done.
+func (i *VCS) CloneContext(ctx context.Context, url, path string) (repo *Repo, err error) {
 	latest := false
 	_, statErr := os.Stat(path)
 	if os.IsNotExist(statErr) {
-		_, err := git.PlainClone(path, false, &git.CloneOptions{
+		_, err := git.PlainCloneContext(ctx, path, false, &git.CloneOptions{
 			URL: url,
 		})
 		if i.err.LogF("Can not clone %s: %v", url, err) {
@@ -46,7 +54,7 @@ func (i *VCS) Clone(url, path string) (repo *Repo, err error) {
 		return nil, fmt.Errorf(`error cloning %s, can not open %s: %w`, url, path, err)
 	}
 	if !latest {
-		err = repo.Pull()
+		err = repo.PullContext(ctx)
 		if err != nil {
 			return nil, fmt.Errorf(`error cloning %s, can not pull: %w`, url, err)
 		}
@@ -73,38 +81,23 @@ type Repo struct {
 	err  errs.HandlerIface
 }
 
-// Checkout checks out a reference
+// Checkout checks out a reference. ref is a branch, a tag or a full or
+// abbreviated commit SHA. The worktree is left at a detached HEAD. An empty ref
+// returns a detached worktree to the default branch.
 func (r *Repo) Checkout(ref string) error {
 	if ref == "" {
-		return nil
-	}
-	iter, err := r.repo.References()
-	if err != nil {
-		return fmt.Errorf(`checkout error: %w`, err)
+		ref = r.defaultBranch()
 	}
-	var targetRef *plumbing.Reference
-	fn := func(curRef *plumbing.Reference) error {
-		if curRef.Name().Short() == ref {
-			targetRef = curRef
-		}
+	if ref == "" {
 		return nil
 	}
-	err = iter.ForEach(fn)
+	hash, err := r.Resolve(ref)
 	if err != nil {
-		return fmt.Errorf(`reference error: %w`, err)
-	}
-
-	if targetRef == nil {
-		return fmt.Errorf(`could not find reference "%s"`, ref)
-	}
-
-	refObj, err := r.repo.Reference(targetRef.Name(), true)
-	if r.err.LogF("Error reading reference '%s' for path '%s': %+v", ref, r.path, err) {
-		return fmt.Errorf("checkout error: %w", err)
+		return fmt.Errorf(`checkout error: %w`, err)
 	}
 
 	chkops := &git.CheckoutOptions{
-		Hash: refObj.Hash(),
+		Hash: plumbing.NewHash(hash),
 	}
 
 	w, err := r.repo.Worktree()
@@ -119,7 +112,102 @@ func (r *Repo) Checkout(ref string) error {
 	return nil
 }
 
+// defaultBranch returns the branch created by the clone if HEAD is detached
+func (r *Repo) defaultBranch() string {
+	head, err := r.repo.Head()
+	if err != nil || head.Name().IsBranch() {
+		return ""
+	}
+	iter, err := r.repo.Branches()
+	if err != nil {
+		return ""
+	}
+	defer iter.Close()
+	branch, err := iter.Next()
+	if err != nil {
+		return ""
+	}
+	return branch.Name().Short()
+}
+
+// Resolve returns the commit SHA of ref. Branches are resolved against the
+// "origin" remote first so that a pinned branch follows upstream.
+func (r *Repo) Resolve(ref string) (string, error) {
+	for _, rev := range []string{"origin/" + ref, ref} {
+		hash, err := r.repo.ResolveRevision(plumbing.Revision(rev))
+		if err == nil {
+			return hash.String(), nil
+		}
+	}
+	return "", fmt.Errorf(`could not find reference "%s"`, ref)
+}
+
+// Head returns the commit SHA checked out in the worktree
+func (r *Repo) Head() (string, error) {
+	ref, err := r.repo.Head()
+	if err != nil {
+		return "", fmt.Errorf(`head error: %w`, err)
+	}
+	return ref.Hash().String(), nil
+}
+
+// Commit a commit returned by Log
+type Commit struct {
+	Hash    string // Commit SHA
+	Subject string // First line of the commit message
+}
+
+// Log returns the commits reachable from to that are not reachable from from,
+// newest first. This is the equivalent of "git log from..to".
+func (r *Repo) Log(from, to string) (commits []Commit, err error) {
+	fromHash, err := r.Resolve(from)
+	if err != nil {
+		return nil, fmt.Errorf(`log error: %w`, err)
+	}
+	toHash, err := r.Resolve(to)
+	if err != nil {
+		return nil, fmt.Errorf(`log error: %w`, err)
+	}
+
+	seen := map[plumbing.Hash]bool{}
+	iter, err := r.repo.Log(&git.LogOptions{From: plumbing.NewHash(fromHash)})
+	if err != nil {
+		return nil, fmt.Errorf(`log error: %w`, err)
+	}
+	err = iter.ForEach(func(c *object.Commit) error {
+		seen[c.Hash] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf(`log error: %w`, err)
+	}
+
+	iter, err = r.repo.Log(&git.LogOptions{From: plumbing.NewHash(toHash)})
+	if err != nil {
+		return nil, fmt.Errorf(`log error: %w`, err)
+	}
+	err = iter.ForEach(func(c *object.Commit) error {
+		if seen[c.Hash] {
+			return nil
+		}
+		commits = append(commits, Commit{
+			Hash:    c.Hash.String(),
+			Subject: strings.SplitN(strings.TrimSpace(c.Message), "\n", 2)[0],
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf(`log error: %w`, err)
+	}
+	return commits, nil
+}
+
 func (r *Repo) Pull() error {
+	return r.PullContext(context.Background())
+}
+
+// PullContext same as Pull. The pull is aborted when ctx is done.
+func (r *Repo) PullContext(ctx context.Context) error {
 	w, err := r.repo.Worktree()
 	if r.err.LogF("Error reading path '%s': %+v", r.path, err) {
 		return fmt.Errorf("pull error: %w", err)
@@ -133,8 +221,21 @@ func (r *Repo) Pull() error {
 		return nil
 	}
 
+	// A detached HEAD is a pinned reference. Fetch so that Checkout can
+	// resolve it, there is no branch to pull.
+	head, err := r.repo.Head()
+	if err == nil && !head.Name().IsBranch() {
+		err = r.repo.FetchContext(ctx, &git.FetchOptions{})
+		if err != git.NoErrAlreadyUpToDate {
+			if r.err.LogF("Error fetching %s: %+v", r.path, err) {
+				return fmt.Errorf("fetch error: %w", err)
+			}
+		}
+		return nil
+	}
+
 	pullopts := &git.PullOptions{}
-	err = w.Pull(pullopts)
+	err = w.PullContext(ctx, pullopts)
 	if err != git.NoErrAlreadyUpToDate {
 		if r.err.LogF("Error pulling %s: %+v", r.path, err) {
 			return fmt.Errorf("pull error: %w", err)
@@ -159,11 +260,10 @@ func (r *Repo) Tags() (tags []string) {
 	return tags
 }
 
-// Versions will return a list of versions that match semantic versioning
+// Versions will return a list of tags that are versions. See versiontag.Parse
 func (r *Repo) Versions() (versions []string) {
-	regex := regexp.MustCompile(`^\d+\.\d+(?:\.\d+)?$`)
 	for _, t := range r.Tags() {
-		if regex.MatchString(t) {
+		if _, err := versiontag.Parse(t); err == nil {
 			versions = append(versions, t)
 		}
 	}
@@ -1,9 +1,13 @@
 package vcs_test
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/kick-project/kick/internal/di"
 	"github.com/kick-project/kick/internal/resources/testtools"
 	"github.com/kick-project/kick/internal/resources/vcs"
@@ -51,6 +55,61 @@ func TestRepo_Checkout(t *testing.T) {
 	}
 }
 
+func TestRepo_Checkout_Hash(t *testing.T) {
+	params = setup()
+	r, err := params.vcs.Open(params.repopath)
+	if err != nil {
+		t.Error(err)
+	}
+	hash, err := r.Resolve(`1.0.0`)
+	assert.NoError(t, err)
+	assert.Len(t, hash, 40)
+	err = r.Checkout(hash[:7])
+	assert.NoError(t, err)
+	err = r.Checkout(`nosuchref`)
+	assert.Error(t, err)
+	err = r.Checkout(`master`)
+	assert.NoError(t, err)
+}
+
+func TestRepo_Checkout_Default(t *testing.T) {
+	params = setup()
+	dir := filepath.Join(params.base, "TestRepo_Checkout_Default")
+	err := os.RemoveAll(dir)
+	assert.NoError(t, err)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	w, err := repo.Worktree()
+	assert.NoError(t, err)
+	hashes := []string{}
+	for _, msg := range []string{"first", "second"} {
+		err = os.WriteFile(filepath.Join(dir, "file.txt"), []byte(msg+"\n"), 0644)
+		assert.NoError(t, err)
+		_, err = w.Add("file.txt")
+		assert.NoError(t, err)
+		hash, err := w.Commit(msg, &git.CommitOptions{
+			Author: &object.Signature{Name: "kick", Email: "kick@example.com", When: time.Now()},
+		})
+		assert.NoError(t, err)
+		hashes = append(hashes, hash.String())
+	}
+
+	r, err := params.vcs.Open(dir)
+	assert.NoError(t, err)
+	err = r.Checkout(hashes[0])
+	assert.NoError(t, err)
+	head, err := r.Head()
+	assert.NoError(t, err)
+	assert.Equal(t, hashes[0], head)
+
+	// An empty ref returns to the default branch
+	err = r.Checkout(``)
+	assert.NoError(t, err)
+	head, err = r.Head()
+	assert.NoError(t, err)
+	assert.Equal(t, hashes[1], head)
+}
+
 func TestRepo_Versions(t *testing.T) {
 	params := setup()
 	r, err := params.vcs.Open(params.repopath)
@@ -0,0 +1,62 @@
+// Package versiontag parses the version tags of templates
+package versiontag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coreos/go-semver/semver"
+)
+
+// Parse parses tag as a semantic version. A leading v is removed and a missing
+// minor or patch number is taken as 0, E.G. v1.4 is parsed as 1.4.0. Numbers
+// with leading zeros and empty pre-release or build parts are rejected.
+func Parse(tag string) (*semver.Version, error) {
+	v := strings.TrimPrefix(tag, "v")
+	core := v
+	if i := strings.IndexAny(v, "-+"); i != -1 {
+		core = v[:i]
+	}
+	if err := strict(core, v[len(core):]); err != nil {
+		return nil, fmt.Errorf("%s is not a semantic version: %w", tag, err)
+	}
+	switch strings.Count(core, ".") {
+	case 0:
+		v = core + ".0.0" + v[len(core):]
+	case 1:
+		v = core + ".0" + v[len(core):]
+	}
+	return semver.NewVersion(v)
+}
+
+// strict checks what semver.NewVersion lets through. core holds the version
+// numbers and suffix the pre-release and build parts.
+func strict(core, suffix string) error {
+	for _, n := range strings.Split(core, ".") {
+		if len(n) > 1 && n[0] == '0' {
+			return fmt.Errorf("leading zero in %s", n)
+		}
+	}
+	build := ""
+	if i := strings.Index(suffix, "+"); i != -1 {
+		suffix, build = suffix[:i], suffix[i:]
+	}
+	if suffix != "" {
+		for _, id := range strings.Split(suffix[1:], ".") {
+			if id == "" {
+				return fmt.Errorf("empty pre-release identifier")
+			}
+			if len(id) > 1 && id[0] == '0' && strings.Trim(id, "0123456789") == "" {
+				return fmt.Errorf("leading zero in %s", id)
+			}
+		}
+	}
+	if build != "" {
+		for _, id := range strings.Split(build[1:], ".") {
+			if id == "" {
+				return fmt.Errorf("empty build identifier")
+			}
+		}
+	}
+	return nil
+}
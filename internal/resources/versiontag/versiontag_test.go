@@ -0,0 +1,31 @@
+package versiontag_test
+
+import (
+	"testing"
+
+	"github.com/kick-project/kick/internal/resources/versiontag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for tag, want := range map[string]string{
+		"1.2.3":      "1.2.3",
+		"v1.2.3":     "1.2.3",
+		"1.4":        "1.4.0",
+		"v2":         "2.0.0",
+		"2.0-rc.1":   "2.0.0-rc.1",
+		"1.2.3+b.01": "1.2.3+b.01",
+		"1.0.0-0a.1": "1.0.0-0a.1",
+	} {
+		v, err := versiontag.Parse(tag)
+		if assert.NoError(t, err, tag) {
+			assert.Equal(t, want, v.String(), tag)
+		}
+	}
+
+	for _, tag := range []string{"", "v", "release", "1.2.3.4", "1..2", "1.x",
+		"01.2.3", "1.02", "1.2.3-", "1.2.3+", "1-", "1.2.3-rc..1", "1.2.3-01", "1.2.3+b..1"} {
+		_, err := versiontag.Parse(tag)
+		assert.Error(t, err, tag)
+	}
+}
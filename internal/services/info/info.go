@@ -0,0 +1,246 @@
+// Package info shows the metadata of templates available from repositories
+package info
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/kick-project/kick/internal/resources/errs"
+	"github.com/kick-project/kick/internal/resources/model"
+	"github.com/kick-project/kick/internal/resources/output"
+	"github.com/kick-project/kick/internal/resources/template/renderer"
+	"github.com/kick-project/kick/internal/resources/versiontag"
+	"gorm.io/gorm"
+)
+
+var queryTemplates = `
+SELECT
+	template.id,
+	template.name,
+	template.url,
+	template.desc,
+	template.labels,
+	IFNULL(repo.name, ''),
+	IFNULL(template_meta.renderer, ''),
+	IFNULL(template_meta.keywords, ''),
+	IFNULL(template_meta.license, ''),
+	IFNULL(template_meta.readme, '')
+FROM template LEFT JOIN repo_template ON (template.id = repo_template.template_id)
+LEFT JOIN repo ON (repo_template.repo_id = repo.id)
+LEFT JOIN template_meta ON (template.id = template_meta.template_id AND template_meta.deleted_at IS NULL)
+WHERE (template.name = ? OR template.url = ?) AND template.deleted_at IS NULL
+ORDER BY repo.name, template.url
+`
+
+// Info show the metadata of templates available from repositories
+//
+//go:generate ifacemaker -f info.go -s Info -p info -i InfoIface -o info_interfaces.go -c "AUTO GENERATED. DO NOT EDIT."
+type Info struct {
+	enc    output.Encoder    // Output encoder
+	errs   errs.HandlerIface // Error handler
+	orm    *gorm.DB          // GoRM
+	stderr io.Writer         // Stderr
+	stdout io.Writer         // Stdout
+}
+
+// Options options for New
+type Options struct {
+	Encoder    output.Encoder    `validate:"required"`            // Output encoder
+	ErrHandler errs.HandlerIface `validate:"required"`            // Error handler
+	ORM        *gorm.DB          `validate:"required,structonly"` // GORM
+	Stderr     io.Writer         `validate:"required"`            // Stderr
+	Stdout     io.Writer         `validate:"required"`            // Stdout
+}
+
+// New construct an Info object
+func New(opts *Options) *Info {
+	return &Info{
+		enc:    opts.Encoder,
+		errs:   opts.ErrHandler,
+		orm:    opts.ORM,
+		stderr: opts.Stderr,
+		stdout: opts.Stdout,
+	}
+}
+
+// Record a template in the output of Info
+type Record struct {
+	Name      string     `json:"name" yaml:"name"`
+	Repo      string     `json:"repo" yaml:"repo"`
+	URL       string     `json:"url" yaml:"url"`
+	Desc      string     `json:"description" yaml:"description"`
+	Versions  []string   `json:"versions" yaml:"versions"`
+	Renderer  string     `json:"renderer" yaml:"renderer"`
+	License   string     `json:"license" yaml:"license"`
+	Labels    []string   `json:"labels" yaml:"labels"`
+	Keywords  []string   `json:"keywords" yaml:"keywords"`
+	Variables []Variable `json:"variables" yaml:"variables"`
+	Readme    string     `json:"readme" yaml:"readme"`
+}
+
+// Variable a variable declared by a template
+type Variable struct {
+	Name    string `json:"name" yaml:"name"`
+	Desc    string `json:"description" yaml:"description"`
+	Default string `json:"default" yaml:"default"`
+}
+
+// Info shows the templates matching name. name is a template name, a name and
+// repository in the form name/repo as listed by "kick search" or a template
+// URL. Returns 255 if no template matches.
+func (i *Info) Info(name string) int {
+	records := i.records(name)
+	if len(records) == 0 {
+		fmt.Fprintf(i.stderr, "no template %s. run \"kick update\" to update repository information\n", name)
+		return 255
+	}
+
+	if i.enc.Format() == output.TABLE {
+		i.text(records)
+		return 0
+	}
+	tbl := &output.Table{
+		Header:  []string{"Name", "Repo", "URL", "Description", "Versions", "Renderer", "License", "Labels", "Keywords", "Variables"},
+		Records: records,
+	}
+	for _, r := range records {
+		vars := []string{}
+		for _, v := range r.Variables {
+			vars = append(vars, v.Name)
+		}
+		tbl.Rows = append(tbl.Rows, []string{
+			r.Name, r.Repo, r.URL, r.Desc,
+			strings.Join(r.Versions, " "), r.Renderer, r.License,
+			strings.Join(r.Labels, " "), strings.Join(r.Keywords, " "), strings.Join(vars, " "),
+		})
+	}
+	if i.errs.LogF("can not write template information: %w", i.enc.Encode(i.stdout, tbl)) {
+		return 255
+	}
+	return 0
+}
+
+// records returns the templates matching name. A name that matches no
+// template name or URL, E.G. the local path /srv/tmpl, is split as name/repo.
+func (i *Info) records(name string) []Record {
+	records := i.query(name, "")
+	if len(records) == 0 && !strings.Contains(name, "://") && strings.Contains(name, "/") {
+		records = i.query(split(name))
+	}
+	return records
+}
+
+// query returns the templates with the name or URL name, in repository repo
+// when repo is not empty
+func (i *Info) query(name, repo string) []Record {
+	rows, err := i.orm.Raw(queryTemplates, name, name).Rows()
+	i.errs.PanicF("query error: %w", err)
+	defer rows.Close()
+
+	records := []Record{}
+	ids := []uint{}
+	for rows.Next() {
+		var (
+			id       uint
+			labels   string
+			keywords string
+			r        Record
+		)
+		err = rows.Scan(&id, &r.Name, &r.URL, &r.Desc, &labels, &r.Repo, &r.Renderer, &keywords, &r.License, &r.Readme)
+		i.errs.PanicF("query error: %w", err)
+		if repo != "" && r.Repo != repo {
+			continue
+		}
+		if r.Renderer == "" {
+			r.Renderer = renderer.Default
+		}
+		r.Labels = strings.Fields(labels)
+		r.Keywords = strings.Fields(keywords)
+		records = append(records, r)
+		ids = append(ids, id)
+	}
+	for n, id := range ids {
+		records[n].Versions = i.versions(id)
+		records[n].Variables = i.variables(id)
+	}
+	return records
+}
+
+// versions returns the versions of a template in semantic version order
+func (i *Info) versions(templateID uint) []string {
+	rows := []model.Versions{}
+	err := i.orm.Where("template_id = ?", templateID).Find(&rows).Error
+	i.errs.PanicF("query error: %w", err)
+	versions := []string{}
+	for _, row := range rows {
+		versions = append(versions, row.Version)
+	}
+	sort.SliceStable(versions, func(a, b int) bool {
+		va, erra := versiontag.Parse(versions[a])
+		vb, errb := versiontag.Parse(versions[b])
+		if erra != nil || errb != nil {
+			return versions[a] < versions[b]
+		}
+		return va.LessThan(*vb)
+	})
+	return versions
+}
+
+// variables returns the variables declared by a template sorted by name
+func (i *Info) variables(templateID uint) []Variable {
+	rows := []model.Variable{}
+	err := i.orm.Where("template_id = ?", templateID).Order("name").Find(&rows).Error
+	i.errs.PanicF("query error: %w", err)
+	vars := []Variable{}
+	for _, row := range rows {
+		vars = append(vars, Variable{Name: row.Name, Desc: row.Desc, Default: row.Default})
+	}
+	return vars
+}
+
+// text writes records as text with a table of variables
+func (i *Info) text(records []Record) {
+	for n, r := range records {
+		if n > 0 {
+			fmt.Fprintln(i.stdout)
+		}
+		fmt.Fprintf(i.stdout, `name:        %s
+repository:  %s
+url:         %s
+description: %s
+versions:    %s
+renderer:    %s
+license:     %s
+labels:      %s
+keywords:    %s
+`, r.Name, dash(r.Repo), r.URL, r.Desc, dash(strings.Join(r.Versions, " ")), r.Renderer, dash(r.License),
+			dash(strings.Join(r.Labels, " ")), dash(strings.Join(r.Keywords, " ")))
+		if len(r.Variables) > 0 {
+			tbl := &output.Table{Header: []string{"Variable", "Description", "Default"}}
+			for _, v := range r.Variables {
+				tbl.Rows = append(tbl.Rows, []string{v.Name, v.Desc, v.Default})
+			}
+			fmt.Fprintln(i.stdout, "variables:")
+			i.errs.LogF("can not write variables: %w", i.enc.Encode(i.stdout, tbl))
+		}
+		if r.Readme != "" {
+			fmt.Fprintf(i.stdout, "readme:\n    %s\n", r.Readme)
+		}
+	}
+}
+
+// split splits name/repo
+func split(name string) (string, string) {
+	i := strings.LastIndex(name, "/")
+	return name[:i], name[i+1:]
+}
+
+// dash returns "-" for an empty string
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
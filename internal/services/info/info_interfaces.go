@@ -0,0 +1,11 @@
+// AUTO GENERATED. DO NOT EDIT.
+
+package info
+
+// InfoIface ...
+type InfoIface interface {
+	// Info shows the templates matching name. name is a template name, a name and
+	// repository in the form name/repo as listed by "kick search" or a template
+	// URL. Returns 255 if no template matches.
+	Info(name string) int
+}
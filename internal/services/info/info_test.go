@@ -0,0 +1,133 @@
+package info_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kick-project/kick/internal/di"
+	"github.com/kick-project/kick/internal/resources/model"
+	"github.com/kick-project/kick/internal/resources/output"
+	"github.com/kick-project/kick/internal/resources/template/renderer"
+	"github.com/kick-project/kick/internal/resources/testtools"
+	"github.com/kick-project/kick/internal/services/info"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestInfo_Table(t *testing.T) {
+	i, stdout, stderr := newInfo(t, "TestInfo_Table", &output.TableEncoder{})
+
+	assert.Equal(t, 0, i.Info("gocli"))
+	out := stdout.String()
+	assert.Regexp(t, `name:\s+gocli`, out)
+	assert.Regexp(t, `repository:\s+repo1`, out)
+	assert.Regexp(t, `versions:\s+1.2.0 1.10.0`, out)
+	assert.Regexp(t, `renderer:\s+texttemplate`, out)
+	assert.Regexp(t, `license:\s+MIT`, out)
+	assert.Regexp(t, `keywords:\s+cli command`, out)
+	assert.Regexp(t, `\|\s+AUTHOR\s+\|\s+project author\s+\|\s+nobody\s+\|`, out)
+	assert.Regexp(t, `readme:\n\s+Generate a Go command line application`, out)
+
+	assert.Equal(t, 255, i.Info("missing"))
+	assert.Contains(t, stderr.String(), "no template missing")
+}
+
+func TestInfo_JSON(t *testing.T) {
+	i, stdout, _ := newInfo(t, "TestInfo_JSON", &output.JSONEncoder{})
+
+	assert.Equal(t, 0, i.Info("gocli/repo1"))
+	records := []info.Record{}
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &records))
+	assert.Equal(t, []info.Record{{
+		Name:     "gocli",
+		Repo:     "repo1",
+		URL:      "http://127.0.0.1:8080/gocli.git",
+		Desc:     "Go command line application",
+		Versions: []string{"1.2.0", "1.10.0"},
+		Renderer: "texttemplate",
+		License:  "MIT",
+		Labels:   []string{"go"},
+		Keywords: []string{"cli", "command"},
+		Variables: []info.Variable{
+			{Name: "AUTHOR", Desc: "project author", Default: "nobody"},
+			{Name: "NAME", Desc: "project name"},
+		},
+		Readme: "Generate a Go command line application",
+	}}, records)
+
+	// A template without metadata uses the default renderer
+	stdout.Reset()
+	assert.Equal(t, 0, i.Info("http://127.0.0.1:8080/plain.git"))
+	records = []info.Record{}
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &records))
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, renderer.Default, records[0].Renderer)
+		assert.Equal(t, []info.Variable{}, records[0].Variables)
+	}
+
+	assert.Equal(t, 255, i.Info("gocli/repo2"))
+
+	// A local path is matched as a URL before it is split as name/repo
+	stdout.Reset()
+	assert.Equal(t, 0, i.Info("/srv/tmpl"))
+	records = []info.Record{}
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &records))
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "local", records[0].Name)
+	}
+}
+
+func newInfo(t *testing.T, name string, enc output.Encoder) (i *info.Info, stdout, stderr *bytes.Buffer) {
+	home := filepath.Join(testtools.TempDir(), name)
+	os.RemoveAll(home)
+	inject := di.New(&di.Options{Home: home})
+	inject.MakeSetup().Init()
+	db := inject.MakeORM()
+	buildInfoData(t, db)
+
+	stdout = &bytes.Buffer{}
+	stderr = &bytes.Buffer{}
+	i = info.New(&info.Options{
+		Encoder:    enc,
+		ErrHandler: inject.MakeErrorHandler(),
+		ORM:        db,
+		Stderr:     stderr,
+		Stdout:     stdout,
+	})
+	return i, stdout, stderr
+}
+
+func buildInfoData(t *testing.T, db *gorm.DB) {
+	repo := model.Repo{Name: "repo1", URL: "http://127.0.0.1:8080/repo1.git", Desc: "Repo 1"}
+	tmpl := model.Template{
+		Name:     "gocli",
+		URL:      "http://127.0.0.1:8080/gocli.git",
+		Desc:     "Go command line application",
+		Labels:   "go",
+		Vars:     "AUTHOR NAME",
+		Repo:     []model.Repo{repo},
+		Versions: []model.Versions{{Version: "1.10.0"}, {Version: "1.2.0"}},
+	}
+	assert.NoError(t, db.Create(&tmpl).Error)
+	assert.NoError(t, db.Create(&model.TemplateMeta{
+		TemplateID: tmpl.ID,
+		Renderer:   "texttemplate",
+		Keywords:   "cli command",
+		License:    "MIT",
+		Readme:     "Generate a Go command line application",
+	}).Error)
+	for _, v := range []model.Variable{
+		{TemplateID: tmpl.ID, Name: "NAME", Desc: "project name"},
+		{TemplateID: tmpl.ID, Name: "AUTHOR", Desc: "project author", Default: "nobody"},
+	} {
+		assert.NoError(t, db.Create(&v).Error)
+	}
+
+	plain := model.Template{Name: "plain", URL: "http://127.0.0.1:8080/plain.git", Desc: "Plain template"}
+	assert.NoError(t, db.Create(&plain).Error)
+	local := model.Template{Name: "local", URL: "/srv/tmpl", Desc: "Local template"}
+	assert.NoError(t, db.Create(&local).Error)
+}
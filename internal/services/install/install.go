@@ -2,6 +2,7 @@ package install
 
 import (
 	"bufio"
+	"context"
 	"database/sql"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/kick-project/kick/internal/resources/client"
 	"github.com/kick-project/kick/internal/resources/config"
@@ -26,6 +28,7 @@ import (
 type Install struct {
 	client     *client.Client
 	ConfigFile *config.File
+	ctx        context.Context
 	orm        *gorm.DB
 	log        logger.OutputIface
 	exit       *exit.Handler
@@ -40,6 +43,7 @@ type Install struct {
 type Options struct {
 	Client     *client.Client     `validate:"required"`
 	ConfigFile *config.File       `validate:"required"`
+	Context    context.Context    `validate:"required"` // Cancels fetches
 	ORM        *gorm.DB           `validate:"required"`
 	Log        logger.OutputIface `validate:"required"`
 	Exit       *exit.Handler      `validate:"required"`
@@ -55,6 +59,7 @@ func New(opts *Options) *Install {
 	return &Install{
 		client:     opts.Client,
 		ConfigFile: opts.ConfigFile,
+		ctx:        opts.Context,
 		orm:        opts.ORM,
 		log:        opts.Log,
 		exit:       opts.Exit,
@@ -88,7 +93,9 @@ LEFT JOIN repo ON (repo_template.repo_id = repo.id)
 WHERE template.name = ?
 `
 
-// Install install template
+// Install install template. template is a template name with an optional
+// version, E.G. tmpl/repo@1.4.0, or a URL or path with an optional ref,
+// E.G. http://host/tmpl.git#main. A version or ref pins the template.
 func (i *Install) Install(handle, template string) (ret int) {
 	i.log.Debugf("Install(%s, %s)", handle, template)
 
@@ -100,14 +107,16 @@ func (i *Install) Install(handle, template string) (ret int) {
 	}
 
 	// Install from a template name
-	found := i.processTemplate(handle, template)
-	if found {
+	found, err := i.processTemplate(handle, template)
+	if i.err.LogF("can not install %s: %v", template, err) {
+		return 255
+	} else if found {
 		return 0
 	}
 
 	// Install from a URL
-	found, err := i.processLocation(handle, template)
-	if err != nil {
+	found, err = i.processLocation(handle, template)
+	if i.err.LogF("can not install %s: %v", template, err) {
 		return 255
 	} else if !found {
 		i.log.Printf("invalid template or url %s\n", template)
@@ -119,12 +128,23 @@ func (i *Install) Install(handle, template string) (ret int) {
 func (i *Install) processLocation(handle, location string) (found bool, err error) {
 	i.log.Debugf("processLocation(%s, %s)", handle, location)
 
+	ref := ""
+	if idx := strings.LastIndex(location, "#"); idx >= 0 {
+		location, ref = location[:idx], location[idx+1:]
+		if ref == "" {
+			return false, fmt.Errorf("empty ref in %s#", location)
+		}
+	}
+
 	p, err := filepath.Abs(file.ExpandPath(location))
 	if err != nil {
 		return false, err
 	}
 	// Check if its a path on the local file system
 	if info, err := os.Stat(p); err == nil && info.IsDir() {
+		if ref != "" {
+			return false, fmt.Errorf("a ref can not be used with the local path %s", p)
+		}
 		t := config.Template{
 			URL: p,
 		}
@@ -141,6 +161,7 @@ func (i *Install) processLocation(handle, location string) (found bool, err erro
 	}
 	t := config.Template{
 		URL:  urlx.URL,
+		Ref:  ref,
 		Desc: "Direct installation",
 	}
 	err = i.createEntry(handle, t)
@@ -150,15 +171,16 @@ func (i *Install) processLocation(handle, location string) (found bool, err erro
 	return true, nil
 }
 
-func (i *Install) processTemplate(handle, template string) (processed bool) {
+func (i *Install) processTemplate(handle, template string) (processed bool, err error) {
 	i.log.Debugf("processTemplate(%s, %s)", handle, template)
 	var (
 		entries []config.Template
 		full    string
 		name    string
 		origin  string
+		version string
 	)
-	re := regexp.MustCompile(`^([a-z0-9]+)(?:/([a-z0-9]+))?$`)
+	re := regexp.MustCompile(`^([a-z0-9]+)(?:/([a-z0-9]+))?(?:@([A-Za-z0-9._/-]+))?$`)
 	match := re.FindStringSubmatch(template)
 	if len(match) == 0 {
 		return
@@ -166,21 +188,23 @@ func (i *Install) processTemplate(handle, template string) (processed bool) {
 	full = match[0]
 	name = match[1]
 	origin = match[2]
+	version = match[3]
 	if full == "" {
 		return
 	}
 
 	// Add entry
 	entries = i.templateMatches(name, origin)
+	for x := range entries {
+		entries[x].Ref = version
+	}
 	switch len(entries) {
 	case 0:
-		return false
+		return false, nil
 	case 1:
-		_ = i.createEntry(handle, entries[0])
-		return true
+		return true, i.createEntry(handle, entries[0])
 	default:
-		i.promptEntry(handle, entries)
-		return true
+		return true, i.promptEntry(handle, entries)
 	}
 }
 
@@ -241,7 +265,7 @@ func (i *Install) templateMatches(name, origin string) (entries []config.Templat
 // TODO: Add unit tests for promptEntry
 
 // promptEntry prompts for an entry
-func (i *Install) promptEntry(handle string, entries []config.Template) {
+func (i *Install) promptEntry(handle string, entries []config.Template) error {
 	l := len(entries)
 	fmt.Fprint(i.stdout, "multiple matches\n", l)
 	for x := 0; x < l; x++ {
@@ -273,12 +297,12 @@ func (i *Install) promptEntry(handle string, entries []config.Template) {
 			break
 		}
 	}
-	_ = i.createEntry(handle, entries[selected-1])
+	return i.createEntry(handle, entries[selected-1])
 }
 
 // createEntry creates a entry
 func (i *Install) createEntry(handle string, entry config.Template) error {
-	_, err := i.getRepo(entry.URL)
+	_, err := i.getRepo(entry.URL, entry.Ref)
 	if err != nil {
 		return err
 	}
@@ -304,12 +328,12 @@ func (i *Install) createEntry(handle string, entry config.Template) error {
 	return nil
 }
 
-// getRepo get version control system repository or set a location to a template.
-// returns the local path location.
-func (i *Install) getRepo(url string) (string, error) {
-	p, err := i.client.GetTemplate(url, "")
-	if err != nil {
-		return "", err
+// getRepo get version control system repository at ref or set a location to a
+// template. returns the local path location.
+func (i *Install) getRepo(url, ref string) (string, error) {
+	fetched := i.client.FetchAll(i.ctx, []client.Fetch{{Kind: client.TEMPLATE, URL: url, Ref: ref}})[0]
+	if fetched.Err != nil {
+		return "", fetched.Err
 	}
-	return p.Path(), err
+	return fetched.Plumb.Path(), nil
 }
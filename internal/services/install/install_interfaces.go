@@ -4,6 +4,8 @@ package install
 
 // InstalIface ...
 type InstalIface interface {
-	// Install install template
+	// Install install template. template is a template name with an optional
+	// version, E.G. tmpl/repo@1.4.0, or a URL or path with an optional ref,
+	// E.G. http://host/tmpl.git#main. A version or ref pins the template.
 	Install(handle, template string) (ret int)
 }
@@ -7,25 +7,38 @@ import (
 	"text/tabwriter"
 
 	"github.com/kick-project/kick/internal/resources/config"
-	"github.com/olekukonko/tablewriter"
+	"github.com/kick-project/kick/internal/resources/errs"
+	"github.com/kick-project/kick/internal/resources/output"
 	terminal "github.com/wayneashleyberry/terminal-dimensions"
 )
 
 // List manage listing of installed templates
+//
 //go:generate ifacemaker -f list.go -s List -p list -i ListIface -o list_interfaces.go -c "AUTO GENERATED. DO NOT EDIT."
 type List struct {
-	Stderr io.Writer    `validate:"required"`
-	Stdout io.Writer    `validate:"required"`
-	Conf   *config.File `validate:"required"`
+	Stderr  io.Writer      `validate:"required"`
+	Stdout  io.Writer      `validate:"required"`
+	Conf    *config.File   `validate:"required"`
+	Encoder output.Encoder `validate:"required"`
 }
 
-// List lists the output
+// Record an installed template in JSON and YAML output
+type Record struct {
+	Handle   string `json:"handle" yaml:"handle"`               // Handle used to start a project
+	Template string `json:"template" yaml:"template"`           // Name of the template
+	Origin   string `json:"origin" yaml:"origin"`               // Repository the template was installed from
+	Desc     string `json:"description" yaml:"description"`     // Description of the template
+	URL      string `json:"url" yaml:"url"`                     // Location of the template
+	Ref      string `json:"ref,omitempty" yaml:"ref,omitempty"` // Version or ref the template is pinned to
+}
+
+// List lists the output. Output formats other than a table always include
+// every field.
 func (l *List) List(long bool) int {
-	if long {
-		l.longFmt()
-	} else {
-		l.shortFmt()
+	if long || l.Encoder.Format() != output.TABLE {
+		return l.longFmt()
 	}
+	l.shortFmt()
 	return 0
 }
 
@@ -61,12 +74,11 @@ func (l *List) shortFmt() {
 	w.Flush()
 }
 
-func (l *List) longFmt() {
-	var (
-		header []string
-		table  [][]string
-	)
-	header = []string{"Handle", "Template", "Description", "Location"}
+func (l *List) longFmt() int {
+	tbl := &output.Table{
+		Header: []string{"Handle", "Template", "Description", "Location"},
+	}
+	records := []Record{}
 	for _, row := range l.sort(l.Conf.Templates) {
 		var (
 			templateName string
@@ -84,15 +96,25 @@ func (l *List) longFmt() {
 		if desc == "" {
 			desc = "-"
 		}
-		table = append(table, []string{row.Handle, templateName, desc, row.URL})
+		location := row.URL
+		if row.Ref != "" {
+			location = location + "#" + row.Ref
+		}
+		tbl.Rows = append(tbl.Rows, []string{row.Handle, templateName, desc, location})
+		records = append(records, Record{
+			Handle:   row.Handle,
+			Template: row.Template,
+			Origin:   row.Origin,
+			Desc:     row.Desc,
+			URL:      row.URL,
+			Ref:      row.Ref,
+		})
 	}
-	writer := tablewriter.NewWriter(l.Stdout)
-	writer.SetAlignment(tablewriter.ALIGN_LEFT)
-	writer.SetHeader(header)
-	for _, v := range table {
-		writer.Append(v)
+	tbl.Records = records
+	if errs.LogF("can not write templates: %w", l.Encoder.Encode(l.Stdout, tbl)) {
+		return 255
 	}
-	writer.Render()
+	return 0
 }
 
 func (l *List) sort(in []config.Template) (out []config.Template) {
@@ -4,6 +4,7 @@ package list
 
 // ListIface ...
 type ListIface interface {
-	// List lists the output
+	// List lists the output. Output formats other than a table always include
+	// every field.
 	List(long bool) int
 }
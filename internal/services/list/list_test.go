@@ -2,18 +2,21 @@ package list
 
 import (
 	"bytes"
+	"encoding/json"
 	"testing"
 
 	"github.com/kick-project/kick/internal/resources/config"
+	"github.com/kick-project/kick/internal/resources/output"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestListShort(t *testing.T) {
 	stderr, stdout, conf := getOptions()
 	l := List{
-		Stderr: stderr,
-		Stdout: stdout,
-		Conf:   conf,
+		Stderr:  stderr,
+		Stdout:  stdout,
+		Conf:    conf,
+		Encoder: &output.TableEncoder{},
 	}
 	l.List(false)
 
@@ -25,9 +28,10 @@ func TestListShort(t *testing.T) {
 func TestListLong(t *testing.T) {
 	stderr, stdout, conf := getOptions()
 	l := List{
-		Stderr: stderr,
-		Stdout: stdout,
-		Conf:   conf,
+		Stderr:  stderr,
+		Stdout:  stdout,
+		Conf:    conf,
+		Encoder: &output.TableEncoder{},
 	}
 	l.List(true)
 	out := stdout.String()
@@ -38,6 +42,23 @@ func TestListLong(t *testing.T) {
 	assert.Regexp(t, `\|\s+handle4\s+\|\s+-\s+\|\s+-\s+\|\s+http://\S+`, out)
 }
 
+func TestListJSON(t *testing.T) {
+	stderr, stdout, conf := getOptions()
+	l := List{
+		Stderr:  stderr,
+		Stdout:  stdout,
+		Conf:    conf,
+		Encoder: &output.JSONEncoder{},
+	}
+	assert.Equal(t, 0, l.List(false))
+	records := []Record{}
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &records))
+	if assert.Len(t, records, 4) {
+		assert.Equal(t, Record{Handle: "handle3", Template: "template3", URL: "http://template.io/template3.git"}, records[2])
+	}
+	assert.Contains(t, stdout.String(), `"origin": "origin1"`)
+}
+
 func getOptions() (stderr, stdout *bytes.Buffer, conf *config.File) {
 	stderr = &bytes.Buffer{}
 	stdout = &bytes.Buffer{}
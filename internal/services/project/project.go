@@ -0,0 +1,384 @@
+package project
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/kick-project/kick/internal/resources/checksum"
+	"github.com/kick-project/kick/internal/resources/config"
+	"github.com/kick-project/kick/internal/resources/diff3"
+	"github.com/kick-project/kick/internal/resources/errs"
+	"github.com/kick-project/kick/internal/resources/file"
+	"github.com/kick-project/kick/internal/resources/logger"
+	"github.com/kick-project/kick/internal/resources/output"
+	"github.com/kick-project/kick/internal/resources/template"
+	"github.com/kick-project/kick/internal/resources/template/variables"
+)
+
+// RejectSuffix is appended to the name of a file holding the template's
+// version of a conflicting file when conflicts are rejected.
+const RejectSuffix = ".rej"
+
+// Record a file affected by Update in JSON and YAML output
+type Record struct {
+	Path   string `json:"path" yaml:"path"`     // Path relative to the project
+	Status string `json:"status" yaml:"status"` // One of created, updated, merged, conflict, removed or kept
+}
+
+// Project manage projects generated from templates
+//
+//go:generate ifacemaker -f project.go -s Project -p project -i ProjectIface -o project_interfaces.go -c "AUTO GENERATED. DO NOT EDIT."
+type Project struct {
+	conf     *config.File
+	enc      output.Encoder
+	errs     errs.HandlerIface
+	log      logger.OutputIface
+	stdout   io.Writer
+	tmplOpts *template.Options // Options of the templates generated by Update
+}
+
+// Options options for New
+type Options struct {
+	Conf     *config.File       `validate:"required"`
+	Encoder  output.Encoder     `validate:"required"`
+	Errs     errs.HandlerIface  `validate:"required"`
+	Log      logger.OutputIface `validate:"required"`
+	Stdout   io.Writer          `validate:"required"`
+	Template *template.Options  `validate:"required"` // Options of the templates generated by Update
+}
+
+// New constructor
+func New(opts *Options) *Project {
+	return &Project{
+		conf:     opts.Conf,
+		enc:      opts.Encoder,
+		errs:     opts.Errs,
+		log:      opts.Log,
+		stdout:   opts.Stdout,
+		tmplOpts: opts.Template,
+	}
+}
+
+// UpdateOptions options to Project.Update
+type UpdateOptions struct {
+	To     string // Ref to update to. Defaults to the ref the template is pinned to
+	Reject bool   // Write the template's version of conflicting files to a .rej file
+}
+
+// UpdateResult paths, relative to the project, affected by Update
+type UpdateResult struct {
+	Created   []string // Files added by the template
+	Updated   []string // Files not modified in the project, replaced by the template
+	Merged    []string // Files modified in the project and the template, merged without conflicts
+	Conflicts []string // Files with conflict markers, or a .rej file when rejecting conflicts
+	Removed   []string // Files removed from the template and not modified in the project
+	Kept      []string // Files removed from the template or deleted from the project, left as they are
+}
+
+// Update regenerates the project in path from a newer version of its
+// template. The project is generated at the ref in the project's manifest and
+// at the new ref. Files the user has not modified are updated, files both
+// have modified are merged and conflicts are marked with conflict markers.
+func (p *Project) Update(path string, opts UpdateOptions) int {
+	m, err := template.LoadManifest(path)
+	if p.errs.LogF("%v", err) {
+		return 255
+	}
+	installed := false
+	for _, t := range p.conf.Templates {
+		if t.Handle == m.Handle {
+			installed = true
+		}
+	}
+	if !installed {
+		p.log.Printf("handle %s is not installed. install it with: kick install %s %s\n", m.Handle, m.Handle, m.URL)
+		return 255
+	}
+
+	tmp, err := os.MkdirTemp(os.Getenv("TEMP"), "kick-update-")
+	if p.errs.LogF("can not create temporary directory: %v", err) {
+		return 255
+	}
+	defer os.RemoveAll(tmp)
+
+	vars := variables.New()
+	for k, v := range m.Project {
+		vars.ProjectVariable(k, v)
+	}
+	for k, v := range m.Variables {
+		vars.SetVariable(k, v)
+	}
+
+	base := filepath.Join(tmp, "base")
+	baseRef := m.Commit
+	if baseRef == "" {
+		baseRef = m.Ref
+	}
+	err = p.generate(m, vars, baseRef, base)
+	if p.errs.LogF("%v", err) {
+		return 255
+	}
+	next := filepath.Join(tmp, "next")
+	err = p.generate(m, vars, opts.To, next)
+	if p.errs.LogF("%v", err) {
+		return 255
+	}
+	nextManifest, err := template.LoadManifest(next)
+	if p.errs.LogF("%v", err) {
+		return 255
+	}
+
+	label := m.Handle
+	switch {
+	case nextManifest.Ref != "":
+		label += "@" + nextManifest.Ref
+	case len(nextManifest.Commit) >= 7:
+		label += "@" + nextManifest.Commit[:7]
+	}
+	u := &updater{
+		project:  path,
+		base:     base,
+		next:     next,
+		manifest: m,
+		label:    label,
+		reject:   opts.Reject,
+		result:   &UpdateResult{},
+	}
+	err = u.update()
+	if p.errs.LogF("can not update project %s: %v", path, err) {
+		return 255
+	}
+	err = nextManifest.Save(path)
+	if p.errs.LogF("%v", err) {
+		return 255
+	}
+
+	p.log.Printf("updated project:%s handle:%s\n", path, label)
+	p.fmtResult(u.result, opts.Reject)
+	return 0
+}
+
+// generate generates the template of m at ref into dest without running hooks.
+// Each generation uses a new template so that no state of a previous
+// generation, E.G. the renderer, carries over.
+func (p *Project) generate(m *template.Manifest, vars *variables.Variables, ref, dest string) error {
+	o := *p.tmplOpts
+	o.Variables = vars
+	t := template.New(&o)
+	t.SetRef(ref)
+	t.SetSrcDest(m.Handle, dest)
+	t.SetNoHooks(true)
+	t.SetLabels(m.Labels)
+	if t.Run() != 0 {
+		if ref == "" {
+			ref = "pinned ref"
+		}
+		return fmt.Errorf("can not generate handle %s at %s", m.Handle, ref)
+	}
+	return nil
+}
+
+func (p *Project) fmtResult(result *UpdateResult, reject bool) {
+	tbl := &output.Table{
+		Header: []string{"File", "Status"},
+	}
+	records := []Record{}
+	groups := []struct {
+		status string
+		files  []string
+	}{
+		{"created", result.Created},
+		{"updated", result.Updated},
+		{"merged", result.Merged},
+		{"conflict", result.Conflicts},
+		{"removed", result.Removed},
+		{"kept", result.Kept},
+	}
+	for _, g := range groups {
+		for _, f := range g.files {
+			tbl.Rows = append(tbl.Rows, []string{f, g.status})
+			records = append(records, Record{Path: f, Status: g.status})
+		}
+	}
+	tbl.Records = records
+	if p.errs.LogF("can not write update result: %w", p.enc.Encode(p.stdout, tbl)) || p.enc.Format() != output.TABLE {
+		return
+	}
+	fmt.Fprintf(p.stdout, "%d created, %d updated, %d merged, %d conflicts, %d removed, %d kept\n",
+		len(result.Created), len(result.Updated), len(result.Merged), len(result.Conflicts), len(result.Removed), len(result.Kept))
+	if len(result.Conflicts) == 0 {
+		return
+	}
+	if reject {
+		fmt.Fprintf(p.stdout, "the template's version of conflicting files was written with the %s suffix\n", RejectSuffix)
+	} else {
+		fmt.Fprintln(p.stdout, "conflicts are marked with conflict markers")
+	}
+}
+
+// updater applies a regenerated project to a project
+type updater struct {
+	project  string             // Project directory
+	base     string             // Project generated at the manifest's ref
+	next     string             // Project generated at the new ref
+	manifest *template.Manifest // Manifest of the project
+	label    string             // Conflict marker label of the template
+	reject   bool               // Write .rej files instead of conflict markers
+	result   *UpdateResult
+}
+
+func (u *updater) update() error {
+	nextSums, err := template.Checksums(u.next)
+	if err != nil {
+		return err
+	}
+
+	paths := []string{}
+	for rel := range nextSums {
+		paths = append(paths, rel)
+	}
+	sort.Strings(paths)
+	for _, rel := range paths {
+		err = u.file(rel, nextSums[rel])
+		if err != nil {
+			return err
+		}
+	}
+
+	// Files removed from the template
+	paths = []string{}
+	for rel := range u.manifest.Files {
+		if _, ok := nextSums[rel]; !ok {
+			paths = append(paths, rel)
+		}
+	}
+	sort.Strings(paths)
+	for _, rel := range paths {
+		sum, ok, err := u.checksum(rel)
+		if err != nil {
+			return err
+		}
+		switch {
+		case !ok:
+		case sum == u.manifest.Files[rel]:
+			err = os.Remove(filepath.Join(u.project, filepath.FromSlash(rel)))
+			if err != nil {
+				return err
+			}
+			u.result.Removed = append(u.result.Removed, rel)
+		default:
+			u.result.Kept = append(u.result.Kept, rel)
+		}
+	}
+	return nil
+}
+
+// checksum returns the checksum of the regular file rel in the project. ok is
+// false if the file does not exist.
+func (u *updater) checksum(rel string) (sum string, ok bool, err error) {
+	path := filepath.Join(u.project, filepath.FromSlash(rel))
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	if !info.Mode().IsRegular() {
+		return "", false, fmt.Errorf("%s is not a regular file", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false, err
+	}
+	defer f.Close()
+	b, err := checksum.Sha256Sum(f)
+	if err != nil {
+		return "", false, err
+	}
+	return fmt.Sprintf("%x", b), true, nil
+}
+
+// file applies the file rel of the regenerated project. nextSum is the
+// checksum of the file in the regenerated project.
+func (u *updater) file(rel, nextSum string) error {
+	src := filepath.Join(u.next, filepath.FromSlash(rel))
+	dst := filepath.Join(u.project, filepath.FromSlash(rel))
+	sum, exists, err := u.checksum(rel)
+	if err != nil {
+		return err
+	}
+	_, generated := u.manifest.Files[rel]
+	switch {
+	case !exists && generated:
+		// Deleted from the project
+		u.result.Kept = append(u.result.Kept, rel)
+		return nil
+	case !exists:
+		err = os.MkdirAll(filepath.Dir(dst), 0755)
+		if err != nil {
+			return err
+		}
+		u.result.Created = append(u.result.Created, rel)
+		return file.CopyAll(src, dst)
+	case sum == nextSum:
+		return nil
+	case sum == u.manifest.Files[rel]:
+		u.result.Updated = append(u.result.Updated, rel)
+		return file.CopyAll(src, dst)
+	}
+
+	// Modified in the project
+	ours, err := os.ReadFile(dst)
+	if err != nil {
+		return err
+	}
+	theirs, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	base, err := os.ReadFile(filepath.Join(u.base, filepath.FromSlash(rel)))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if bytes.Equal(base, theirs) {
+		// Not changed by the template
+		return nil
+	}
+
+	text := !binary(base) && !binary(ours) && !binary(theirs)
+	conflicts := 1
+	merged := ""
+	if text {
+		merged, conflicts = diff3.Merge(string(base), string(ours), string(theirs), "local", u.label)
+	}
+	switch {
+	case conflicts == 0:
+		u.result.Merged = append(u.result.Merged, rel)
+		return writeFile(dst, merged)
+	case u.reject || !text:
+		u.result.Conflicts = append(u.result.Conflicts, rel)
+		return file.CopyAll(src, dst+RejectSuffix)
+	default:
+		u.result.Conflicts = append(u.result.Conflicts, rel)
+		return writeFile(dst, merged)
+	}
+}
+
+// binary returns true if b looks like binary content
+func binary(b []byte) bool {
+	return bytes.IndexByte(b, 0) >= 0
+}
+
+// writeFile replaces the content of an existing file keeping its permissions
+func writeFile(path, content string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), info.Mode().Perm())
+}
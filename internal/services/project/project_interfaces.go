@@ -0,0 +1,12 @@
+// AUTO GENERATED. DO NOT EDIT.
+
+package project
+
+// ProjectIface ...
+type ProjectIface interface {
+	// Update regenerates the project in path from a newer version of its
+	// template. The project is generated at the ref in the project's manifest and
+	// at the new ref. Files the user has not modified are updated, files both
+	// have modified are merged and conflicts are marked with conflict markers.
+	Update(path string, opts UpdateOptions) int
+}
@@ -0,0 +1,5 @@
+package repo
+
+// VerifyTemplate verifies the template checked out to dir. Exported for the
+// tests of package repo_test, which can build a Repo with package di.
+var VerifyTemplate = (*Repo).verifyTemplate
@@ -0,0 +1,136 @@
+package repo
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kick-project/kick/internal/resources/config/configtemplate"
+	"github.com/kick-project/kick/internal/resources/serialize"
+)
+
+// ReadmeExcerptLen maximum length of the README excerpt copied to a repo
+const ReadmeExcerptLen = 300
+
+// readmeFiles file names searched for a README in order
+var readmeFiles = []string{"README.md", "README.markdown", "README.rst", "README.txt", "README"}
+
+// licenseFiles file names searched for a license in order
+var licenseFiles = []string{"LICENSE", "LICENSE.md", "LICENSE.txt", "COPYING"}
+
+// licenses phrases found in license files and their SPDX identifiers. A
+// license matches when its file contains every phrase. More specific licenses
+// are listed first.
+var licenses = []struct {
+	phrases []string
+	spdx    string
+}{
+	{[]string{"GNU AFFERO GENERAL PUBLIC LICENSE"}, "AGPL-3.0"},
+	{[]string{"GNU LESSER GENERAL PUBLIC LICENSE"}, "LGPL-3.0"},
+	{[]string{"GNU GENERAL PUBLIC LICENSE", "Version 3"}, "GPL-3.0"},
+	{[]string{"GNU GENERAL PUBLIC LICENSE", "Version 2"}, "GPL-2.0"},
+	{[]string{"Apache License", "Version 2.0"}, "Apache-2.0"},
+	{[]string{"Mozilla Public License Version 2.0"}, "MPL-2.0"},
+	{[]string{"Permission is hereby granted, free of charge"}, "MIT"},
+	{[]string{"Redistribution and use in source and binary forms", "Neither the name"}, "BSD-3-Clause"},
+	{[]string{"Redistribution and use in source and binary forms"}, "BSD-2-Clause"},
+	{[]string{"This is free and unencumbered software released into the public domain"}, "Unlicense"},
+}
+
+// templateMeta returns the metadata of the template cloned to dir
+func templateMeta(dir string, conf *configtemplate.TemplateMain) serialize.RepoTemplateMeta {
+	meta := serialize.RepoTemplateMeta{
+		Labels:   conf.LabelNames(),
+		Renderer: conf.Renderer,
+		Keywords: conf.Keywords,
+		License:  conf.License,
+		Readme:   readmeExcerpt(dir),
+	}
+	for _, name := range conf.EnvNames() {
+		env := conf.Envs[name]
+		meta.Variables = append(meta.Variables, serialize.RepoVariable{
+			Name:    name,
+			Desc:    env.Desc,
+			Default: env.Default,
+		})
+	}
+	if meta.License == "" {
+		meta.License = detectLicense(dir)
+	}
+	return meta
+}
+
+// readmeExcerpt returns the first paragraph of the README in dir, skipping
+// headings and badges, truncated to ReadmeExcerptLen.
+func readmeExcerpt(dir string) string {
+	for _, name := range readmeFiles {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		defer f.Close()
+
+		paragraph := []string{}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			switch {
+			case line == "" && len(paragraph) > 0:
+				return truncate(strings.Join(paragraph, " "), ReadmeExcerptLen)
+			case line == "",
+				strings.HasPrefix(line, "#"),
+				strings.HasPrefix(line, "!["),
+				strings.HasPrefix(line, "[!["),
+				strings.Trim(line, "=-~*") == "":
+				// Heading, underline or badge
+				paragraph = paragraph[:0]
+			default:
+				paragraph = append(paragraph, line)
+			}
+		}
+		return truncate(strings.Join(paragraph, " "), ReadmeExcerptLen)
+	}
+	return ""
+}
+
+// truncate shortens s to at most max bytes at a word boundary
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	s = s[:max-3]
+	if i := strings.LastIndexByte(s, ' '); i > 0 {
+		s = s[:i]
+	}
+	return s + "..."
+}
+
+// detectLicense returns the SPDX identifier of the license file in dir or an
+// empty string if it is not recognized.
+func detectLicense(dir string) string {
+	for _, name := range licenseFiles {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		text := string(data)
+		for _, l := range licenses {
+			if containsAll(text, l.phrases) {
+				return l.spdx
+			}
+		}
+		return ""
+	}
+	return ""
+}
+
+// containsAll returns true if s contains every phrase
+func containsAll(s string, phrases []string) bool {
+	for _, p := range phrases {
+		if !strings.Contains(s, p) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kick-project/kick/internal/resources/config/configtemplate"
+	"github.com/kick-project/kick/internal/resources/serialize"
+	"github.com/kick-project/kick/internal/resources/testtools"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateMeta(t *testing.T) {
+	dir := filepath.Join(testtools.TempDir(), "TestTemplateMeta")
+	os.RemoveAll(dir)
+	assert.NoError(t, os.MkdirAll(dir, 0755))
+	readme := `# gocli
+
+[![Build](https://example.com/badge.svg)](https://example.com)
+
+Generate a Go command line
+application.
+
+## Usage
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte(readme), 0644))
+	license := "MIT License\n\nPermission is hereby granted, free of charge, to any person"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "LICENSE"), []byte(license), 0644))
+
+	conf := &configtemplate.TemplateMain{
+		Envs: map[string]configtemplate.Env{
+			"NAME":   {Desc: "project name"},
+			"AUTHOR": {Desc: "project author", Default: "nobody"},
+		},
+		Labels:   map[string][]string{"go.mod": {"go"}},
+		Renderer: "texttemplate",
+		Keywords: []string{"cli"},
+	}
+	meta := templateMeta(dir, conf)
+	assert.Equal(t, serialize.RepoTemplateMeta{
+		Labels: []string{"go"},
+		Variables: []serialize.RepoVariable{
+			{Name: "AUTHOR", Desc: "project author", Default: "nobody"},
+			{Name: "NAME", Desc: "project name"},
+		},
+		Renderer: "texttemplate",
+		Keywords: []string{"cli"},
+		License:  "MIT",
+		Readme:   "Generate a Go command line application.",
+	}, meta)
+
+	// A license declared in .kick.yml takes precedence
+	conf.License = "Apache-2.0"
+	assert.Equal(t, "Apache-2.0", templateMeta(dir, conf).License)
+}
+
+func TestReadmeExcerpt_Truncate(t *testing.T) {
+	dir := filepath.Join(testtools.TempDir(), "TestReadmeExcerpt_Truncate")
+	os.RemoveAll(dir)
+	assert.NoError(t, os.MkdirAll(dir, 0755))
+	assert.Equal(t, "", readmeExcerpt(dir))
+
+	text := strings.Repeat("word ", 100)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte(text), 0644))
+	excerpt := readmeExcerpt(dir)
+	assert.LessOrEqual(t, len(excerpt), ReadmeExcerptLen)
+	assert.True(t, strings.HasSuffix(excerpt, "word..."))
+}
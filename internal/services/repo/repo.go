@@ -12,6 +12,7 @@ import (
 	"github.com/coreos/go-semver/semver"
 	"github.com/go-playground/validator"
 	"github.com/jinzhu/copier"
+	"github.com/kick-project/kick/internal/resources/checksum"
 	"github.com/kick-project/kick/internal/resources/client"
 	"github.com/kick-project/kick/internal/resources/client/plumb"
 	"github.com/kick-project/kick/internal/resources/config"
@@ -20,9 +21,11 @@ import (
 	"github.com/kick-project/kick/internal/resources/logger"
 	"github.com/kick-project/kick/internal/resources/marshal"
 	"github.com/kick-project/kick/internal/resources/model"
+	"github.com/kick-project/kick/internal/resources/output"
 	"github.com/kick-project/kick/internal/resources/serialize"
+	"github.com/kick-project/kick/internal/resources/template"
 	"github.com/kick-project/kick/internal/resources/vcs"
-	"github.com/olekukonko/tablewriter"
+	"github.com/kick-project/kick/internal/resources/versiontag"
 	"gorm.io/gorm"
 )
 
@@ -36,37 +39,40 @@ type Repo struct {
 	errs       errs.HandlerIface   // Error handler
 	log        logger.OutputIface  // Logger
 	orm        *gorm.DB            // GoRM
+	enc        output.Encoder      // Output encoder
 	stdout     io.Writer           // Stdout
-	twriter    *tablewriter.Table  // Table writer
+	tmplOpts   *template.Options   // Options of the templates rendered by Verify
 	valid      *validator.Validate // Validation
 	vcs        *vcs.VCS            // Version control repo
 }
 
 // Options options for New
 type Options struct {
-	Client      *client.Client      `validate:"required"` // Git client
-	Conf        *config.File        `validate:"required"` // Config file
-	ErrHandler  errs.HandlerIface   `validate:"required"` // Error handler
-	Log         logger.OutputIface  `validate:"required"` // Logger
-	ORM         *gorm.DB            `validate:"required"` // GORM
-	Stdout      io.Writer           `validate:"required"` // Writer
-	TableWriter *tablewriter.Table  `validate:"required"` // Tablewriter
-	Valid       *validator.Validate `validate:"required"` // Validator
-	VCS         *vcs.VCS            `validate:"required"` // Version Control Repo
+	Client     *client.Client      `validate:"required"` // Git client
+	Conf       *config.File        `validate:"required"` // Config file
+	Encoder    output.Encoder      `validate:"required"` // Output encoder
+	ErrHandler errs.HandlerIface   `validate:"required"` // Error handler
+	Log        logger.OutputIface  `validate:"required"` // Logger
+	ORM        *gorm.DB            `validate:"required"` // GORM
+	Stdout     io.Writer           `validate:"required"` // Writer
+	Template   *template.Options   `validate:"required"` // Options of the templates rendered by Verify
+	Valid      *validator.Validate `validate:"required"` // Validator
+	VCS        *vcs.VCS            `validate:"required"` // Version Control Repo
 }
 
 // New construct a Repo object
 func New(opts *Options) *Repo {
 	r := &Repo{
-		client:  opts.Client,
-		conf:    opts.Conf,
-		errs:    opts.ErrHandler,
-		log:     opts.Log,
-		orm:     opts.ORM,
-		stdout:  opts.Stdout,
-		twriter: opts.TableWriter,
-		valid:   opts.Valid,
-		vcs:     opts.VCS,
+		client:   opts.Client,
+		conf:     opts.Conf,
+		errs:     opts.ErrHandler,
+		log:      opts.Log,
+		orm:      opts.ORM,
+		enc:      opts.Encoder,
+		stdout:   opts.Stdout,
+		tmplOpts: opts.Template,
+		valid:    opts.Valid,
+		vcs:      opts.VCS,
 	}
 	return r
 }
@@ -98,13 +104,26 @@ func (r *Repo) buildRepo() {
 	err := os.MkdirAll(destDir, 0755)
 	errs.FatalF("Can create directory \"%s\": %v", destDir, err)
 
+	index := &serialize.RepoIndex{
+		Name:      r.serialized.Name,
+		Desc:      r.serialized.Desc,
+		Templates: []serialize.RepoIndexTemplate{},
+	}
 	for _, url := range r.serialized.TemplateURLs {
 		plu, ok := r.downloadTemplate(url)
 		if !ok {
 			continue
 		}
-		r.constructRepo(destDir, plu)
+		entry, ok := r.constructRepo(destDir, plu)
+		if !ok {
+			continue
+		}
+		index.Templates = append(index.Templates, entry)
 	}
+
+	indexPath := filepath.Join(r.wd(), serialize.IndexFile)
+	err = marshal.ToFile(index, indexPath)
+	r.errs.LogF("Can not save file \"%s\": %v", indexPath, err)
 }
 
 func (r *Repo) downloadTemplate(url string) (plu *plumb.Plumb, ok bool) {
@@ -122,13 +141,15 @@ func (r *Repo) downloadTemplate(url string) (plu *plumb.Plumb, ok bool) {
 	return plumb, true
 }
 
-func (r *Repo) constructRepo(destDir string, plu *plumb.Plumb) bool {
+// constructRepo writes the template file of plu to destDir and returns its
+// entry in the repo index
+func (r *Repo) constructRepo(destDir string, plu *plumb.Plumb) (entry serialize.RepoIndexTemplate, ok bool) {
 	// Load .kick.yml
 	var templateMain configtemplate.TemplateMain
 	srcTemplate := filepath.Join(plu.Path(), ".kick.yml")
 	err := marshal.FromFile(&templateMain, srcTemplate)
 	if r.errs.LogF("Can not load file \"%s\": %v", srcTemplate, err) {
-		return false
+		return entry, false
 	}
 
 	// Validate .kick.yml
@@ -139,14 +160,14 @@ func (r *Repo) constructRepo(destDir string, plu *plumb.Plumb) bool {
 			invalid = append(invalid, err.StructField())
 		}
 		r.log.Errorf("Can not load %s invalid fields: ", strings.Join(invalid, `,`))
-		return false
+		return entry, false
 	}
 
 	// Copy object to "templates/*.yml" yaml file
 	var templateElement serialize.RepoTemplateFile
 	err = copier.Copy(&templateElement, &templateMain)
 	if r.errs.LogF("Can not copy objects: %v", err) {
-		return false
+		return entry, false
 	}
 	// Add URL
 	templateElement.URL = plu.URL()
@@ -154,39 +175,92 @@ func (r *Repo) constructRepo(destDir string, plu *plumb.Plumb) bool {
 	// Add Version
 	templateElement.Versions = r.versions(plu)
 
+	// Add metadata
+	templateElement.RepoTemplateMeta = templateMeta(plu.Path(), &templateMain)
+
 	// Write "templates/*.yml" yaml file
 	destRepoYAML := filepath.Join(destDir, templateElement.Name+".yml")
 	err = marshal.ToFile(&templateElement, destRepoYAML)
 	if r.errs.LogF("Can not save file \"%s\": %v", destRepoYAML, err) { // nolint
-		return false
+		return entry, false
+	}
+
+	// Index entry
+	sum, err := fileSha256(srcTemplate)
+	if r.errs.LogF("Can not checksum file \"%s\": %v", srcTemplate, err) {
+		return entry, false
+	}
+	entry = serialize.RepoIndexTemplate{
+		Name:             templateElement.Name,
+		Desc:             templateElement.Desc,
+		URL:              templateElement.URL,
+		Checksum:         sum,
+		Versions:         []serialize.RepoIndexVersion{},
+		RepoTemplateMeta: templateElement.RepoTemplateMeta,
+	}
+	repo, err := r.vcs.Open(plu.Path())
+	if r.errs.LogF("error opening %s: %v", plu.Path(), err) {
+		return entry, false
+	}
+	for _, v := range templateElement.Versions {
+		commit, err := repo.Resolve(v)
+		if r.errs.LogF("Can not resolve version %s of %s: %v", v, plu.URL(), err) {
+			continue
+		}
+		entry.Versions = append(entry.Versions, serialize.RepoIndexVersion{Version: v, Commit: commit})
 	}
-	return true
+	return entry, true
+}
+
+// fileSha256 returns the SHA256 checksum of the file at path
+func fileSha256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	sum, err := checksum.Sha256Sum(f)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sum), nil
 }
 
 func (r *Repo) versions(plu *plumb.Plumb) []string {
 	versStr := []string{}
 	repo, err := r.vcs.Open(plu.Path())
 	r.errs.FatalF(`error opening %s: %w`, plu.Path(), err)
-	// Sort verions
-	var versions semver.Versions
+	// Sort verions. Tags are kept as they are so they can be checked out
+	parsed := map[string]*semver.Version{}
 	for _, v := range repo.Versions() {
-		curver := semver.New(v)
-		versions = append(versions, curver)
-		_ = curver
-	}
-	sort.Sort(&versions)
-	for _, v := range versions {
-		versStr = append(versStr, v.String())
+		curver, err := versiontag.Parse(v)
+		if r.errs.LogF("Skipping tag %s of %s: %v", v, plu.URL(), err) {
+			continue
+		}
+		parsed[v] = curver
+		versStr = append(versStr, v)
 	}
+	sort.Slice(versStr, func(i, j int) bool {
+		return parsed[versStr[i]].LessThan(*parsed[versStr[j]])
+	})
 	return versStr
 }
 
+// Record a repository in JSON and YAML output
+type Record struct {
+	Name string `json:"name" yaml:"name"`                                   // Name of the repository
+	URL  string `json:"url" yaml:"url"`                                     // Location of the repository
+	Desc string `json:"description,omitempty" yaml:"description,omitempty"` // Description of the repository
+}
+
 // List list repositories
 func (r *Repo) List() {
 	result := r.orm.Model(&model.Repo{}).Select(`name, url`).Where(`name != ?`, "local")
 	r.errs.FatalF(`database query error: %w`, result.Error)
-	r.twriter.SetAlignment(tablewriter.ALIGN_LEFT)
-	r.twriter.SetHeader([]string{"repo", "url"})
+	tbl := &output.Table{
+		Header: []string{"repo", "url"},
+	}
+	records := []Record{}
 	rows, err := result.Rows()
 	r.errs.FatalF(`database query error: %w`, err)
 	for rows.Next() {
@@ -196,16 +270,31 @@ func (r *Repo) List() {
 		)
 		err = rows.Scan(&name, &url)
 		r.errs.FatalF(`table scan error: %w`, err)
-		r.twriter.Append([]string{name.String, url.String})
+		tbl.Rows = append(tbl.Rows, []string{name.String, url.String})
+		records = append(records, Record{Name: name.String, URL: url.String})
 	}
-	r.twriter.Render()
+	tbl.Records = records
+	r.errs.FatalF(`can not write repositories: %w`, r.enc.Encode(r.stdout, tbl))
 }
 
 // Info information on repositories
 func (r *Repo) Info(repo string) {
 	repoModel := &model.Repo{}
 	_ = r.orm.First(repoModel, `name != ? and name = ?`, "local", repo)
-	fmt.Fprintf(r.stdout, `name: %s
+	if r.enc.Format() == output.TABLE {
+		fmt.Fprintf(r.stdout, `name: %s
 url: %s
 description: %s`, repoModel.Name, repoModel.URL, repoModel.Desc)
+		return
+	}
+	tbl := &output.Table{
+		Header: []string{"name", "url", "description"},
+		Rows:   [][]string{{repoModel.Name, repoModel.URL, repoModel.Desc}},
+		Records: Record{
+			Name: repoModel.Name,
+			URL:  repoModel.URL,
+			Desc: repoModel.Desc,
+		},
+	}
+	r.errs.FatalF(`can not write repository: %w`, r.enc.Encode(r.stdout, tbl))
 }
@@ -6,6 +6,13 @@ package repo
 type RepoIface interface {
 	// Build build repo
 	Build()
+	// Verify renders every template of repo.yml into a temporary directory using
+	// sample values of the variables declared in `.kick.yml`. Render errors,
+	// unknown variables, invalid modelines, invalid `.kick.yml` files,
+	// non-semver tags and duplicate template names are reported. If report is not
+	// empty the result is also written to report as JUnit XML or JSON depending on
+	// its suffix. Returns 255 if any template fails.
+	Verify(report string) int
 	// List list repositories
 	List()
 	// Info information on repositories
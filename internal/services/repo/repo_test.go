@@ -6,7 +6,10 @@ import (
 	"testing"
 
 	"github.com/kick-project/kick/internal/di"
+	"github.com/kick-project/kick/internal/resources/marshal"
+	"github.com/kick-project/kick/internal/resources/serialize"
 	"github.com/kick-project/kick/internal/resources/testtools"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestRepo_Build(t *testing.T) {
@@ -51,4 +54,15 @@ templates:
 	)
 	m := inject.MakeRepo()
 	m.Build()
+
+	index := &serialize.RepoIndex{}
+	err = marshal.FromFile(index, filepath.Join(dirPath, serialize.IndexFile))
+	assert.NoError(t, err)
+	assert.Equal(t, "repo1", index.Name)
+	assert.Len(t, index.Templates, 5)
+	assert.Equal(t, "tmpl1", index.Templates[1].Name)
+	assert.Equal(t, "http://127.0.0.1:8080/tmpl1.git", index.Templates[1].URL)
+	assert.Len(t, index.Templates[1].Checksum, 64)
+	assert.Equal(t, "7.7.7", index.Templates[1].Versions[0].Version)
+	assert.Len(t, index.Templates[1].Versions[0].Commit, 40)
 }
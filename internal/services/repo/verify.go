@@ -0,0 +1,462 @@
+package repo
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-playground/validator"
+	"github.com/kick-project/kick/internal/resources/config/configtemplate"
+	"github.com/kick-project/kick/internal/resources/errs"
+	"github.com/kick-project/kick/internal/resources/exit"
+	"github.com/kick-project/kick/internal/resources/logger"
+	"github.com/kick-project/kick/internal/resources/marshal"
+	"github.com/kick-project/kick/internal/resources/output"
+	"github.com/kick-project/kick/internal/resources/template"
+	"github.com/kick-project/kick/internal/resources/template/renderer"
+	"github.com/kick-project/kick/internal/resources/template/variables"
+	"github.com/kick-project/kick/internal/resources/versiontag"
+)
+
+// Checks reported by Verify
+const (
+	CheckFetch     = "fetch"     // Template can not be downloaded
+	CheckConfig    = "config"    // `.kick.yml` can not be parsed or is invalid
+	CheckVersion   = "version"   // Tag that is not a semantic version
+	CheckDuplicate = "duplicate" // Template name used by more than one template
+	CheckModeline  = "modeline"  // Invalid modeline
+	CheckVariable  = "variable"  // Variable not declared in `.kick.yml`
+	CheckRender    = "render"    // File or path that fails to render
+)
+
+// Report formats written by Verify. The format is chosen by the suffix of the
+// report file.
+const (
+	ReportJUnit = "junit" // JUnit XML. Suffix ".xml"
+	ReportJSON  = "json"  // JSON. Suffix ".json"
+)
+
+// versionTag tags that are meant to be versions
+var versionTag = regexp.MustCompile(`^v?\d`)
+
+// Report result of verifying the templates of a repo
+type Report struct {
+	Name      string           `json:"name" yaml:"name"`           // Name of the repo
+	Tests     int              `json:"tests" yaml:"tests"`         // Number of templates verified
+	Failures  int              `json:"failures" yaml:"failures"`   // Number of templates that failed
+	Templates []TemplateReport `json:"templates" yaml:"templates"` // Result of each template
+}
+
+// TemplateReport result of verifying a template
+type TemplateReport struct {
+	Name     string    `json:"name" yaml:"name"`         // Template name. The URL if `.kick.yml` can not be read
+	URL      string    `json:"url" yaml:"url"`           // Location of the template
+	Failures []Failure `json:"failures" yaml:"failures"` // Empty if the template passed
+}
+
+// Failure a problem found by Verify
+type Failure struct {
+	Check   string `json:"check" yaml:"check"`                   // One of the Check* constants
+	File    string `json:"file,omitempty" yaml:"file,omitempty"` // File relative to the template root
+	Line    int    `json:"line,omitempty" yaml:"line,omitempty"` // Line within File
+	Message string `json:"message" yaml:"message"`               // Description of the problem
+}
+
+func (f Failure) String() string {
+	loc := f.File
+	if loc != "" && f.Line > 0 {
+		loc = fmt.Sprintf("%s:%d", loc, f.Line)
+	}
+	if loc == "" {
+		return fmt.Sprintf("%s: %s", f.Check, f.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", f.Check, loc, f.Message)
+}
+
+// Verify renders every template of repo.yml into a temporary directory using
+// sample values of the variables declared in `.kick.yml`. Render errors,
+// unknown variables, invalid modelines, invalid `.kick.yml` files,
+// non-semver tags and duplicate template names are reported. If report is not
+// empty the result is also written to report as JUnit XML or JSON depending on
+// its suffix. Returns 255 if any template fails.
+func (r *Repo) Verify(report string) int {
+	if report != "" {
+		_, err := reportFormat(report)
+		if r.errs.LogF("Can not write report: %v", err) {
+			return 255
+		}
+	}
+	r.loadRepo()
+
+	result := &Report{
+		Name:      r.serialized.Name,
+		Templates: []TemplateReport{},
+	}
+	for _, url := range r.serialized.TemplateURLs {
+		tr := TemplateReport{Name: url, URL: url}
+		err := r.valid.Var(url, "url")
+		if err != nil {
+			tr.Failures = append(tr.Failures, Failure{Check: CheckFetch, Message: fmt.Sprintf("invalid url: %v", err)})
+			result.Templates = append(result.Templates, tr)
+			continue
+		}
+		plu, err := r.client.GetTemplate(url, "")
+		if err != nil {
+			tr.Failures = append(tr.Failures, Failure{Check: CheckFetch, Message: err.Error()})
+			result.Templates = append(result.Templates, tr)
+			continue
+		}
+		tr = r.verifyTemplate(plu.Path(), url)
+		result.Templates = append(result.Templates, tr)
+	}
+	verifyDuplicates(result.Templates)
+
+	result.Tests = len(result.Templates)
+	for _, tr := range result.Templates {
+		if len(tr.Failures) > 0 {
+			result.Failures++
+		}
+	}
+
+	ret := 0
+	if result.Failures > 0 {
+		ret = 255
+	}
+	if report != "" {
+		err := writeReport(report, result)
+		if r.errs.LogF("Can not write report \"%s\": %v", report, err) {
+			ret = 255
+		}
+	}
+	if r.errs.LogF("Can not write verify result: %v", r.printReport(result)) {
+		ret = 255
+	}
+	return ret
+}
+
+// printReport writes the result of each template to stdout. Output formats
+// other than a table encode the whole report.
+func (r *Repo) printReport(report *Report) error {
+	if r.enc.Format() != output.TABLE {
+		tbl := &output.Table{
+			Header:  []string{"template", "url", "failures"},
+			Records: report,
+		}
+		for _, tr := range report.Templates {
+			tbl.Rows = append(tbl.Rows, []string{tr.Name, tr.URL, fmt.Sprint(len(tr.Failures))})
+		}
+		return r.enc.Encode(r.stdout, tbl)
+	}
+	for _, tr := range report.Templates {
+		status := "ok"
+		if len(tr.Failures) > 0 {
+			status = "FAIL"
+		}
+		if _, err := fmt.Fprintf(r.stdout, "%-4s %s\n", status, tr.Name); err != nil {
+			return err
+		}
+		for _, f := range tr.Failures {
+			if _, err := fmt.Fprintf(r.stdout, "     %s\n", f); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintf(r.stdout, "%d templates, %d failed\n", report.Tests, report.Failures)
+	return err
+}
+
+// verifyTemplate verifies the template cloned to dir
+func (r *Repo) verifyTemplate(dir, url string) TemplateReport {
+	tr := TemplateReport{Name: url, URL: url, Failures: []Failure{}}
+
+	// Load .kick.yml
+	var conf configtemplate.TemplateMain
+	confPath := filepath.Join(dir, ".kick.yml")
+	err := marshal.FromFile(&conf, confPath)
+	if err != nil {
+		tr.Failures = append(tr.Failures, Failure{Check: CheckConfig, File: ".kick.yml", Message: fmt.Sprintf("can not parse: %v", err)})
+		return tr
+	}
+	if conf.Name != "" {
+		tr.Name = conf.Name
+	}
+
+	// Validate .kick.yml
+	err = r.valid.Struct(&conf)
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		for _, e := range verrs {
+			tr.Failures = append(tr.Failures, Failure{
+				Check:   CheckConfig,
+				File:    ".kick.yml",
+				Message: fmt.Sprintf("invalid field %s: failed on the %s rule", e.Field(), e.Tag()),
+			})
+		}
+	} else if err != nil {
+		tr.Failures = append(tr.Failures, Failure{Check: CheckConfig, File: ".kick.yml", Message: err.Error()})
+	}
+
+	// Tags
+	repo, err := r.vcs.Open(dir)
+	if err != nil {
+		tr.Failures = append(tr.Failures, Failure{Check: CheckVersion, Message: fmt.Sprintf("can not open repository: %v", err)})
+	} else {
+		tr.Failures = append(tr.Failures, verifyTags(repo.Tags())...)
+	}
+
+	tr.Failures = append(tr.Failures, verifyConditions(&conf)...)
+	tr.Failures = append(tr.Failures, r.verifyRender(dir, &conf)...)
+	return tr
+}
+
+// verifyTags reports tags that look like versions but are rejected by
+// versiontag.Parse. Such tags are not offered as versions of the template.
+func verifyTags(tags []string) (failures []Failure) {
+	sorted := append([]string{}, tags...)
+	sort.Strings(sorted)
+	for _, t := range sorted {
+		if !versionTag.MatchString(t) {
+			continue
+		}
+		if _, err := versiontag.Parse(t); err != nil {
+			failures = append(failures, Failure{
+				Check:   CheckVersion,
+				Message: fmt.Sprintf("tag %s is not a semantic version, expected [v]MAJOR[.MINOR[.PATCH]]", t),
+			})
+		}
+	}
+	return failures
+}
+
+// verifyDuplicates reports templates that share a name with another template
+// of the repo
+func verifyDuplicates(templates []TemplateReport) {
+	byName := map[string][]int{}
+	for i, tr := range templates {
+		byName[tr.Name] = append(byName[tr.Name], i)
+	}
+	for i, tr := range templates {
+		if len(byName[tr.Name]) < 2 {
+			continue
+		}
+		others := []string{}
+		for _, j := range byName[tr.Name] {
+			if j != i {
+				others = append(others, templates[j].URL)
+			}
+		}
+		templates[i].Failures = append(templates[i].Failures, Failure{
+			Check:   CheckDuplicate,
+			Message: fmt.Sprintf("template name %s is also used by %s", tr.Name, strings.Join(others, ", ")),
+		})
+	}
+}
+
+// verifyConditions reports conditions that use variables that are not
+// declared
+func verifyConditions(conf *configtemplate.TemplateMain) (failures []Failure) {
+	for label, cond := range conf.Conditions {
+		for name := range cond {
+			if _, ok := conf.Envs[name]; !ok {
+				failures = append(failures, Failure{
+					Check:   CheckVariable,
+					File:    ".kick.yml",
+					Message: fmt.Sprintf("condition %s uses %s which is not declared in envs", label, name),
+				})
+			}
+		}
+	}
+	return failures
+}
+
+// sampleVariables returns the variables templates are verified with. The
+// process environment is left out so that a variable that is not declared
+// fails on every machine, whether or not it is set where verify runs. Declared
+// variables are given sample values as the template is loaded. See
+// template.Options.Samples.
+func sampleVariables() *variables.Variables {
+	vars := &variables.Variables{
+		Env:     map[string]string{},
+		Project: map[string]string{},
+		Vars:    map[string]string{},
+	}
+	vars.ProjectVariable("NAME", "example")
+	return vars
+}
+
+// verifyRender generates the template in dir, as a strict dry run of kick
+// start, with sample values of the variables declared by the template and the
+// templates it includes. Files that fail to render and variables that are used
+// but not declared are reported.
+func (r *Repo) verifyRender(dir string, conf *configtemplate.TemplateMain) (failures []Failure) {
+	if conf.Renderer != "" {
+		if _, ok := r.tmplOpts.RenderersAvail.Get(conf.Renderer); !ok {
+			return []Failure{{Check: CheckConfig, File: ".kick.yml", Message: fmt.Sprintf("no such renderer %s", conf.Renderer)}}
+		}
+	}
+
+	// Errors that make kick start exit are logged to out and end the render
+	out := &bytes.Buffer{}
+	defer func() {
+		if p := recover(); p != nil {
+			msg := strings.TrimPrefix(strings.TrimSpace(out.String()), "ERROR ")
+			if msg == "" {
+				msg = fmt.Sprint(p)
+			}
+			failures = append(failures, Failure{Check: CheckRender, Message: msg})
+		}
+	}()
+	exitHandler := &exit.Handler{Mode: exit.MPanic}
+	log := logger.New(out, "", 0, logger.ErrorLevel, exitHandler)
+	o := *r.tmplOpts
+	o.Checkvars = nil
+	o.Errs = errs.New(exitHandler, log)
+	o.Exit = exitHandler
+	o.Log = log
+	o.Samples = true
+	o.Stderr = out
+	o.Stdout = io.Discard
+	o.Variables = sampleVariables()
+	tmpl := template.New(&o)
+	tmpl.SetStrict(true)
+
+	name := conf.Name
+	if name == "" {
+		name = filepath.Base(dir)
+	}
+	tmpl.SetSrcPath(name, dir)
+	for _, err := range tmpl.Check() {
+		failures = append(failures, renderFailures(err)...)
+	}
+	return failures
+}
+
+// renderFailures converts an error returned by template.Check into failures.
+// Variables that are not set are not declared in `.kick.yml`, as every
+// declared variable has a sample value.
+func renderFailures(err error) []Failure {
+	var (
+		merr  *renderer.MissingError
+		ferr  *template.FileError
+		mlerr *template.ModelineError
+	)
+	switch {
+	case errors.As(err, &merr):
+		failures := []Failure{}
+		for _, m := range merr.Missing {
+			failures = append(failures, Failure{
+				Check:   CheckVariable,
+				File:    m.File,
+				Line:    m.Line,
+				Message: fmt.Sprintf("%s is not declared in the envs of .kick.yml", m.Name),
+			})
+		}
+		return failures
+	case errors.As(err, &ferr) && errors.As(err, &mlerr):
+		return []Failure{{Check: CheckModeline, File: ferr.File, Line: mlerr.Line, Message: mlerr.Err.Error()}}
+	case errors.As(err, &ferr):
+		return []Failure{{Check: CheckRender, File: ferr.File, Message: strings.TrimPrefix(ferr.Err.Error(), ferr.Path+": ")}}
+	}
+	return []Failure{{Check: CheckRender, Message: err.Error()}}
+}
+
+// reportFormat returns the report format of path based on its suffix
+func reportFormat(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xml":
+		return ReportJUnit, nil
+	case ".json":
+		return ReportJSON, nil
+	}
+	return "", fmt.Errorf(`unknown report format "%s", expected a .xml or .json file`, path)
+}
+
+// writeReport writes report to path as JUnit XML or JSON
+func writeReport(path string, report *Report) error {
+	format, err := reportFormat(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	switch format {
+	case ReportJUnit:
+		err = writeJUnit(f, report)
+	default:
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	}
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// JUnit XML elements. Each template is a test case of the repo test suite.
+type (
+	junitSuites struct {
+		XMLName xml.Name     `xml:"testsuites"`
+		Suites  []junitSuite `xml:"testsuite"`
+	}
+	junitSuite struct {
+		Name     string      `xml:"name,attr"`
+		Tests    int         `xml:"tests,attr"`
+		Failures int         `xml:"failures,attr"`
+		Cases    []junitCase `xml:"testcase"`
+	}
+	junitCase struct {
+		Name      string        `xml:"name,attr"`
+		Classname string        `xml:"classname,attr"`
+		Failure   *junitFailure `xml:"failure,omitempty"`
+	}
+	junitFailure struct {
+		Message string `xml:"message,attr"`
+		Type    string `xml:"type,attr"`
+		Text    string `xml:",chardata"`
+	}
+)
+
+func writeJUnit(w io.Writer, report *Report) error {
+	suite := junitSuite{
+		Name:     report.Name,
+		Tests:    report.Tests,
+		Failures: report.Failures,
+	}
+	for _, tr := range report.Templates {
+		c := junitCase{Name: tr.Name, Classname: report.Name}
+		if len(tr.Failures) > 0 {
+			text := []string{}
+			for _, f := range tr.Failures {
+				text = append(text, f.String())
+			}
+			c.Failure = &junitFailure{
+				Message: fmt.Sprintf("%d problems found in %s", len(tr.Failures), tr.URL),
+				Type:    tr.Failures[0].Check,
+				Text:    strings.Join(text, "\n"),
+			}
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitSuites{Suites: []junitSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
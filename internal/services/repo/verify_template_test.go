@@ -0,0 +1,148 @@
+package repo_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/kick-project/kick/internal/di"
+	"github.com/kick-project/kick/internal/resources/testtools"
+	"github.com/kick-project/kick/internal/services/repo"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTemplate writes files to a git repository in the directory name of the
+// test
+func writeTemplate(t *testing.T, name string, files map[string]string) string {
+	dir := filepath.Join(testtools.TempDir(), t.Name(), name)
+	_, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	for p, content := range files {
+		p = filepath.Join(dir, p)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		assert.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+	return dir
+}
+
+// verifyRepo returns a repo that fetches templates to a home of the test
+func verifyRepo(t *testing.T) *repo.Repo {
+	home := filepath.Join(testtools.TempDir(), t.Name(), "home")
+	inject := di.New(&di.Options{Home: home, Stderr: &bytes.Buffer{}, Stdout: &bytes.Buffer{}})
+	inject.MakeSetup().Init()
+	return inject.MakeRepo()
+}
+
+// withoutRender returns the failures that are not render failures
+func withoutRender(failures []repo.Failure) (out []repo.Failure, render []repo.Failure) {
+	for _, f := range failures {
+		if f.Check == repo.CheckRender {
+			render = append(render, f)
+			continue
+		}
+		out = append(out, f)
+	}
+	return out, render
+}
+
+func TestVerifyTemplate_Render(t *testing.T) {
+	err := os.RemoveAll(filepath.Join(testtools.TempDir(), t.Name()))
+	assert.NoError(t, err)
+	writeTemplate(t, "inc", map[string]string{
+		".kick.yml": "name: inc\ndescription: included\nenvs:\n  INC_NAME: name\n",
+		"inc.txt":   "# kick:render\n${INC_NAME} ${INC_UNKNOWN}\n",
+	})
+	dir := writeTemplate(t, "verify", map[string]string{
+		"README.md":           "# kick:render\n${PROJECT_NAME} by ${AUTHOR}\n",
+		"home.txt":            "# kick:render\n${HOME}\n",
+		"${LICENSE}.txt":      "# kick:render\n${LICENSE}\n",
+		"main.go.tmpl":        "// kick:render engine=text\n{{.Env.AUTHOR}}\n{{.Env.VERIFY_UNKNOWN}}\n",
+		"bad.txt":             "# kick:rendr\n",
+		"broken.tmpl":         "# kick:render engine=text\n{{if}}\n",
+		"copied.txt":          "${NOT_RENDERED}\n",
+		"ci/${VERIFY_CI}.yml": "",
+		"values.json":         "${AUTHOR} ${VERIFY_MISSING}\n",
+		"ignored/file.txt":    "# kick:rendr\n",
+		"mustache.txt":        "# kick:render engine=none\n",
+		"target.txt":          "# kick:render target=../up\n",
+		"docker/Dockerfile":   "# kick:render label=docker\n${AUTHOR}\n",
+		"labels/compose.yml":  "# kick:ignore\n${IGNORED}\n",
+		".kickignore":         "ignored/\n",
+		".kick.yml": `name: verify
+description: verify template
+includes:
+  - ../inc
+envs:
+  AUTHOR: author
+  LICENSE:
+    description: license
+    choices: [MIT, Apache-2.0]
+conditions:
+  docker:
+    USE_DOCKER: "true"
+render:
+  - "*.json"
+`,
+	})
+
+	tr := repo.VerifyTemplate(verifyRepo(t), dir, "http://example.com/verify.git")
+	assert.Equal(t, "verify", tr.Name)
+	failures, render := withoutRender(tr.Failures)
+	assert.ElementsMatch(t, []repo.Failure{
+		{Check: repo.CheckVariable, File: ".kick.yml", Message: "condition docker uses USE_DOCKER which is not declared in envs"},
+		{Check: repo.CheckVariable, File: "../inc:inc.txt", Line: 2, Message: "INC_UNKNOWN is not declared in the envs of .kick.yml"},
+		{Check: repo.CheckModeline, File: "bad.txt", Line: 1, Message: "modeline error: unknown option: rendr"},
+		{Check: repo.CheckVariable, File: "ci/${VERIFY_CI}.yml", Line: 1, Message: "VERIFY_CI is not declared in the envs of .kick.yml"},
+		{Check: repo.CheckVariable, File: "home.txt", Line: 2, Message: "HOME is not declared in the envs of .kick.yml"},
+		{Check: repo.CheckVariable, File: "main.go.tmpl", Line: 3, Message: ".Env.VERIFY_UNKNOWN is not declared in the envs of .kick.yml"},
+		{Check: repo.CheckVariable, File: "values.json", Line: 1, Message: "VERIFY_MISSING is not declared in the envs of .kick.yml"},
+		{Check: repo.CheckModeline, File: "mustache.txt", Line: 1, Message: "no such renderer none"},
+		{Check: repo.CheckModeline, File: "target.txt", Line: 1, Message: `modeline error: invalid target "../up", expected a file name`},
+	}, failures)
+	if assert.Len(t, render, 1) {
+		assert.Equal(t, "broken.tmpl", render[0].File)
+	}
+}
+
+func TestVerifyTemplate_Pass(t *testing.T) {
+	err := os.RemoveAll(filepath.Join(testtools.TempDir(), t.Name()))
+	assert.NoError(t, err)
+	dir := writeTemplate(t, "pass", map[string]string{
+		"README.md":  "# kick:render\n${PROJECT_NAME} ${USE_DOCKER}\n",
+		"Dockerfile": "# kick:render delims=[[,]] engine=text\n[[.Env.USE_DOCKER]]\n",
+		"copied.txt": "${NOT_RENDERED}\n",
+		".kick.yml":  "name: pass\ndescription: pass\nenvs:\n  USE_DOCKER:\n    description: docker\n    type: bool\n",
+	})
+	tr := repo.VerifyTemplate(verifyRepo(t), dir, "http://example.com/pass.git")
+	assert.Empty(t, tr.Failures)
+
+	err = os.WriteFile(filepath.Join(dir, ".kick.yml"), []byte("name: pass\ndescription: pass\nrenderer: none\n"), 0644)
+	assert.NoError(t, err)
+	tr = repo.VerifyTemplate(verifyRepo(t), dir, "http://example.com/pass.git")
+	assert.Equal(t, []repo.Failure{{Check: repo.CheckConfig, File: ".kick.yml", Message: "no such renderer none"}}, tr.Failures)
+
+	// Errors that end kick start are reported as a render failure
+	err = os.WriteFile(filepath.Join(dir, ".kick.yml"), []byte("name: pass\ndescription: pass\ndelims: [\"<<\", \">>\"]\n"), 0644)
+	assert.NoError(t, err)
+	tr = repo.VerifyTemplate(verifyRepo(t), dir, "http://example.com/pass.git")
+	if assert.Len(t, tr.Failures, 1) {
+		assert.Equal(t, repo.CheckRender, tr.Failures[0].Check)
+		assert.Contains(t, tr.Failures[0].Message, "renderer envsubst does not support delims")
+	}
+}
+
+func TestVerifyTemplate_Config(t *testing.T) {
+	err := os.RemoveAll(filepath.Join(testtools.TempDir(), t.Name()))
+	assert.NoError(t, err)
+	dir := writeTemplate(t, "broken", map[string]string{
+		".kick.yml": "name: [broken\n",
+	})
+	tr := repo.VerifyTemplate(verifyRepo(t), dir, "http://example.com/broken.git")
+	assert.Equal(t, "http://example.com/broken.git", tr.Name)
+	if assert.Len(t, tr.Failures, 1) {
+		assert.Equal(t, repo.CheckConfig, tr.Failures[0].Check)
+		assert.Contains(t, tr.Failures[0].Message, "can not parse")
+	}
+}
@@ -0,0 +1,89 @@
+package repo
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kick-project/kick/internal/resources/testtools"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyTags(t *testing.T) {
+	failures := verifyTags([]string{"1.0.0", "v1.1.0", "1.2", "v2", "release", "2.0.0-rc.1", "v1.x", "1.2.3.4", "01.2.3", "1.2.3-"})
+	assert.Equal(t, []Failure{
+		{Check: CheckVersion, Message: "tag 01.2.3 is not a semantic version, expected [v]MAJOR[.MINOR[.PATCH]]"},
+		{Check: CheckVersion, Message: "tag 1.2.3- is not a semantic version, expected [v]MAJOR[.MINOR[.PATCH]]"},
+		{Check: CheckVersion, Message: "tag 1.2.3.4 is not a semantic version, expected [v]MAJOR[.MINOR[.PATCH]]"},
+		{Check: CheckVersion, Message: "tag v1.x is not a semantic version, expected [v]MAJOR[.MINOR[.PATCH]]"},
+	}, failures)
+}
+
+func TestVerifyDuplicates(t *testing.T) {
+	templates := []TemplateReport{
+		{Name: "tmpl", URL: "http://example.com/a.git"},
+		{Name: "tmpl", URL: "http://example.com/b.git"},
+		{Name: "other", URL: "http://example.com/c.git"},
+	}
+	verifyDuplicates(templates)
+	assert.Equal(t, []Failure{{Check: CheckDuplicate, Message: "template name tmpl is also used by http://example.com/b.git"}}, templates[0].Failures)
+	assert.Equal(t, []Failure{{Check: CheckDuplicate, Message: "template name tmpl is also used by http://example.com/a.git"}}, templates[1].Failures)
+	assert.Empty(t, templates[2].Failures)
+
+	templates = []TemplateReport{
+		{Name: "tmpl", URL: "http://example.com/a.git"},
+		{Name: "tmpl", URL: "http://example.com/a.git"},
+	}
+	verifyDuplicates(templates)
+	assert.Equal(t, []Failure{{Check: CheckDuplicate, Message: "template name tmpl is also used by http://example.com/a.git"}}, templates[0].Failures)
+}
+
+func TestWriteReport(t *testing.T) {
+	report := &Report{
+		Name:     "repo1",
+		Tests:    2,
+		Failures: 1,
+		Templates: []TemplateReport{
+			{Name: "tmpl1", URL: "http://example.com/tmpl1.git", Failures: []Failure{}},
+			{Name: "tmpl2", URL: "http://example.com/tmpl2.git", Failures: []Failure{
+				{Check: CheckVariable, File: "README.md", Line: 2, Message: "AUTHOR is not declared in the envs of .kick.yml"},
+			}},
+		},
+	}
+	dir := filepath.Join(testtools.TempDir(), t.Name())
+	assert.NoError(t, os.MkdirAll(dir, 0755))
+
+	p := filepath.Join(dir, "report.json")
+	assert.NoError(t, writeReport(p, report))
+	b, err := os.ReadFile(p)
+	assert.NoError(t, err)
+	decoded := &Report{}
+	assert.NoError(t, json.Unmarshal(b, decoded))
+	assert.Equal(t, report, decoded)
+
+	p = filepath.Join(dir, "report.xml")
+	assert.NoError(t, writeReport(p, report))
+	b, err = os.ReadFile(p)
+	assert.NoError(t, err)
+	suites := junitSuites{}
+	assert.NoError(t, xml.Unmarshal(b, &suites))
+	if assert.Len(t, suites.Suites, 1) {
+		s := suites.Suites[0]
+		assert.Equal(t, "repo1", s.Name)
+		assert.Equal(t, 2, s.Tests)
+		assert.Equal(t, 1, s.Failures)
+		if assert.Len(t, s.Cases, 2) {
+			assert.Nil(t, s.Cases[0].Failure)
+			if assert.NotNil(t, s.Cases[1].Failure) {
+				assert.Equal(t, CheckVariable, s.Cases[1].Failure.Type)
+				assert.Equal(t, "variable: README.md:2: AUTHOR is not declared in the envs of .kick.yml", s.Cases[1].Failure.Text)
+			}
+		}
+	}
+	assert.True(t, bytes.HasPrefix(b, []byte(xml.Header)))
+
+	assert.Error(t, writeReport(filepath.Join(dir, "report.txt"), report))
+}
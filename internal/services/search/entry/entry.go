@@ -2,10 +2,10 @@ package entry
 
 // Entry an individual entry for a given search
 type Entry struct {
-	Name     string // Template name
-	URL      string // URL location
-	Desc     string // Description
-	RepoName string // The repo associated with the template
-	RepoURL  string // The repos' URL location
-	RepoDesc string // The repos' description
+	Name     string `json:"name" yaml:"name"`                         // Template name
+	URL      string `json:"url" yaml:"url"`                           // URL location
+	Desc     string `json:"description" yaml:"description"`           // Description
+	RepoName string `json:"repo_name" yaml:"repo_name"`               // The repo associated with the template
+	RepoURL  string `json:"repo_url" yaml:"repo_url"`                 // The repos' URL location
+	RepoDesc string `json:"repo_description" yaml:"repo_description"` // The repos' description
 }
@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"io"
 
-	"github.com/olekukonko/tablewriter"
-
+	"github.com/kick-project/kick/internal/resources/errs"
+	"github.com/kick-project/kick/internal/resources/output"
 	"github.com/kick-project/kick/internal/services/search/entry"
 )
 
@@ -15,43 +15,45 @@ type Format interface {
 	Writer(io.Writer, <-chan *entry.Entry)
 }
 
-// Tables format as a table
+// Tables format as a table or the output format of an output.Encoder
 type Tables struct {
 	long bool
+	enc  output.Encoder
 }
 
-// New return Tables pointer
-func New(long bool) *Tables {
+// New return Tables pointer. Entries are encoded with enc, defaulting to a
+// table when enc is nil.
+func New(long bool, enc output.Encoder) *Tables {
+	if enc == nil {
+		enc = &output.TableEncoder{}
+	}
 	return &Tables{
 		long: long,
+		enc:  enc,
 	}
 }
 
 // Writer write entries
 func (t *Tables) Writer(w io.Writer, ch <-chan *entry.Entry) {
-	var (
-		header []string
-		table  [][]string
-	)
+	tbl := &output.Table{}
+	records := []*entry.Entry{}
+	for e := range ch {
+		records = append(records, e)
+	}
 	if t.long {
-		header, table = t.longFmt(ch)
+		tbl.Header, tbl.Rows = t.longFmt(records)
 	} else {
-		header, table = t.shortFmt(ch)
+		tbl.Header, tbl.Rows = t.shortFmt(records)
 	}
-	writer := tablewriter.NewWriter(w)
-	writer.SetAlignment(tablewriter.ALIGN_LEFT)
-	writer.SetHeader(header)
-	for _, v := range table {
-		writer.Append(v)
-	}
-	writer.Render()
+	tbl.Records = records
+	errs.LogF("can not write search results: %w", t.enc.Encode(w, tbl))
 }
 
 // TODO: Unit tests for longFmt
 
-func (t *Tables) longFmt(ch <-chan *entry.Entry) (header []string, table [][]string) {
+func (t *Tables) longFmt(entries []*entry.Entry) (header []string, table [][]string) {
 	header = []string{"Template", "Repository", "Template description", "Template Location"}
-	for e := range ch {
+	for _, e := range entries {
 		row := []string{fmt.Sprintf("%s/%s", e.Name, e.RepoName), e.RepoName, e.Desc, e.URL}
 		table = append(table, row)
 	}
@@ -60,9 +62,9 @@ func (t *Tables) longFmt(ch <-chan *entry.Entry) (header []string, table [][]str
 
 // TODO: Unit tests for shortFmt
 
-func (t *Tables) shortFmt(ch <-chan *entry.Entry) (header []string, table [][]string) {
+func (t *Tables) shortFmt(entries []*entry.Entry) (header []string, table [][]string) {
 	header = []string{"Template", "Location"}
-	for e := range ch {
+	for _, e := range entries {
 		row := []string{fmt.Sprintf("%s/%s", e.Name, e.RepoName), e.URL}
 		table = append(table, row)
 	}
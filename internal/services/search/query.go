@@ -0,0 +1,140 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/kick-project/kick/internal/resources/fts"
+	"github.com/kick-project/kick/internal/resources/versiontag"
+)
+
+// Query a parsed search query
+type Query struct {
+	Terms    []fts.Term          // Full text terms. Every term must match
+	Repos    []string            // Repository names from repo: filters
+	Labels   []string            // Labels from label: filters
+	Versions []VersionConstraint // Constraints from version: filters
+}
+
+// VersionConstraint a version: filter, E.G. version:>=1.2
+type VersionConstraint struct {
+	Op      string // One of =, >, >=, <, <=
+	Version *semver.Version
+}
+
+// Match returns true if v satisfies the constraint
+func (c VersionConstraint) Match(v *semver.Version) bool {
+	switch c.Op {
+	case ">":
+		return c.Version.LessThan(*v)
+	case ">=":
+		return !v.LessThan(*c.Version)
+	case "<":
+		return v.LessThan(*c.Version)
+	case "<=":
+		return !c.Version.LessThan(*v)
+	default:
+		return v.Equal(*c.Version)
+	}
+}
+
+// ParseQuery parses a search query. A query is made up of words, "quoted
+// phrases", prefixes ending with * and the filters repo:<name>,
+// label:<label> and version:<op><version>.
+func ParseQuery(term string) (*Query, error) {
+	q := &Query{}
+	for _, tok := range tokenize(term) {
+		if tok.quoted {
+			q.Terms = append(q.Terms, fts.Term{Text: tok.text, Phrase: true})
+			continue
+		}
+		key, value, found := strings.Cut(tok.text, ":")
+		switch {
+		case found && strings.EqualFold(key, "repo"):
+			q.Repos = append(q.Repos, value)
+		case found && strings.EqualFold(key, "label"):
+			q.Labels = append(q.Labels, value)
+		case found && strings.EqualFold(key, "version"):
+			c, err := parseConstraint(value)
+			if err != nil {
+				return nil, err
+			}
+			q.Versions = append(q.Versions, c)
+		default:
+			for _, word := range words(tok.text) {
+				q.Terms = append(q.Terms, word)
+			}
+		}
+	}
+	return q, nil
+}
+
+// token a whitespace separated or quoted part of a query
+type token struct {
+	text   string
+	quoted bool
+}
+
+func tokenize(term string) []token {
+	tokens := []token{}
+	for len(term) > 0 {
+		term = strings.TrimLeft(term, " \t\r\n")
+		if term == "" {
+			break
+		}
+		if term[0] == '"' {
+			end := strings.IndexByte(term[1:], '"')
+			if end == -1 {
+				end = len(term) - 1
+			}
+			if text := strings.TrimSpace(term[1 : end+1]); text != "" {
+				tokens = append(tokens, token{text: text, quoted: true})
+			}
+			if end+2 > len(term) {
+				end = len(term) - 2
+			}
+			term = term[end+2:]
+			continue
+		}
+		end := strings.IndexAny(term, " \t\r\n")
+		if end == -1 {
+			end = len(term)
+		}
+		tokens = append(tokens, token{text: term[:end]})
+		term = term[end:]
+	}
+	return tokens
+}
+
+// words splits text into full text terms. A trailing * marks a prefix.
+// Punctuation separates words the same way as the SQLite tokenizer.
+func words(text string) []fts.Term {
+	prefix := strings.HasSuffix(text, "*")
+	parts := strings.FieldsFunc(text, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r > 127)
+	})
+	terms := []fts.Term{}
+	for i, p := range parts {
+		terms = append(terms, fts.Term{Text: p, Prefix: prefix && i == len(parts)-1})
+	}
+	return terms
+}
+
+func parseConstraint(value string) (VersionConstraint, error) {
+	c := VersionConstraint{Op: "="}
+	filter := value
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(value, op) {
+			c.Op = op
+			value = value[len(op):]
+			break
+		}
+	}
+	v, err := versiontag.Parse(value)
+	if err != nil {
+		return c, fmt.Errorf("invalid version filter version:%s: %w", filter, err)
+	}
+	c.Version = v
+	return c, nil
+}
@@ -3,109 +3,228 @@ package search
 
 import (
 	"database/sql"
-	"fmt"
 	"io"
+	"strings"
 
+	"github.com/coreos/go-semver/semver"
 	"github.com/kick-project/kick/internal/resources/errs"
+	"github.com/kick-project/kick/internal/resources/fts"
+	"github.com/kick-project/kick/internal/resources/model"
+	"github.com/kick-project/kick/internal/resources/output"
+	"github.com/kick-project/kick/internal/resources/versiontag"
 	"github.com/kick-project/kick/internal/services/search/entry"
 	"github.com/kick-project/kick/internal/services/search/formatter"
 	"gorm.io/gorm"
 )
 
-var querySearch = `
-SELECT templateName, templateURL, templateDesc, repoName, repoURL, repoDesc
-FROM
-(
-	SELECT
-		template.name AS templateName,
-		template.url AS templateURL,
-		template.desc AS templateDesc,
-		repo.name AS repoName,
-		repo.url AS repoURL,
-		repo.desc AS repoDesc,
-		CASE WHEN LOWER(template.name) LIKE LOWER(?)
-			THEN
-				template.name
-			ELSE
-				NULL
-		END AS match1,
-		CASE WHEN LOWER(template.url) LIKE LOWER(?)
-			THEN
-				template.name
-			ELSE
-				NULL
-		END AS match2,
-		CASE WHEN LOWER(repo.name) LIKE LOWER(?)
-		THEN
-			template.name
-		ELSE
-			NULL
-		END AS match3
-	FROM template LEFT JOIN repo_template ON (template.id = repo_template.template_id)
-	LEFT JOIN repo ON (repo_template.repo_id = repo.id)
-	WHERE match1 IS NOT NULL OR match2 IS NOT NULL OR match3 IS NOT NULL
-	ORDER BY
-		match1 ASC NULLS LAST,
-		match2 ASC NULLS LAST,
-		match3 ASC NULLS LAST
-)
+var queryTemplates = `
+SELECT
+	template.id,
+	template.name,
+	template.url,
+	template.desc,
+	template.labels,
+	repo.name,
+	repo.url,
+	repo.desc
+FROM template LEFT JOIN repo_template ON (template.id = repo_template.template_id)
+LEFT JOIN repo ON (repo_template.repo_id = repo.id)
+WHERE template.id IN ? AND template.deleted_at IS NULL
+ORDER BY repo.name
 `
 
 // Search search for templates
 //go:generate ifacemaker -f search.go -s Search -p search -i SearchIface -o search_interfaces.go -c "AUTO GENERATED. DO NOT EDIT."
 type Search struct {
-	ORM    *gorm.DB  `validate:"required"`
-	Writer io.Writer `validate:"required"`
+	ORM     *gorm.DB       `validate:"required"`
+	Writer  io.Writer      `validate:"required"`
+	Encoder output.Encoder `validate:"required"`
 }
 
 // Search searches database for term and returns the results through *Entry channel.
+// See ParseQuery for the query syntax. Results are ranked best match first.
 func (s *Search) Search(term string) <-chan *entry.Entry {
 	ch := make(chan *entry.Entry, 24)
 	go func() {
-		var err error
-		rows, err := s.ORM.Raw(
-			querySearch,
-			fmt.Sprintf("%s%%", term),
-			fmt.Sprintf("%%%s%%", term),
-			fmt.Sprintf("%%%s%%", term),
-		).Rows()
+		defer close(ch)
+		q, err := ParseQuery(term)
+		if errs.LogF("invalid search: %w", err) {
+			return
+		}
+
+		ids := s.match(q)
+		if len(ids) == 0 {
+			return
+		}
+		versions := s.versions(ids)
+
+		rows, err := s.ORM.Raw(queryTemplates, ids).Rows()
 		errs.PanicF("query error: %w", err)
 		defer rows.Close()
 
+		results := map[uint][]*entry.Entry{}
 		for rows.Next() {
 			var (
+				id       uint
 				name     sql.NullString
 				URL      sql.NullString
 				desc     sql.NullString
+				labels   sql.NullString
 				repoName sql.NullString
 				repoURL  sql.NullString
 				repoDesc sql.NullString
 			)
 			err := rows.Scan(
-				&name, &URL, &desc,
+				&id, &name, &URL, &desc, &labels,
 				&repoName, &repoURL, &repoDesc,
 			)
 			errs.FatalF("%v", err)
 
-			ch <- &entry.Entry{
+			if !matchRepo(q.Repos, repoName.String) ||
+				!matchLabels(q.Labels, labels.String) ||
+				!matchVersions(q.Versions, versions[id]) {
+				continue
+			}
+			results[id] = append(results[id], &entry.Entry{
 				Name:     name.String,
 				URL:      URL.String,
 				Desc:     desc.String,
 				RepoName: repoName.String,
 				RepoURL:  repoURL.String,
 				RepoDesc: repoDesc.String,
+			})
+		}
+
+		for _, id := range ids {
+			for _, e := range results[id] {
+				ch <- e
 			}
 		}
-		close(ch)
 	}()
 	return ch
 }
 
+// match returns the IDs of templates matching the full text terms of q, best
+// match first, followed by templates whose name contains every term, E.G.
+// mytemplate1 for template. All templates are returned sorted by name when q
+// has no terms.
+func (s *Search) match(q *Query) []uint {
+	ids := []uint{}
+	if len(q.Terms) == 0 {
+		err := s.ORM.Model(&model.Template{}).Order("name, id").Pluck("id", &ids).Error
+		errs.PanicF("query error: %w", err)
+		return ids
+	}
+
+	if !fts.Exists(s.ORM) {
+		errs.PanicF("%w", fts.Rebuild(s.ORM))
+	}
+	results, err := fts.Match(s.ORM, q.Terms)
+	errs.PanicF("%w", err)
+	seen := map[uint]bool{}
+	for _, r := range results {
+		ids = append(ids, r.TemplateID)
+		seen[r.TemplateID] = true
+	}
+	for _, id := range s.nameContains(q.Terms) {
+		if !seen[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// nameContains returns the IDs of templates whose name contains the text of
+// every term, sorted by name
+func (s *Search) nameContains(terms []fts.Term) []uint {
+	ids := []uint{}
+	tx := s.ORM.Model(&model.Template{})
+	for _, t := range terms {
+		tx = tx.Where(`LOWER(name) LIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(strings.ToLower(t.Text))+"%")
+	}
+	err := tx.Order("name, id").Pluck("id", &ids).Error
+	errs.PanicF("query error: %w", err)
+	return ids
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// versions returns the versions of each template
+func (s *Search) versions(ids []uint) map[uint][]*semver.Version {
+	rows := []model.Versions{}
+	err := s.ORM.Where("template_id IN ?", ids).Find(&rows).Error
+	errs.PanicF("query error: %w", err)
+
+	versions := map[uint][]*semver.Version{}
+	for _, row := range rows {
+		v, err := versiontag.Parse(row.Version)
+		if err != nil {
+			continue
+		}
+		versions[row.TemplateID] = append(versions[row.TemplateID], v)
+	}
+	return versions
+}
+
 // Search2Output searches database for term and sends the results to the formatter.Format function supplied in New.
+// Results are written in the output format of Encoder.
 // Blocks until all entries are processed.
 func (s *Search) Search2Output(long bool, term string) int {
+	if _, err := ParseQuery(term); errs.LogF("invalid search: %w", err) {
+		return 255
+	}
 	ch := s.Search(term)
-	output := formatter.New(long)
-	output.Writer(s.Writer, ch)
+	format := formatter.New(long, s.Encoder)
+	format.Writer(s.Writer, ch)
 	return 0
 }
+
+// matchRepo returns true if repo is one of repos or repos is empty
+func matchRepo(repos []string, repo string) bool {
+	for _, r := range repos {
+		if strings.EqualFold(r, repo) {
+			return true
+		}
+	}
+	return len(repos) == 0
+}
+
+// matchLabels returns true if every label in want is in the space separated
+// labels
+func matchLabels(want []string, labels string) bool {
+	have := strings.Fields(strings.ToLower(labels))
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if h == strings.ToLower(w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// matchVersions returns true if a version satisfies every constraint
+func matchVersions(constraints []VersionConstraint, versions []*semver.Version) bool {
+	if len(constraints) == 0 {
+		return true
+	}
+	for _, v := range versions {
+		ok := true
+		for _, c := range constraints {
+			if !c.Match(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
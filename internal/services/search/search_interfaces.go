@@ -9,8 +9,10 @@ import (
 // SearchIface ...
 type SearchIface interface {
 	// Search searches database for term and returns the results through *Entry channel.
+	// See ParseQuery for the query syntax. Results are ranked best match first.
 	Search(term string) <-chan *entry.Entry
 	// Search2Output searches database for term and sends the results to the formatter.Format function supplied in New.
+	// Results are written in the output format of Encoder.
 	// Blocks until all entries are processed.
 	Search2Output(long bool, term string) int
 }
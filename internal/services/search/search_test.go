@@ -1,90 +1,181 @@
 package search_test
 
 import (
-	"fmt"
+	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
-	"regexp"
 	"testing"
 
 	"github.com/kick-project/kick/internal/di"
+	"github.com/kick-project/kick/internal/resources/fts"
 	"github.com/kick-project/kick/internal/resources/model"
+	"github.com/kick-project/kick/internal/resources/output"
 	"github.com/kick-project/kick/internal/resources/testtools"
+	"github.com/kick-project/kick/internal/services/search"
+	"github.com/kick-project/kick/internal/services/search/entry"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
 func TestSearch(t *testing.T) {
-	// Initialize database
-	home := filepath.Join(testtools.TempDir(), "TestSearch")
-	inject := di.New(&di.Options{Home: home})
-	i := inject.MakeSetup()
-	if _, err := os.Stat(inject.SqliteDB); err == nil {
-		err = os.Remove(inject.SqliteDB)
-		if err != nil {
-			t.Error(err)
+	srch := newSearch(t, "TestSearch")
+
+	tests := []struct {
+		term      string
+		want      []string
+		unordered bool // Results rank equally so their order is not fixed
+	}{
+		// Words match names, descriptions and URLs
+		{"template", []string{"firsttemplate", "template1", "template2", "boilerplate3", "boilerplate2", "boilerplate3", "mytemplate1", "mytemplate1", "mytemplate4", "mytemplate5"}, true},
+		// Prefix
+		{"first*", []string{"firsttemplate"}, false},
+		// Phrase
+		{`"boiler plate" mytemplate1`, []string{"mytemplate1", "mytemplate1"}, false},
+		{`"plate boiler"`, []string{}, false},
+		// Names rank above URLs. Names containing the term follow
+		{"template1", []string{"template1", "boilerplate1", "mytemplate1", "mytemplate1"}, false},
+		// Repository names and descriptions
+		{"testrepo2", []string{"mytemplate1", "mytemplate1", "boilerplate1", "boilerplate2", "boilerplate3"}, true},
+		// Labels and variables
+		{"cli", []string{"template1"}, false},
+		{"project_name", []string{"template1"}, false},
+		// Filters
+		{"template repo:testrepo2", []string{"boilerplate2", "boilerplate3", "mytemplate1", "mytemplate1"}, true},
+		{"template repo:TestRepo repo:testrepo2", []string{"firsttemplate", "template1", "template2", "boilerplate3", "boilerplate2", "boilerplate3", "mytemplate1", "mytemplate1"}, true},
+		{"template repo:norepo", []string{}, false},
+		{"label:go", []string{"template1"}, false},
+		{"template label:python", []string{"template2"}, false},
+		{"version:>=1.2", []string{"template1"}, false},
+		{"version:1.1", []string{"template2"}, false},
+		{"version:<1.2", []string{"template1", "template2"}, false},
+		{"template version:<1", []string{}, false},
+		{"version:>=1", []string{"template1", "template2"}, false},
+	}
+	for _, tt := range tests {
+		got := []string{}
+		for e := range srch.Search(tt.term) {
+			got = append(got, e.Name)
+		}
+		if tt.unordered {
+			assert.ElementsMatch(t, tt.want, got, tt.term)
+			continue
 		}
+		assert.Equal(t, tt.want, got, tt.term)
 	}
-	i.Init()
-	db := inject.MakeORM()
-	buildSearchDataORM(t, db)
-
-	// Target search term
-	searchTerm := "template"
-
-	srch := inject.MakeSearch()
 
-	// Parallel arrays to count regexp
-	matchmaker := map[string]*regexp.Regexp{}
-	counts := map[string]int{}
-
-	rePrefix := regexp.MustCompile(fmt.Sprintf("(?i)^%s", searchTerm))
-	reContains := regexp.MustCompile(fmt.Sprintf("(?i)%s", searchTerm))
-	matchmaker["namePrefix"] = rePrefix
-	matchmaker["nameContains"] = reContains
-	matchmaker["urlContains"] = reContains
-	for key := range matchmaker {
-		counts[key] = 0
+	all := 0
+	for range srch.Search("") {
+		all++
 	}
+	assert.Equal(t, 11, all)
+}
 
-	totalTemplateRows := 0
-	for entry := range srch.Search("template") {
-		t.Logf("%v", entry)
-		totalTemplateRows++
-		if matchmaker["namePrefix"].MatchString(entry.Name) {
-			counts["namePrefix"]++
-		}
-		if matchmaker["nameContains"].MatchString(entry.Name) {
-			counts["nameContains"]++
+func TestSearch_NameContains(t *testing.T) {
+	srch := newSearch(t, "TestSearch_NameContains")
+
+	for term, want := range map[string][]string{
+		"template":   {"firsttemplate", "mytemplate1", "mytemplate4", "mytemplate5", "template1", "template2"},
+		"boiler":     {"boilerplate1", "boilerplate2", "boilerplate3"},
+		"late mytem": {"mytemplate1", "mytemplate4", "mytemplate5"},
+	} {
+		names := map[string]bool{}
+		for e := range srch.Search(term) {
+			names[e.Name] = true
 		}
-		if matchmaker["urlContains"].MatchString(entry.URL) {
-			counts["urlContains"]++
+		for _, name := range want {
+			assert.True(t, names[name], "%s does not find %s", term, name)
 		}
 	}
 
-	totalTestRepo2Rows := 0
-	for range srch.Search("testrepo2") {
-		totalTestRepo2Rows++
+	// LIKE wildcards in phrases are matched literally
+	for e := range srch.Search(`"t_mplate"`) {
+		t.Errorf(`"t_mplate" matches %s`, e.Name)
 	}
+}
+
+func TestSearch_Rank(t *testing.T) {
+	srch := newSearch(t, "TestSearch_Rank")
 
-	totalBoilerplate2Rows := 0
-	for range srch.Search("boilerplate") {
-		totalBoilerplate2Rows++
+	got := []string{}
+	for e := range srch.Search("template") {
+		got = append(got, e.Name)
 	}
+	// Shortest descriptions rank first
+	assert.Equal(t, "firsttemplate", got[0])
+}
+
+func TestSearch_Search2Output(t *testing.T) {
+	srch := newSearch(t, "TestSearch_Search2Output")
+	assert.Equal(t, 0, srch.Search2Output(false, "label:go"))
+	assert.Equal(t, 255, srch.Search2Output(false, "version:>=x"))
+}
+
+func TestSearch_Search2Output_JSON(t *testing.T) {
+	srch := newSearch(t, "TestSearch_Search2Output_JSON")
+	out := &bytes.Buffer{}
+	srch.Writer = out
+	srch.Encoder = &output.JSONEncoder{}
+	assert.Equal(t, 0, srch.Search2Output(false, "label:go"))
+
+	records := []entry.Entry{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &records))
+	assert.Equal(t, []entry.Entry{{
+		Name:     "template1",
+		URL:      "http://127.0.0.1:8080/tmpl1.git",
+		Desc:     "My Template Description",
+		RepoName: "testrepo",
+		RepoURL:  "http://127.0.0.1:8080/repo1.git",
+		RepoDesc: "Repo 1",
+	}}, records)
+}
+
+func TestParseQuery(t *testing.T) {
+	q, err := search.ParseQuery(`go "hello world" cli* repo:main label:web version:>=1.2`)
+	assert.NoError(t, err)
+	assert.Equal(t, []fts.Term{
+		{Text: "go"},
+		{Text: "hello world", Phrase: true},
+		{Text: "cli", Prefix: true},
+	}, q.Terms)
+	assert.Equal(t, []string{"main"}, q.Repos)
+	assert.Equal(t, []string{"web"}, q.Labels)
+	assert.Len(t, q.Versions, 1)
+	assert.Equal(t, ">=", q.Versions[0].Op)
+	assert.Equal(t, "1.2.0", q.Versions[0].Version.String())
+
+	q, err = search.ParseQuery("version:<v1")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.0.0", q.Versions[0].Version.String())
 
-	// Match expected counts
-	assert.Equal(t, 2, counts["namePrefix"])
-	assert.Equal(t, 4, counts["nameContains"])
-	assert.Equal(t, 5, counts["urlContains"])
-	assert.Equal(t, 7, totalTemplateRows)
-	assert.Equal(t, 5, totalTestRepo2Rows)
-	assert.Equal(t, 4, totalBoilerplate2Rows)
+	_, err = search.ParseQuery("version:>=x")
+	assert.Error(t, err)
 }
 
 //
 // Utils
 //
+func newSearch(t *testing.T, name string) *search.Search {
+	home := filepath.Join(testtools.TempDir(), name)
+	inject := di.New(&di.Options{Home: home})
+	i := inject.MakeSetup()
+	if _, err := os.Stat(inject.SqliteDB); err == nil {
+		err = os.Remove(inject.SqliteDB)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	i.Init()
+	db := inject.MakeORM()
+	buildSearchDataORM(t, db)
+	err := fts.Rebuild(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return inject.MakeSearch()
+}
+
 func buildSearchDataORM(t *testing.T, db *gorm.DB) {
 	m1 := model.Repo{
 		Name: "testrepo",
@@ -107,14 +198,19 @@ func buildSearchDataORM(t *testing.T, db *gorm.DB) {
 			Desc: "My First Template",
 		},
 		{
-			Name: "template1",
-			URL:  "http://127.0.0.1:8080/tmpl1.git",
-			Desc: "My Template Description",
+			Name:     "template1",
+			URL:      "http://127.0.0.1:8080/tmpl1.git",
+			Desc:     "My Template Description",
+			Labels:   "go cli",
+			Vars:     "project_name",
+			Versions: []model.Versions{{Version: "1.0.0"}, {Version: "1.2.0"}},
 		},
 		{
-			Name: "template2",
-			URL:  "http://127.0.0.1:8080/tmpl2.git",
-			Desc: "My Template Description",
+			Name:     "template2",
+			URL:      "http://127.0.0.1:8080/tmpl2.git",
+			Desc:     "My Template Description",
+			Labels:   "python",
+			Versions: []model.Versions{{Version: "1.1.0"}},
 		},
 		{
 			Name: "boilerplate3",
@@ -3,6 +3,8 @@ package start
 import (
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"text/tabwriter"
@@ -11,14 +13,18 @@ import (
 	"github.com/kick-project/kick/internal/resources/checkvars"
 	"github.com/kick-project/kick/internal/resources/cond"
 	"github.com/kick-project/kick/internal/resources/config"
+	"github.com/kick-project/kick/internal/resources/config/configtemplate"
 	"github.com/kick-project/kick/internal/resources/errs"
 	"github.com/kick-project/kick/internal/resources/exit"
+	"github.com/kick-project/kick/internal/resources/file"
 	"github.com/kick-project/kick/internal/resources/handle"
+	"github.com/kick-project/kick/internal/resources/marshal"
+	"github.com/kick-project/kick/internal/resources/output"
 	"github.com/kick-project/kick/internal/resources/sync"
 	"github.com/kick-project/kick/internal/resources/template"
+	"github.com/kick-project/kick/internal/resources/template/renderer"
 	"github.com/kick-project/kick/internal/resources/template/variables"
 	"github.com/kick-project/kick/internal/resources/templatescan"
-	"github.com/olekukonko/tablewriter"
 	terminal "github.com/wayneashleyberry/terminal-dimensions"
 	"gorm.io/gorm"
 )
@@ -28,11 +34,54 @@ type ShowOptions uint
 const (
 	// Show entries
 	SLABEL ShowOptions = 1 << iota
+	// Show the rule that decides if a file is rendered
+	SRULE
 )
 
 type showRow struct {
 	file  string
 	label []string
+	rule  string
+}
+
+// ShowRecord a template file in JSON and YAML output of Show
+type ShowRecord struct {
+	Path   string   `json:"path" yaml:"path"`                     // Path of the file in the template
+	Labels []string `json:"labels" yaml:"labels"`                 // Labels of the file
+	Rule   string   `json:"rule,omitempty" yaml:"rule,omitempty"` // Rule that decides if the file is rendered
+}
+
+// ListRecord an installed template in JSON and YAML output of List
+type ListRecord struct {
+	Handle   string `json:"handle" yaml:"handle"`               // Handle used to start a project
+	Template string `json:"template" yaml:"template"`           // Name of the template
+	Origin   string `json:"origin" yaml:"origin"`               // Repository the template was installed from
+	Desc     string `json:"description" yaml:"description"`     // Description of the template
+	URL      string `json:"url" yaml:"url"`                     // Location of the template
+	Ref      string `json:"ref,omitempty" yaml:"ref,omitempty"` // Version or ref the template is pinned to
+}
+
+// FunctionRecord a text/template function in JSON and YAML output of Functions
+type FunctionRecord struct {
+	Name        string `json:"name" yaml:"name"`               // Name used in templates
+	Usage       string `json:"usage" yaml:"usage"`             // Example of a call
+	Description string `json:"description" yaml:"description"` // What the function returns
+}
+
+// PlanRecord a destination path in JSON and YAML output of a dry run
+type PlanRecord struct {
+	Path     string `json:"path" yaml:"path"`                             // Destination path
+	Action   string `json:"action" yaml:"action"`                         // One of the template.Action* constants
+	Renderer string `json:"renderer,omitempty" yaml:"renderer,omitempty"` // Renderer of rendered files
+	Layer    string `json:"layer" yaml:"layer"`                           // Template the path comes from
+	Content  string `json:"content,omitempty" yaml:"content,omitempty"`   // Rendered content. Only with --content
+	Conflict bool   `json:"conflict,omitempty" yaml:"conflict,omitempty"` // Path exists in the destination
+}
+
+// MergeRecord a file written or skipped in JSON and YAML output of a merge
+type MergeRecord struct {
+	Path   string `json:"path" yaml:"path"`     // Path relative to the project
+	Status string `json:"status" yaml:"status"` // One of created, overwritten, skipped, conflict or unchanged
 }
 
 // Start manage listing of installed templates
@@ -43,6 +92,7 @@ type Start struct {
 	checkvars *checkvars.Check
 	conf      *config.File
 	db        *gorm.DB
+	enc       output.Encoder
 	exit      exit.HandlerIface
 	handle    *handle.Handle
 	scan      *templatescan.Scan
@@ -58,6 +108,7 @@ type Options struct {
 	CheckVars *checkvars.Check       `validate:"required"`
 	Conf      *config.File           `validate:"required"`
 	DB        *gorm.DB               `validate:"required"`
+	Encoder   output.Encoder         `validate:"required"`
 	Exit      exit.HandlerIface      `validate:"required"`
 	Handle    *handle.Handle         `validate:"required"`
 	Scan      *templatescan.Scan     `validate:"required"`
@@ -74,6 +125,7 @@ func New(opts Options) *Start {
 		checkvars: opts.CheckVars,
 		conf:      opts.Conf,
 		db:        opts.DB,
+		enc:       opts.Encoder,
 		exit:      opts.Exit,
 		handle:    opts.Handle,
 		scan:      opts.Scan,
@@ -85,8 +137,21 @@ func New(opts Options) *Start {
 	return s
 }
 
+// StartOptions options to Start.Start
+type StartOptions struct {
+	DryRun       bool     // Print a plan instead of creating the project
+	Merge        string   // Conflict policy to render into an existing project. See file.Conflict*
+	NoHooks      bool     // Do not run hooks declared in .kick.yml
+	IncludeHooks bool     // Also run hooks declared by included templates
+	Labels       []string // Only generate files without labels or with one of these labels
+	ShowContent  bool     // Include the rendered content of files in the plan
+	Strict       bool     // Fail if rendered files use variables that are not set or are empty
+	Vars         []string // Template variables in the form KEY=VALUE
+	VarsFiles    []string // Answer files in YAML, JSON or dotenv format
+}
+
 // Start start command
-func (s Start) Start(projectname, template, path string) {
+func (s Start) Start(projectname, template, path string, opts StartOptions) {
 	if err := s.check.Init(); err != nil {
 		fmt.Fprintf(s.stderr, "%s\n", err.Error())
 		s.exit.Exit(255)
@@ -98,22 +163,80 @@ func (s Start) Start(projectname, template, path string) {
 	// Set varaibles
 	vars := variables.New()
 	vars.ProjectVariable("NAME", projectname)
+	if err := s.loadVars(vars, opts); err != nil {
+		fmt.Fprintf(s.stderr, "%s\n", err.Error())
+		s.exit.Exit(255)
+	}
 	s.tmpl.SetVars(vars)
 
+	if opts.Merge != "" {
+		if err := file.ValidPolicy(opts.Merge); err != nil {
+			fmt.Fprintf(s.stderr, "%s\n", err.Error())
+			s.exit.Exit(255)
+		}
+	}
+
+	// Set before SetSrcDest, which prompts for variables
+	s.tmpl.SetDryRun(opts.DryRun, opts.ShowContent)
 	// Set project name
 	s.tmpl.SetSrcDest(template, path)
-	_ = s.tmpl.Run()
+	s.tmpl.SetMerge(opts.Merge)
+	s.tmpl.SetNoHooks(opts.NoHooks)
+	s.tmpl.SetIncludeHooks(opts.IncludeHooks)
+	s.tmpl.SetLabels(opts.Labels)
+	s.tmpl.SetStrict(opts.Strict)
+	ret := s.tmpl.Run()
+	switch {
+	case ret != 0:
+	case opts.DryRun:
+		s.fmtPlan(s.tmpl.Plan(), opts.ShowContent, path, opts.Merge)
+	case opts.Merge != "" && s.tmpl.MergeResult() != nil:
+		s.fmtMerge(s.tmpl.MergeResult())
+	}
 }
 
-// List lists the output
+// loadVars loads answer files followed by KEY=VALUE variables. Variables
+// loaded later take precedence over those loaded earlier and all of them take
+// precedence over the environment.
+func (s Start) loadVars(vars *variables.Variables, opts StartOptions) error {
+	for _, f := range opts.VarsFiles {
+		if err := vars.LoadFile(f); err != nil {
+			return err
+		}
+	}
+	for _, kv := range opts.Vars {
+		if err := vars.ParseVar(kv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List lists the output. Output formats other than a table always include
+// every field.
 func (s *Start) List(long bool) {
-	if long {
+	if long || s.enc.Format() != output.TABLE {
 		s.fmtListLong()
 	} else {
 		s.fmtListShort()
 	}
 }
 
+// Functions lists the functions available to text/template templates
+func (s *Start) Functions() {
+	tbl := &output.Table{
+		Header: []string{"Function", "Usage", "Description"},
+		NoWrap: true,
+	}
+	records := []FunctionRecord{}
+	for _, f := range renderer.Funcs {
+		tbl.Rows = append(tbl.Rows, []string{f.Name, f.Usage, f.Description})
+		records = append(records, FunctionRecord{Name: f.Name, Usage: f.Usage, Description: f.Description})
+	}
+	tbl.Records = records
+	errs.Fatal(s.enc.Encode(s.stdout, tbl))
+}
+
 // Show show template files generated within a handle. If a slice of incLabels
 // is provided, only files and directories that have matching labels will be
 // displayed. If a file or directory has no label it is always displayed.
@@ -157,10 +280,55 @@ func (s *Start) Show(hdle string, incLabels []string, ops ShowOptions) {
 			tblIdx[r.Path] = idx
 		}
 	}
+	if ops&SRULE != 0 {
+		s.showRules(tmplDir, rows, tblIdx)
+	}
 	for _, r := range rows {
 		tbl = append(tbl, tblIdx[r])
 	}
-	s.fmtShow(tbl, SLABEL)
+	s.fmtShow(tbl, ops)
+}
+
+// showRules sets the rule that decides if each file is rendered. A modeline
+// takes precedence over the render and norender lists in .kick.yml.
+func (s *Start) showRules(tmplDir string, rows []string, tblIdx map[string]showRow) {
+	type Row struct {
+		Dir    string
+		Path   string
+		Option string
+	}
+	results := []Row{}
+	tx := s.db.Raw(templatescan.QueryScanOption+" WHERE base = ? AND option IS NOT NULL", tmplDir).Scan(&results)
+	errs.Fatal(tx.Error)
+	options := map[string][]string{}
+	for _, r := range results {
+		options[r.Path] = append(options[r.Path], r.Option)
+	}
+
+	conf := &configtemplate.TemplateMain{}
+	confPath := filepath.Join(tmplDir, ".kick.yml")
+	if _, err := os.Stat(confPath); err == nil {
+		err = marshal.FromFile(conf, confPath)
+		errs.Fatal(err)
+	}
+
+	for _, p := range rows {
+		row := tblIdx[p]
+		row.rule = "-"
+		info, err := os.Lstat(filepath.Join(tmplDir, filepath.FromSlash(p)))
+		switch {
+		case err != nil || !info.Mode().IsRegular():
+		case cond.ContainsString("render", options[p]...):
+			row.rule = "modeline: render"
+		case cond.ContainsString("ignore", options[p]...):
+			row.rule = "modeline: ignore"
+		default:
+			if _, rule := conf.Rules.Match(p, false); rule != "" {
+				row.rule = rule
+			}
+		}
+		tblIdx[p] = row
+	}
 }
 
 func (s *Start) fmtListShort() {
@@ -196,11 +364,10 @@ func (s *Start) fmtListShort() {
 }
 
 func (s *Start) fmtListLong() {
-	var (
-		header []string
-		table  [][]string
-	)
-	header = []string{"Handle", "Template", "Description", "Location"}
+	tbl := &output.Table{
+		Header: []string{"Handle", "Template", "Description", "Location"},
+	}
+	records := []ListRecord{}
 	for _, row := range s.sort(s.conf.Templates) {
 		var (
 			templateName string
@@ -218,37 +385,131 @@ func (s *Start) fmtListLong() {
 		if desc == "" {
 			desc = "-"
 		}
-		table = append(table, []string{row.Handle, templateName, desc, row.URL})
-	}
-	writer := tablewriter.NewWriter(s.stdout)
-	writer.SetAlignment(tablewriter.ALIGN_LEFT)
-	writer.SetHeader(header)
-	for _, v := range table {
-		writer.Append(v)
+		tbl.Rows = append(tbl.Rows, []string{row.Handle, templateName, desc, row.URL})
+		records = append(records, ListRecord{
+			Handle:   row.Handle,
+			Template: row.Template,
+			Origin:   row.Origin,
+			Desc:     row.Desc,
+			URL:      row.URL,
+			Ref:      row.Ref,
+		})
 	}
-	writer.Render()
+	tbl.Records = records
+	errs.Fatal(s.enc.Encode(s.stdout, tbl))
 }
 
 func (s *Start) fmtShow(tbl []showRow, show ShowOptions) {
 	displayLabel := show&SLABEL != 0
-	writer := tablewriter.NewWriter(s.stdout)
-	writer.SetAlignment(tablewriter.ALIGN_LEFT)
-	var (
-		header []string = []string{"Files"}
-		row    []string
-	)
+	displayRule := show&SRULE != 0
+	out := &output.Table{
+		Header: []string{"Files"},
+	}
 	if displayLabel {
-		header = append(header, "Labels")
+		out.Header = append(out.Header, "Labels")
 	}
-	writer.SetHeader(header)
+	if displayRule {
+		out.Header = append(out.Header, "Rule")
+	}
+	records := []ShowRecord{}
 	for _, r := range tbl {
-		row = []string{r.file}
+		row := []string{r.file}
+		record := ShowRecord{Path: r.file, Labels: []string{}}
 		if displayLabel {
 			row = append(row, strings.Join(r.label, " "))
+			record.Labels = append(record.Labels, r.label...)
+		}
+		if displayRule {
+			row = append(row, r.rule)
+			if r.rule != "-" {
+				record.Rule = r.rule
+			}
+		}
+		out.Rows = append(out.Rows, row)
+		records = append(records, record)
+	}
+	out.Records = records
+	errs.Fatal(s.enc.Encode(s.stdout, out))
+}
+
+// fmtPlan writes the plan of a dry run into path. The table format is followed
+// by a note when path exists and merge is not set, and the content of rendered
+// files if content is true. Other output formats hold the content in their
+// records.
+func (s *Start) fmtPlan(plan []template.PlanEntry, content bool, path, merge string) {
+	tbl := &output.Table{
+		Header: []string{"Path", "Action", "Renderer", "Layer", "Conflict"},
+	}
+	records := []PlanRecord{}
+	for _, e := range plan {
+		renderer := e.Renderer
+		if renderer == "" {
+			renderer = "-"
+		}
+		conflict := "-"
+		if e.Conflict {
+			conflict = "exists"
+		}
+		tbl.Rows = append(tbl.Rows, []string{e.Path, e.Action, renderer, e.Layer, conflict})
+		records = append(records, PlanRecord{Path: e.Path, Action: e.Action, Renderer: e.Renderer, Layer: e.Layer, Content: e.Content, Conflict: e.Conflict})
+	}
+	tbl.Records = records
+	errs.Fatal(s.enc.Encode(s.stdout, tbl))
+	if s.enc.Format() != output.TABLE {
+		return
+	}
+	if _, err := os.Stat(path); err == nil && merge == "" {
+		fmt.Fprintf(s.stdout, "path '%s' exists. kick start aborts unless --merge is set\n", path)
+	}
+	if !content {
+		return
+	}
+	for _, e := range plan {
+		if e.Action != template.ActionRender {
+			continue
+		}
+		fmt.Fprintf(s.stdout, "\n==> %s <==\n%s", e.Path, e.Content)
+		if !strings.HasSuffix(e.Content, "\n") {
+			fmt.Fprintln(s.stdout)
 		}
-		writer.Append(row)
 	}
-	writer.Render()
+}
+
+// fmtMerge writes the files affected by a merge. The table format leaves out
+// unchanged files, which are only counted, and is followed by a summary.
+func (s *Start) fmtMerge(result *file.MergeResult) {
+	tbl := &output.Table{
+		Header: []string{"File", "Status"},
+	}
+	records := []MergeRecord{}
+	groups := []struct {
+		status string
+		files  []string
+	}{
+		{"created", result.Created},
+		{"overwritten", result.Overwritten},
+		{"skipped", result.Skipped},
+		{"conflict", result.Conflicts},
+		{"unchanged", result.Unchanged},
+	}
+	for _, g := range groups {
+		for _, f := range g.files {
+			if g.status != "unchanged" {
+				tbl.Rows = append(tbl.Rows, []string{f, g.status})
+			}
+			records = append(records, MergeRecord{Path: f, Status: g.status})
+		}
+	}
+	tbl.Records = records
+	errs.Fatal(s.enc.Encode(s.stdout, tbl))
+	if s.enc.Format() != output.TABLE {
+		return
+	}
+	fmt.Fprintf(s.stdout, "%d created, %d overwritten, %d skipped, %d conflicts, %d unchanged\n",
+		len(result.Created), len(result.Overwritten), len(result.Skipped), len(result.Conflicts), len(result.Unchanged))
+	if len(result.Conflicts) > 0 {
+		fmt.Fprintf(s.stdout, "conflicting files were written with the %s suffix, numbered if that file already existed\n", file.KeepBothSuffix)
+	}
 }
 
 func (s *Start) sort(in []config.Template) (out []config.Template) {
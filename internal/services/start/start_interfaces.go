@@ -5,9 +5,12 @@ package start
 // StartIface ...
 type StartIface interface {
 	// Start start command
-	Start(projectname, template, path string)
-	// List lists the output
+	Start(projectname, template, path string, opts StartOptions)
+	// List lists the output. Output formats other than a table always include
+	// every field.
 	List(long bool)
+	// Functions lists the functions available to text/template templates
+	Functions()
 	// Show show files used in a template. base is the path to the template
 	// directory on local disk. If a slice of incLabels is provided, only files,
 	// directories that have matching labels will be displayed. If a file or
@@ -2,20 +2,27 @@ package start_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/kick-project/kick/internal/di"
 	"github.com/kick-project/kick/internal/resources/client/plumb"
 	"github.com/kick-project/kick/internal/resources/config"
 	"github.com/kick-project/kick/internal/resources/exit"
+	"github.com/kick-project/kick/internal/resources/file"
 	"github.com/kick-project/kick/internal/resources/handle"
 	"github.com/kick-project/kick/internal/resources/marshal"
+	"github.com/kick-project/kick/internal/resources/output"
+	"github.com/kick-project/kick/internal/resources/template"
 	"github.com/kick-project/kick/internal/resources/testtools"
 	"github.com/kick-project/kick/internal/services/start"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
 )
 
 var (
@@ -42,6 +49,30 @@ func TestStart_List_Long(t *testing.T) {
 	assert.Regexp(t, `\|\s+handle4\s+\|\s+-\s+\|\s+-\s+\|\s+http://\S+`, out)
 }
 
+func TestStart_List_JSON(t *testing.T) {
+	s, _, stdout := makeOutput(output.JSON)
+	s.List(false)
+	records := []start.ListRecord{}
+	err := json.Unmarshal(stdout.Bytes(), &records)
+	assert.NoError(t, err)
+	if assert.Len(t, records, 4) {
+		assert.Equal(t, start.ListRecord{
+			Handle:   "handle1",
+			Template: "template1",
+			Origin:   "origin1",
+			URL:      "http://template.io/template1.git",
+		}, records[0])
+	}
+}
+
+func TestStart_List_TSV(t *testing.T) {
+	s, _, stdout := makeOutput(output.TSV)
+	s.List(true)
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	assert.Equal(t, "Handle\tTemplate\tDescription\tLocation", lines[0])
+	assert.Equal(t, "handle1\ttemplate1/origin1\t-\thttp://template.io/template1.git", lines[1])
+}
+
 func TestStart_Start(t *testing.T) {
 	tmpdir := testtools.TempDir()
 	path, _ := os.MkdirTemp(tmpdir, "start-")
@@ -59,7 +90,7 @@ func TestStart_Start(t *testing.T) {
 		}
 	}()
 	s, _, _ := make()
-	s.Start(project, tmpl, path)
+	s.Start(project, tmpl, path, start.StartOptions{})
 
 	type interpolated struct {
 		Project string `yaml:"project"`
@@ -77,6 +108,15 @@ func TestStart_Start(t *testing.T) {
 	assert.Equal(t, d.Home, os.Getenv("HOME"))
 }
 
+func TestStart_Show_YAML(t *testing.T) {
+	s, _, stdout := makeOutput(output.YAML)
+	s.Show("gotesthandle", []string{}, start.SLABEL)
+	records := []start.ShowRecord{}
+	err := yaml.Unmarshal(stdout.Bytes(), &records)
+	assert.NoError(t, err)
+	assert.Contains(t, records, start.ShowRecord{Path: "go.mod", Labels: []string{"go", "core"}})
+}
+
 func TestStart_Show(t *testing.T) {
 	s, _, stdout := make()
 	s.Show("gotesthandle", []string{}, start.SLABEL)
@@ -90,10 +130,15 @@ func TestStart_Show(t *testing.T) {
 }
 
 func make() (s *start.Start, stderr *bytes.Buffer, stdout *bytes.Buffer) {
+	return makeOutput(output.TABLE)
+}
+
+func makeOutput(format string) (s *start.Start, stderr *bytes.Buffer, stdout *bytes.Buffer) {
 	home, _ := filepath.Abs(filepath.Join(testtools.TempDir(), "home"))
 	stderr, stdout, conf := getOptions()
 	inject := di.New(&di.Options{
-		Home: home,
+		Home:   home,
+		Output: format,
 	})
 	setup := inject.MakeSetup()
 	setup.Init()
@@ -104,10 +149,14 @@ func make() (s *start.Start, stderr *bytes.Buffer, stdout *bytes.Buffer) {
 					Handle: "gotesthandle",
 					URL:    filepath.Join(testtools.FixtureDir(), "gotemplate"),
 				},
+				{
+					Handle: "rendertemplate",
+					URL:    filepath.Join(testtools.FixtureDir(), "rendertemplate"),
+				},
 			},
 		},
 		Plumb: func(url, ref string) (*plumb.Plumb, error) {
-			return plumb.New("", FixtureTemplate, "")
+			return plumb.New("", url, "")
 		},
 	})
 	o := start.Options{
@@ -116,6 +165,7 @@ func make() (s *start.Start, stderr *bytes.Buffer, stdout *bytes.Buffer) {
 		CheckVars: inject.MakeCheckVars(),
 		Exit:      &exit.Handler{Mode: exit.MPanic},
 		DB:        inject.MakeORMInMemory(),
+		Encoder:   inject.MakeEncoder(),
 		Handle:    h,
 		Scan:      inject.MakeScan(),
 		Stderr:    stderr,
@@ -159,3 +209,175 @@ func getOptions() (stderr, stdout *bytes.Buffer, conf *config.File) {
 	}
 	return
 }
+
+func TestStart_Start_Vars(t *testing.T) {
+	s, _, _ := make()
+	addTemplate(t, "vartemplate", filepath.Join(testtools.FixtureDir(), "vartemplate"))
+
+	path := filepath.Join(mkdtemp(t), "varproject")
+	s.Start("varproject", "vartemplate", path, start.StartOptions{
+		Vars:      []string{"VT_LICENSE=Apache-2.0"},
+		VarsFiles: []string{filepath.Join(testtools.FixtureDir(), "answers", "vartemplate.yml")},
+	})
+
+	b, err := os.ReadFile(filepath.Join(path, "README.md"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "# varproject")
+	assert.Contains(t, string(b), "author: John Smith")
+	assert.Contains(t, string(b), "license: Apache-2.0")
+
+	answers := map[string]string{}
+	err = marshal.FromFile(&answers, filepath.Join(path, template.AnswersFile))
+	assert.NoError(t, err)
+	assert.Equal(t, "John Smith", answers["VT_AUTHOR"])
+	assert.Equal(t, "Apache-2.0", answers["VT_LICENSE"])
+}
+
+func TestStart_Start_VarsDotenv(t *testing.T) {
+	s, _, _ := make()
+	addTemplate(t, "vartemplate", filepath.Join(testtools.FixtureDir(), "vartemplate"))
+
+	path := filepath.Join(mkdtemp(t), "dotenvproject")
+	s.Start("dotenvproject", "vartemplate", path, start.StartOptions{
+		VarsFiles: []string{filepath.Join(testtools.FixtureDir(), "answers", "vartemplate.env")},
+	})
+
+	b, err := os.ReadFile(filepath.Join(path, "README.md"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "author: Jane Doe")
+	assert.Contains(t, string(b), "license: Apache-2.0")
+}
+
+// addTemplate installs a template located on the local filesystem.
+func addTemplate(t *testing.T, hdl, path string) {
+	home, _ := filepath.Abs(filepath.Join(testtools.TempDir(), "home"))
+	inject := di.New(&di.Options{
+		Home: home,
+	})
+	conf := inject.ConfigFile()
+	for _, cur := range conf.Templates {
+		if cur.Handle == hdl {
+			return
+		}
+	}
+	err := conf.AppendTemplate(config.Template{
+		Handle: hdl,
+		URL:    path,
+	})
+	assert.NoError(t, err)
+	err = conf.SaveTemplates()
+	assert.NoError(t, err)
+}
+
+func mkdtemp(t *testing.T) string {
+	d, err := os.MkdirTemp(testtools.TempDir(), "start-")
+	assert.NoError(t, err)
+	return d
+}
+
+func TestStart_Start_DryRun(t *testing.T) {
+	s, _, stdout := make()
+	addTemplate(t, "vartemplate", filepath.Join(testtools.FixtureDir(), "vartemplate"))
+
+	path := filepath.Join(mkdtemp(t), "dryrunproject")
+	s.Start("dryrunproject", "vartemplate", path, start.StartOptions{
+		DryRun:      true,
+		ShowContent: true,
+		VarsFiles:   []string{filepath.Join(testtools.FixtureDir(), "answers", "vartemplate.yml")},
+	})
+
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	out := stdout.String()
+	assert.Regexp(t, `\|\s+PATH\s+\|\s+ACTION\s+\|\s+RENDERER\s+\|`, out)
+	assert.Regexp(t, `\|\s+\S+/dryrunproject\s+\|\s+mkdir\s+\|\s+-\s+\|`, out)
+	assert.Regexp(t, `\|\s+\S+/dryrunproject/README.md\s+\|\s+render\s+\|\s+envsubst\s+\|`, out)
+	assert.Regexp(t, `\|\s+\S+/dryrunproject/.kick.yml\s+\|\s+skip\s+\|\s+-\s+\|`, out)
+	assert.Contains(t, out, "/dryrunproject/README.md <==\n# dryrunproject\n")
+	assert.Contains(t, out, "author: John Smith")
+}
+
+func TestStart_Start_DryRun_Exists(t *testing.T) {
+	s, _, stdout := make()
+	addTemplate(t, "vartemplate", filepath.Join(testtools.FixtureDir(), "vartemplate"))
+
+	path := filepath.Join(mkdtemp(t), "dryrunexists")
+	err := os.MkdirAll(path, 0755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(path, "README.md"), []byte("existing\n"), 0644)
+	assert.NoError(t, err)
+
+	// Neither aborts on the existing path nor prompts for the unset variables
+	s.Start("dryrunexists", "vartemplate", path, start.StartOptions{DryRun: true, ShowContent: true})
+
+	out := stdout.String()
+	assert.Regexp(t, `\|\s+\S+/dryrunexists\s+\|\s+mkdir\s+\|\s+-\s+\|\s+vartemplate\s+\|\s+-\s+\|`, out)
+	assert.Regexp(t, `\|\s+\S+/dryrunexists/README.md\s+\|\s+render\s+\|\s+envsubst\s+\|\s+vartemplate\s+\|\s+exists\s+\|`, out)
+	assert.Contains(t, out, "path '"+path+"' exists. kick start aborts unless --merge is set")
+	assert.Contains(t, out, "author: sample-vt_author\nlicense: MIT\n")
+	b, err := os.ReadFile(filepath.Join(path, "README.md"))
+	assert.NoError(t, err)
+	assert.Equal(t, "existing\n", string(b))
+}
+
+func TestStart_Start_Merge(t *testing.T) {
+	s, _, stdout := make()
+	addTemplate(t, "vartemplate", filepath.Join(testtools.FixtureDir(), "vartemplate"))
+
+	path := filepath.Join(mkdtemp(t), "mergeproject")
+	err := os.MkdirAll(path, 0755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(path, "README.md"), []byte("existing\n"), 0644)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(path, "NOTES.txt"), []byte("notes\n"), 0644)
+	assert.NoError(t, err)
+
+	s.Start("mergeproject", "vartemplate", path, start.StartOptions{
+		Merge:     file.ConflictKeepBoth,
+		VarsFiles: []string{filepath.Join(testtools.FixtureDir(), "answers", "vartemplate.yml")},
+	})
+
+	b, err := os.ReadFile(filepath.Join(path, "README.md"))
+	assert.NoError(t, err)
+	assert.Equal(t, "existing\n", string(b))
+	b, err = os.ReadFile(filepath.Join(path, "README.md"+file.KeepBothSuffix))
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "author: John Smith")
+	assert.FileExists(t, filepath.Join(path, "NOTES.txt"))
+	assert.FileExists(t, filepath.Join(path, template.AnswersFile))
+
+	out := stdout.String()
+	assert.Regexp(t, `\|\s+README.md\s+\|\s+conflict\s+\|`, out)
+	assert.Regexp(t, `\|\s+`+regexp.QuoteMeta(template.AnswersFile)+`\s+\|\s+created\s+\|`, out)
+	assert.Regexp(t, `\|\s+`+regexp.QuoteMeta(template.ManifestFile)+`\s+\|\s+created\s+\|`, out)
+	assert.Contains(t, out, "2 created, 0 overwritten, 0 skipped, 1 conflicts, 0 unchanged")
+}
+
+func TestStart_Show_Rule(t *testing.T) {
+	s, _, stdout := make()
+	s.Show("rendertemplate", []string{}, start.SRULE)
+	out := stdout.String()
+	assert.Regexp(t, `\|\s+FILES\s+\|\s+RULE\s+\|`, out)
+	assert.Regexp(t, `\|\s+config.json\s+\|\s+render: \*.json\s+\|`, out)
+	assert.Regexp(t, `\|\s+scripts/setup.sh\s+\|\s+render: scripts/\s+\|`, out)
+	assert.Regexp(t, `\|\s+testdata/fixture.json\s+\|\s+norender: testdata/\s+\|`, out)
+	assert.Regexp(t, `\|\s+testdata/modeline.txt\s+\|\s+modeline: render\s+\|`, out)
+	assert.Regexp(t, `\|\s+plain.txt\s+\|\s+-\s+\|`, out)
+}
+
+func TestStart_Functions(t *testing.T) {
+	s, _, stdout := make()
+	s.Functions()
+	assert.Regexp(t, `FUNCTION\s+\|\s+USAGE\s+\|\s+DESCRIPTION`, stdout.String())
+	assert.Regexp(t, `snake\s+\|\s+\{\{snake \.Project\.NAME\}\}\s+\|\s+Convert to snake_case`, stdout.String())
+}
+
+func TestStart_Functions_JSON(t *testing.T) {
+	s, _, stdout := makeOutput(output.JSON)
+	s.Functions()
+	records := []start.FunctionRecord{}
+	err := json.Unmarshal(stdout.Bytes(), &records)
+	assert.NoError(t, err)
+	assert.Contains(t, records, start.FunctionRecord{Name: "snake", Usage: "{{snake .Project.NAME}}", Description: "Convert to snake_case"})
+}
@@ -1,16 +1,20 @@
 package update
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/kick-project/kick/internal/resources/client"
 	"github.com/kick-project/kick/internal/resources/config"
 	"github.com/kick-project/kick/internal/resources/errs"
+	"github.com/kick-project/kick/internal/resources/fts"
 	"github.com/kick-project/kick/internal/resources/logger"
 	"github.com/kick-project/kick/internal/resources/marshal"
 	"github.com/kick-project/kick/internal/resources/model"
+	"github.com/kick-project/kick/internal/resources/serialize"
 	_ "github.com/mattn/go-sqlite3" // Required by 'database/sql'
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -21,6 +25,7 @@ import (
 type Update struct {
 	client      *client.Client
 	configFile  *config.File       `validate:"required"`
+	ctx         context.Context    `validate:"required"`
 	err         errs.HandlerIface  `validate:"required"`
 	orm         *gorm.DB           `validate:"required"`
 	log         logger.OutputIface `validate:"required"`
@@ -31,6 +36,7 @@ type Update struct {
 type Options struct {
 	Client      *client.Client     `validate:"required"`
 	ConfigFile  *config.File       `validate:"required"`
+	Context     context.Context    `validate:"required"` // Cancels fetches
 	Err         errs.HandlerIface  `validate:"required"`
 	ORM         *gorm.DB           `validate:"required"`
 	Log         logger.OutputIface `validate:"required"`
@@ -42,6 +48,7 @@ func New(opts *Options) *Update {
 	return &Update{
 		client:      opts.Client,
 		configFile:  opts.ConfigFile,
+		ctx:         opts.Context,
 		err:         opts.Err,
 		orm:         opts.ORM,
 		log:         opts.Log,
@@ -53,6 +60,11 @@ func New(opts *Options) *Update {
 func (m *Update) Build() error {
 	conf := m.configFile
 
+	err := model.Migrate(m.orm)
+	if err != nil {
+		return fmt.Errorf("can not migrate database: %w", err)
+	}
+
 	c := workers{
 		client: m.client,
 		err:    m.err,
@@ -60,20 +72,33 @@ func (m *Update) Build() error {
 		log:    m.log,
 	}
 
-	churl := make(chan string, 64)
 	chtemplates := make(chan *Template, 64)
-	c.concurClones(6, churl, chtemplates)
 	c.concurInserts(m.orm, chtemplates)
 
+	// Repos with an index are fetched over HTTP, others are cloned
+	fetches := []client.Fetch{}
 	for _, url := range conf.RepoURLs {
-		c.wait.Add(1)
-		churl <- url
+		if !client.IsIndex(url) {
+			fetches = append(fetches, client.Fetch{Kind: client.REPO, URL: url})
+			continue
+		}
+		index, err := m.client.GetIndex(m.ctx, url)
+		if c.err.LogF("error: %v: skipping %s\n", err, url) {
+			continue
+		}
+		c.processIndex(url, index, chtemplates)
+	}
+	for _, fetched := range m.client.FetchAll(m.ctx, fetches) {
+		if fetched.Err != nil {
+			continue
+		}
+		c.processRepo(fetched.URL, fetched.Plumb.Path(), chtemplates)
 	}
 
 	// Wait for all all processing to finish
 	c.wait.Wait()
 
-	return nil
+	return fts.Rebuild(m.orm)
 }
 
 type workers struct {
@@ -83,39 +108,12 @@ type workers struct {
 	wait   *sync.WaitGroup
 }
 
-// concurClones concurrent cloning of git repositories.
-// where num is the number of concurrent downloads, churl is a string url and tchan is a channel of resulting templates.
-func (c *workers) concurClones(num int, churl <-chan string, tchan chan<- *Template) {
-	for i := 0; i < num; i++ {
-		go func() {
-			for {
-				url, ok := <-churl
-				switch {
-				case !ok:
-					return
-				default:
-					c.processURL(url, tchan)
-					c.wait.Done()
-				}
-			}
-		}()
-	}
-}
-
-func (c *workers) processURL(url string, chtemplate chan<- *Template) {
-	p, err := c.client.GetRepo(url, "")
-	if c.err.LogF(`error cloning "%s": %w`, url, err) {
-		return
-	}
-	localpath := p.Path()
-
+// processRepo loads the templates of the repo url fetched to localpath
+func (c *workers) processRepo(url, localpath string, chtemplate chan<- *Template) {
 	mpath := filepath.Clean(fmt.Sprintf("%s/repo.yml", localpath))
-	if c.err.LogF("error: can not open %s: %w: skipping %s", mpath, err, url) {
-		return
-	}
 
 	repo := &Repo{URL: url}
-	err = repo.Load(mpath)
+	err := repo.Load(mpath)
 	if c.err.LogF("error: %w: skipping %s\n", err, url) {
 		return
 	}
@@ -137,6 +135,31 @@ func (c *workers) processURL(url string, chtemplate chan<- *Template) {
 	}
 }
 
+// processIndex loads the templates of the repo index fetched from url
+func (c *workers) processIndex(url string, index *serialize.RepoIndex, chtemplate chan<- *Template) {
+	repo := Repo{
+		Name:        index.Name,
+		URL:         url,
+		Description: index.Desc,
+	}
+	for _, entry := range index.Templates {
+		t := &Template{
+			Name:        entry.Name,
+			URL:         entry.URL,
+			Description: entry.Desc,
+			Versions:    []string{},
+			Repo:        repo,
+
+			RepoTemplateMeta: entry.RepoTemplateMeta,
+		}
+		for _, v := range entry.Versions {
+			t.Versions = append(t.Versions, v.Version)
+		}
+		c.wait.Add(1)
+		chtemplate <- t
+	}
+}
+
 // concurInserts populates the database
 // where num is the number of concurrent routines
 // and ch is the channel to read templates from.
@@ -175,10 +198,12 @@ func (c *workers) insert(orm *gorm.DB, t *Template) {
 	}
 
 	modTemplate := model.Template{
-		Name: t.Name,
-		URL:  t.URL,
-		Desc: t.Description,
-		Repo: []model.Repo{modRepo},
+		Name:   t.Name,
+		URL:    t.URL,
+		Desc:   t.Description,
+		Labels: strings.Join(t.Labels, " "),
+		Vars:   strings.Join(t.VariableNames(), " "),
+		Repo:   []model.Repo{modRepo},
 	}
 	result = orm.Clauses(clause.Insert{Modifier: "OR IGNORE"}).Create(&modTemplate)
 	if result.RowsAffected != 1 {
@@ -189,6 +214,8 @@ func (c *workers) insert(orm *gorm.DB, t *Template) {
 		modTemplate.Name = t.Name
 		modTemplate.URL = t.URL
 		modTemplate.Desc = t.Description
+		modTemplate.Labels = strings.Join(t.Labels, " ")
+		modTemplate.Vars = strings.Join(t.VariableNames(), " ")
 		modTemplate.Repo = append(modTemplate.Repo, modRepo)
 
 		orm.Model(&modTemplate).Updates(&modTemplate)
@@ -205,6 +232,34 @@ func (c *workers) insert(orm *gorm.DB, t *Template) {
 			c.err.Panic(result.Error)
 		}
 	}
+
+	c.insertMeta(orm, modTemplate.ID, t)
+}
+
+// insertMeta replaces the metadata and variables of the template templateID
+func (c *workers) insertMeta(orm *gorm.DB, templateID uint, t *Template) {
+	err := orm.Unscoped().Where("template_id = ?", templateID).Delete(&model.TemplateMeta{}).Error
+	c.err.Panic(err)
+	err = orm.Create(&model.TemplateMeta{
+		TemplateID: templateID,
+		Renderer:   t.Renderer,
+		Keywords:   strings.Join(t.Keywords, " "),
+		License:    t.License,
+		Readme:     t.Readme,
+	}).Error
+	c.err.Panic(err)
+
+	err = orm.Unscoped().Where("template_id = ?", templateID).Delete(&model.Variable{}).Error
+	c.err.Panic(err)
+	for _, v := range t.Variables {
+		err = orm.Create(&model.Variable{
+			TemplateID: templateID,
+			Name:       v.Name,
+			Desc:       v.Desc,
+			Default:    v.Default,
+		}).Error
+		c.err.Panic(err)
+	}
 }
 
 // Repo is the repo struct
@@ -231,6 +286,8 @@ type Template struct {
 	Description string   `json:"description" yaml:"description"`
 	Versions    []string `json:"versions" yaml:"versions"`
 	Repo        Repo
+
+	serialize.RepoTemplateMeta `yaml:",inline"`
 }
 
 // Load loads from a json or yaml file
@@ -2,14 +2,18 @@ package update_test
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	fp "path/filepath"
 	"testing"
 
 	"github.com/kick-project/kick/internal/di"
 	"github.com/kick-project/kick/internal/resources/errs"
+	"github.com/kick-project/kick/internal/resources/model"
 	"github.com/kick-project/kick/internal/resources/testtools"
 	"github.com/kick-project/kick/internal/services/update"
+	"github.com/stretchr/testify/assert"
 	"syreclabs.com/go/faker"
 )
 
@@ -140,3 +144,70 @@ func fakeJSON(t *testing.T) (path, name, url, desc string) {
 	tf.Close()
 	return tf.Name(), name, url, desc
 }
+
+func TestUpdate_Build_Index(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name": "indexrepo", "description": "index repo", "templates": [
+  {"name": "indexed", "description": "indexed template", "url": "http://127.0.0.1:8080/tmpl1.git",
+   "versions": [{"version": "1.0.0", "commit": "abc"}, {"version": "1.1.0", "commit": "def"}],
+   "labels": ["go"], "keywords": ["cli"], "renderer": "texttemplate", "license": "MIT",
+   "readme": "An indexed template", "variables": [{"name": "AUTHOR", "description": "project author"}]}
+]}`)
+	}))
+	defer srv.Close()
+
+	home := fp.Join(testtools.TempDir(), "TestUpdate_Build_Index")
+	err := os.RemoveAll(home)
+	assert.NoError(t, err)
+	s := di.New(&di.Options{
+		Home: home,
+	})
+	initIt(s)
+	conf := s.ConfigFile()
+	conf.RepoURLs = []string{srv.URL + "/index.json"}
+
+	err = s.MakeUpdate().Build()
+	assert.NoError(t, err)
+
+	repo := model.Repo{}
+	result := s.MakeORM().First(&repo, "url = ?", srv.URL+"/index.json")
+	assert.NoError(t, result.Error)
+	assert.Equal(t, "indexrepo", repo.Name)
+
+	tmpl := model.Template{}
+	result = s.MakeORM().First(&tmpl, "name = ?", "indexed")
+	assert.NoError(t, result.Error)
+	assert.Equal(t, "http://127.0.0.1:8080/tmpl1.git", tmpl.URL)
+
+	var versions int64
+	s.MakeORM().Model(&model.Versions{}).Where("template_id = ?", tmpl.ID).Count(&versions)
+	assert.Equal(t, int64(2), versions)
+
+	assert.Equal(t, "go", tmpl.Labels)
+	assert.Equal(t, "AUTHOR", tmpl.Vars)
+
+	meta := model.TemplateMeta{}
+	result = s.MakeORM().First(&meta, "template_id = ?", tmpl.ID)
+	assert.NoError(t, result.Error)
+	assert.Equal(t, "texttemplate", meta.Renderer)
+	assert.Equal(t, "cli", meta.Keywords)
+	assert.Equal(t, "MIT", meta.License)
+	assert.Equal(t, "An indexed template", meta.Readme)
+
+	vars := []model.Variable{}
+	result = s.MakeORM().Find(&vars, "template_id = ?", tmpl.ID)
+	assert.NoError(t, result.Error)
+	if assert.Len(t, vars, 1) {
+		assert.Equal(t, "AUTHOR", vars[0].Name)
+		assert.Equal(t, "project author", vars[0].Desc)
+	}
+
+	// Updating again replaces the metadata
+	err = s.MakeUpdate().Build()
+	assert.NoError(t, err)
+	var count int64
+	s.MakeORM().Model(&model.Variable{}).Where("template_id = ?", tmpl.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+	s.MakeORM().Model(&model.TemplateMeta{}).Where("template_id = ?", tmpl.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
@@ -0,0 +1,282 @@
+package upgrade
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/kick-project/kick/internal/resources/client"
+	"github.com/kick-project/kick/internal/resources/client/plumb"
+	"github.com/kick-project/kick/internal/resources/config"
+	"github.com/kick-project/kick/internal/resources/errs"
+	"github.com/kick-project/kick/internal/resources/logger"
+	"github.com/kick-project/kick/internal/resources/output"
+	"github.com/kick-project/kick/internal/resources/sync"
+	"github.com/kick-project/kick/internal/resources/vcs"
+	"github.com/kick-project/kick/internal/resources/versiontag"
+	"gorm.io/gorm"
+)
+
+// Upgrade list and upgrade the versions that installed templates are pinned to
+//
+//go:generate ifacemaker -f upgrade.go -s Upgrade -p upgrade -i UpgradeIface -o upgrade_interfaces.go -c "AUTO GENERATED. DO NOT EDIT."
+type Upgrade struct {
+	client *client.Client     // Git client
+	conf   *config.File       // Config file
+	enc    output.Encoder     // Output encoder
+	errs   errs.HandlerIface  // Error handler
+	log    logger.OutputIface // Logger
+	orm    *gorm.DB           // GoRM
+	stdout io.Writer          // Stdout
+	sync   sync.SyncIface     // Sync installed templates
+	vcs    *vcs.VCS           // Version control repo
+}
+
+// Options options for New
+type Options struct {
+	Client     *client.Client     `validate:"required"`            // Git client
+	Conf       *config.File       `validate:"required"`            // Config file
+	Encoder    output.Encoder     `validate:"required"`            // Output encoder
+	ErrHandler errs.HandlerIface  `validate:"required"`            // Error handler
+	Log        logger.OutputIface `validate:"required"`            // Logger
+	ORM        *gorm.DB           `validate:"required,structonly"` // GORM
+	Stdout     io.Writer          `validate:"required"`            // Writer
+	Sync       sync.SyncIface     `validate:"required"`            // Sync installed templates
+	VCS        *vcs.VCS           `validate:"required"`            // Version Control Repo
+}
+
+// Record a template pinned to an outdated version in JSON and YAML output
+type Record struct {
+	Handle  string `json:"handle" yaml:"handle"`   // Handle of the template
+	Current string `json:"current" yaml:"current"` // Version the template is pinned to
+	Latest  string `json:"latest" yaml:"latest"`   // Latest version of the template
+	URL     string `json:"url" yaml:"url"`         // Location of the template
+}
+
+// New construct an Upgrade object
+func New(opts *Options) *Upgrade {
+	return &Upgrade{
+		client: opts.Client,
+		conf:   opts.Conf,
+		enc:    opts.Encoder,
+		errs:   opts.ErrHandler,
+		log:    opts.Log,
+		orm:    opts.ORM,
+		stdout: opts.Stdout,
+		sync:   opts.Sync,
+		vcs:    opts.VCS,
+	}
+}
+
+// Outdated lists installed templates that are pinned to a version older than
+// the latest version. Versions are semantic version tags in the template's
+// repository and the versions listed in repo metadata. Output formats other
+// than a table encode an empty list when every template is up to date.
+func (u *Upgrade) Outdated() int {
+	tbl := &output.Table{
+		Header: []string{"Handle", "Current", "Latest", "Location"},
+	}
+	records := []Record{}
+	for _, t := range u.conf.Templates {
+		current, err := versiontag.Parse(t.Ref)
+		if err != nil {
+			// Not pinned or pinned to a branch or commit
+			continue
+		}
+		versions, err := u.versions(t)
+		if u.errs.LogF("can not list versions of %s: %v", t.Handle, err) {
+			continue
+		}
+		latest := versions[len(versions)-1]
+		if !current.LessThan(*latest.semver) {
+			continue
+		}
+		tbl.Rows = append(tbl.Rows, []string{t.Handle, t.Ref, latest.tag, t.URL})
+		records = append(records, Record{Handle: t.Handle, Current: t.Ref, Latest: latest.tag, URL: t.URL})
+	}
+	tbl.Records = records
+
+	if len(records) == 0 && u.enc.Format() == output.TABLE {
+		fmt.Fprintln(u.stdout, "all pinned templates are up to date")
+		return 0
+	}
+	if u.errs.LogF("can not write outdated templates: %w", u.enc.Encode(u.stdout, tbl)) {
+		return 255
+	}
+	return 0
+}
+
+// Upgrade moves the pin of handle to version and prints the commits between
+// the old and new refs. If version is empty the latest version is used. If
+// handle is empty every template pinned to a version is upgraded to its
+// latest version.
+func (u *Upgrade) Upgrade(handle, version string) int {
+	if handle == "" && version != "" {
+		u.log.Printf("a handle is required to upgrade to version %s\n", version)
+		return 255
+	}
+
+	templates := []config.Template{}
+	for _, t := range u.conf.Templates {
+		switch {
+		case handle != "" && t.Handle == handle:
+			templates = append(templates, t)
+		case handle == "":
+			if _, err := versiontag.Parse(t.Ref); err == nil {
+				templates = append(templates, t)
+			}
+		}
+	}
+	if handle != "" && len(templates) == 0 {
+		u.log.Printf("handle %s is not installed\n", handle)
+		return 255
+	}
+
+	ret := 0
+	upgraded := false
+	for _, t := range templates {
+		target := version
+		if target == "" {
+			versions, err := u.versions(t)
+			if u.errs.LogF("can not list versions of %s: %v", t.Handle, err) {
+				ret = 255
+				continue
+			}
+			target = versions[len(versions)-1].tag
+		}
+		if target == t.Ref {
+			fmt.Fprintf(u.stdout, "handle:%s is up to date at %s\n", t.Handle, t.Ref)
+			continue
+		}
+		err := u.upgrade(t, target)
+		if u.errs.LogF("can not upgrade %s: %v", t.Handle, err) {
+			ret = 255
+			continue
+		}
+		upgraded = true
+	}
+
+	if upgraded {
+		err := u.conf.SaveTemplates()
+		if u.errs.LogF("can not save templates: %v", err) {
+			return 255
+		}
+		u.sync.Files()
+	}
+	return ret
+}
+
+// upgrade checks out target, prints the commit log and pins t to target
+func (u *Upgrade) upgrade(t config.Template, target string) error {
+	from := t.Ref
+	if from == "" {
+		from = "HEAD"
+	}
+	p, err := u.client.GetTemplate(t.URL, t.Ref)
+	if err != nil {
+		return err
+	}
+	if p.Method() == plumb.NOOP {
+		return fmt.Errorf("%s is not a remote repository", t.URL)
+	}
+	old, err := u.vcs.Open(p.Path())
+	if err != nil {
+		return err
+	}
+	fromHash, err := old.Resolve(from)
+	if err != nil {
+		return err
+	}
+
+	p, err = u.client.GetTemplate(t.URL, target)
+	if err != nil {
+		return err
+	}
+	repo, err := u.vcs.Open(p.Path())
+	if err != nil {
+		return err
+	}
+	added, err := repo.Log(fromHash, target)
+	if err != nil {
+		return err
+	}
+	removed, err := repo.Log(target, fromHash)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(u.stdout, "upgraded handle:%s %s -> %s\n", t.Handle, from, target)
+	for _, c := range added {
+		fmt.Fprintf(u.stdout, "  + %s %s\n", c.Hash[:7], c.Subject)
+	}
+	for _, c := range removed {
+		fmt.Fprintf(u.stdout, "  - %s %s\n", c.Hash[:7], c.Subject)
+	}
+
+	for i := range u.conf.Templates {
+		if u.conf.Templates[i].Handle == t.Handle {
+			u.conf.Templates[i].Ref = target
+		}
+	}
+	return nil
+}
+
+// version a version tag
+type version struct {
+	tag    string
+	semver *semver.Version
+}
+
+// versions returns the versions of t in ascending order. At least one version
+// is returned if err is nil.
+func (u *Upgrade) versions(t config.Template) ([]version, error) {
+	seen := map[string]bool{}
+	versions := []version{}
+	add := func(tag string) {
+		ver, err := versiontag.Parse(tag)
+		if err != nil || seen[ver.String()] {
+			return
+		}
+		seen[ver.String()] = true
+		versions = append(versions, version{tag: tag, semver: ver})
+	}
+
+	p, err := u.client.GetTemplate(t.URL, t.Ref)
+	if err != nil {
+		return nil, err
+	}
+	if p.Method() == plumb.SYNC {
+		repo, err := u.vcs.Open(p.Path())
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range repo.Versions() {
+			add(tag)
+		}
+	}
+
+	rows, err := u.orm.Raw(`SELECT versions.version FROM versions
+JOIN template ON (versions.template_id = template.id)
+WHERE template.url = ?`, t.URL).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tag string
+		err = rows.Scan(&tag)
+		if err != nil {
+			return nil, err
+		}
+		add(tag)
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no versions found for %s", t.URL)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].semver.LessThan(*versions[j].semver)
+	})
+	return versions, nil
+}
@@ -0,0 +1,16 @@
+// AUTO GENERATED. DO NOT EDIT.
+
+package upgrade
+
+// UpgradeIface ...
+type UpgradeIface interface {
+	// Outdated lists installed templates that are pinned to a version older than
+	// the latest version. Versions are semantic version tags in the template's
+	// repository and the versions listed in repo metadata.
+	Outdated() int
+	// Upgrade moves the pin of handle to version and prints the commits between
+	// the old and new refs. If version is empty the latest version is used. If
+	// handle is empty every template pinned to a version is upgraded to its
+	// latest version.
+	Upgrade(handle, version string) int
+}
@@ -0,0 +1,51 @@
+package infocmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kick-project/kick/internal/di"
+	"github.com/kick-project/kick/internal/resources/errs"
+	"github.com/kick-project/kick/internal/resources/exit"
+	"github.com/kick-project/kick/internal/resources/options"
+)
+
+// UsageDoc help document passed to docopts
+var UsageDoc = `Show information on a template before installing it
+
+Usage:
+    kick info <template>
+
+Options:
+    -h --help        print help
+    <template>       template name, name/repo as listed by kick search or URL
+
+Shows the description, versions, renderer, license, labels, keywords, variables
+and README excerpt of the template from repository information downloaded by
+kick update.
+`
+
+// OptInfo show template information
+type OptInfo struct {
+	Info     bool   `docopt:"info"`
+	Template string `docopt:"<template>"`
+}
+
+// Info show information on a template
+func Info(args []string, inject *di.DI) int {
+	opts := &OptInfo{}
+	options.Bind(UsageDoc, args, opts)
+	if !opts.Info {
+		errs.Panic(errors.New("Info set to false"))
+		return 256
+	}
+
+	chk := inject.MakeCheck()
+	if err := chk.Init(); err != nil {
+		fmt.Fprintf(inject.Stderr, "%s\n", err.Error())
+		exit.Exit(255)
+	}
+
+	i := inject.MakeInfo()
+	return i.Info(opts.Template)
+}
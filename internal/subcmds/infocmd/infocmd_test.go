@@ -0,0 +1,12 @@
+package infocmd_test
+
+import (
+	"testing"
+
+	"github.com/kick-project/kick/internal/subcmds/infocmd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsageDoc(t *testing.T) {
+	assert.NotRegexp(t, "\t", infocmd.UsageDoc)
+}
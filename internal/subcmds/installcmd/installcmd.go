@@ -20,6 +20,13 @@ Options:
     -h --help        print help
     <handle>         name to use when creating new projects
     <location>       template name, URL or location of template
+
+Pinning:
+    A template name can be suffixed with @<version> and a URL with
+    #<branch|tag|sha> to install and generate from that ref.
+
+    kick install go go/myrepo@1.4.0
+    kick install h https://github.com/user/template.git#main
 `
 
 // OptInstall initialize configuration file
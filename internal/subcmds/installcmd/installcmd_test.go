@@ -39,6 +39,66 @@ func TestInstallTemplateURL(t *testing.T) {
 	installTest(t, "TestInstallTemplateURL", handle, template)
 }
 
+func TestInstallTemplateVersion(t *testing.T) {
+	handle := "handle5"
+	template := "tmpl1/repo1@7.7.7"
+	home := installTest(t, "TestInstallTemplateVersion", handle, template)
+	pinTest(t, home, handle, "7.7.7")
+}
+
+func TestInstallTemplateURLRef(t *testing.T) {
+	handle := "handle6"
+	template := "http://localhost:8080/tmpl3.git#master"
+	home := installTest(t, "TestInstallTemplateURLRef", handle, template)
+	pinTest(t, home, handle, "master")
+}
+
+func TestInstallTemplateNoVersion(t *testing.T) {
+	id := "TestInstallTemplateNoVersion"
+	home := filepath.Join(testtools.TempDir(), id)
+	kickDir := filepath.Join(home, ".kick")
+	err := os.MkdirAll(kickDir, 0755)
+	if err != nil {
+		t.Errorf("Can not create directory \"%s\": %v", kickDir, err)
+		return
+	}
+
+	inject := di.New(&di.Options{Home: home})
+	inject.LogLevel(logger.DebugLevel)
+
+	ec := setupcmd.SetupCmd([]string{"setup"}, inject)
+	assert.Equal(t, 0, ec)
+
+	ec = updatecmd.Update([]string{"update"}, inject)
+	assert.Equal(t, 0, ec)
+
+	ec = installcmd.Install([]string{"install", "handle7", "tmpl1/repo1@99.0.0"}, inject)
+	assert.NotEqual(t, 0, ec)
+}
+
+// pinTest checks that the ref is persisted and checked out in the only clone
+// of the template
+func pinTest(t *testing.T, home, handle, ref string) {
+	b, err := os.ReadFile(filepath.Join(home, ".kick", "templates.yml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "ref: "+ref)
+
+	inject := di.New(&di.Options{Home: home})
+	path, err := inject.MakeHandle().Handle2Path(handle)
+	assert.NoError(t, err)
+	// No clone is made for the ref
+	matches, err := filepath.Glob(path + "@*")
+	assert.NoError(t, err)
+	assert.Empty(t, matches)
+	repo, err := inject.MakeVCS().Open(path)
+	assert.NoError(t, err)
+	want, err := repo.Resolve(ref)
+	assert.NoError(t, err)
+	head, err := repo.Head()
+	assert.NoError(t, err)
+	assert.Equal(t, want, head)
+}
+
 func TestInstallSSHPublic(t *testing.T) {
 	if os.Getenv("KICK_TEST_SSH") != "true" {
 		t.Skip(`SSH git repo tests are disabled`)
@@ -93,10 +153,10 @@ func TestInstallPath(t *testing.T) {
 	installTest(t, "TestInstallPath", handle, template)
 }
 
-func installTest(t *testing.T, id, handle, template string) {
+func installTest(t *testing.T, id, handle, template string) (home string) {
 	exit.Mode(exit.MPanic)
 	// Home Directory
-	home := filepath.Join(testtools.TempDir(), id)
+	home = filepath.Join(testtools.TempDir(), id)
 
 	// Make kick config dir
 	kickDir := filepath.Join(home, ".kick")
@@ -140,4 +200,5 @@ func installTest(t *testing.T, id, handle, template string) {
 	}
 	p := filepath.Clean(filepath.Join(td, handle))
 	startcmd.Start([]string{"start", handle, p}, inject)
+	return home
 }
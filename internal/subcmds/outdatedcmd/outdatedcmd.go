@@ -0,0 +1,45 @@
+package outdatedcmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kick-project/kick/internal/di"
+	"github.com/kick-project/kick/internal/resources/errs"
+	"github.com/kick-project/kick/internal/resources/exit"
+	"github.com/kick-project/kick/internal/resources/options"
+)
+
+// UsageDoc help document passed to docopts
+var UsageDoc = `List installed templates pinned to an outdated version
+
+Usage:
+    kick outdated
+
+Options:
+    -h --help        print help
+`
+
+// OptOutdated list outdated templates
+type OptOutdated struct {
+	Outdated bool `docopt:"outdated"`
+}
+
+// Outdated list installed templates pinned to an outdated version
+func Outdated(args []string, inject *di.DI) int {
+	opts := &OptOutdated{}
+	options.Bind(UsageDoc, args, opts)
+	if !opts.Outdated {
+		errs.Panic(errors.New("Outdated set to false"))
+		return 256
+	}
+
+	chk := inject.MakeCheck()
+	if err := chk.Init(); err != nil {
+		fmt.Fprintf(inject.Stderr, "%s\n", err.Error())
+		exit.Exit(255)
+	}
+
+	u := inject.MakeUpgrade()
+	return u.Outdated()
+}
@@ -0,0 +1,12 @@
+package outdatedcmd_test
+
+import (
+	"testing"
+
+	"github.com/kick-project/kick/internal/subcmds/outdatedcmd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsageDoc(t *testing.T) {
+	assert.NotRegexp(t, "\t", outdatedcmd.UsageDoc)
+}
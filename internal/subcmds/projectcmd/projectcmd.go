@@ -0,0 +1,68 @@
+package projectcmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kick-project/kick/internal/di"
+	"github.com/kick-project/kick/internal/resources/errs"
+	"github.com/kick-project/kick/internal/resources/exit"
+	"github.com/kick-project/kick/internal/resources/options"
+	"github.com/kick-project/kick/internal/services/project"
+)
+
+// UsageDoc help document passed to docopts
+var UsageDoc = `Manage projects generated from templates
+
+Usage:
+    kick project update [<path>] [--to=<ref>] [--reject]
+
+Options:
+    -h --help     print help
+    update        regenerate a project from a newer version of its template
+    <path>        project path. Defaults to the current directory
+    --to=<ref>    version, branch, tag or commit to update to. Defaults to the ref the
+                  template is pinned to
+    --reject      write the template's version of conflicting files to a .rej file
+                  instead of inserting conflict markers
+
+Files not modified since the project was generated are replaced. Files modified
+in the project and the template are merged, conflicts are marked with conflict
+markers. Files deleted from the project are not restored.
+`
+
+// OptProject manage projects
+type OptProject struct {
+	Project bool   `docopt:"project"`
+	Update  bool   `docopt:"update"`
+	Path    string `docopt:"<path>"`
+	To      string `docopt:"--to"`
+	Reject  bool   `docopt:"--reject"`
+}
+
+// Project manage projects generated from templates
+func Project(args []string, inject *di.DI) int {
+	opts := &OptProject{}
+	options.Bind(UsageDoc, args, opts)
+	if !opts.Project {
+		errs.Panic(errors.New("Project set to false"))
+		return 256
+	}
+
+	chk := inject.MakeCheck()
+	if err := chk.Init(); err != nil {
+		fmt.Fprintf(inject.Stderr, "%s\n", err.Error())
+		exit.Exit(255)
+	}
+
+	path := opts.Path
+	if path == "" {
+		path = "."
+	}
+	p := inject.MakeProject()
+	switch {
+	case opts.Update:
+		return p.Update(path, project.UpdateOptions{To: opts.To, Reject: opts.Reject})
+	}
+	return 255
+}
@@ -0,0 +1,153 @@
+package projectcmd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kick-project/kick/internal/di"
+	"github.com/kick-project/kick/internal/resources/template"
+	"github.com/kick-project/kick/internal/resources/testtools"
+	"github.com/kick-project/kick/internal/services/start"
+	"github.com/kick-project/kick/internal/subcmds/installcmd"
+	"github.com/kick-project/kick/internal/subcmds/projectcmd"
+	"github.com/kick-project/kick/internal/subcmds/setupcmd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsageDoc(t *testing.T) {
+	assert.NotRegexp(t, "\t", projectcmd.UsageDoc)
+}
+
+// releases the files of a template at each version
+var releases = []testtools.Commit{
+	{
+		Message: "Release 1.0.0",
+		Tag:     "1.0.0",
+		Files: map[string]string{
+			".kick.yml":  "name: versioned\n",
+			"README.md":  "# title\none\ntwo\nthree\nfour\n",
+			"name.txt":   "# kick:render\n${PROJECT_NAME}\n",
+			"config.txt": "setting=a\n",
+			"old.txt":    "old\n",
+			"keep.txt":   "keep\n",
+		},
+	},
+	{
+		Message: "Release 2.0.0",
+		Tag:     "2.0.0",
+		Files: map[string]string{
+			".kick.yml":  "name: versioned\n",
+			"README.md":  "# title\none\ntwo\nthree\nFOUR\n",
+			"name.txt":   "# kick:render\nname=${PROJECT_NAME}\n",
+			"config.txt": "setting=b\n",
+			"keep.txt":   "keep\n",
+			"new.txt":    "new\n",
+		},
+	},
+}
+
+func TestProjectUpdate(t *testing.T) {
+	inject, stdout, project := projectTest(t, "TestProjectUpdate", releases)
+
+	stdout.Reset()
+	ec := projectcmd.Project([]string{"project", "update", project, "--to", "2.0.0"}, inject)
+	assert.Equal(t, 0, ec)
+	out := stdout.String()
+	assert.Regexp(t, `new\.txt\s+\|\s+created`, out)
+	assert.Regexp(t, `README\.md\s+\|\s+merged`, out)
+	assert.Regexp(t, `name\.txt\s+\|\s+updated`, out)
+	assert.Regexp(t, `config\.txt\s+\|\s+conflict`, out)
+	assert.Regexp(t, `old\.txt\s+\|\s+removed`, out)
+	assert.Contains(t, out, "1 created, 1 updated, 1 merged, 1 conflicts, 1 removed, 0 kept")
+
+	assert.Equal(t, "# title\nONE\ntwo\nthree\nFOUR\n", readFile(t, project, "README.md"))
+	assert.Equal(t, "<<<<<<< local\nsetting=c\n=======\nsetting=b\n>>>>>>> versioned@2.0.0\n", readFile(t, project, "config.txt"))
+	assert.Equal(t, "new\n", readFile(t, project, "new.txt"))
+	assert.Contains(t, readFile(t, project, "name.txt"), "name=myproject\n")
+	assert.NoFileExists(t, filepath.Join(project, "old.txt"))
+
+	m, err := template.LoadManifest(project)
+	assert.NoError(t, err)
+	assert.Equal(t, "2.0.0", m.Ref)
+	assert.Equal(t, "myproject", m.Project["NAME"])
+	assert.Contains(t, m.Files, "new.txt")
+	assert.NotContains(t, m.Files, "old.txt")
+
+	// Both refs are checked out in the clone of the template
+	clones, err := filepath.Glob(filepath.Join(inject.PathTemplateDir, "*", "*@*"))
+	assert.NoError(t, err)
+	assert.Empty(t, clones)
+}
+
+func TestProjectUpdate_Reject(t *testing.T) {
+	inject, stdout, project := projectTest(t, "TestProjectUpdate_Reject", releases)
+
+	stdout.Reset()
+	ec := projectcmd.Project([]string{"project", "update", project, "--to", "2.0.0", "--reject"}, inject)
+	assert.Equal(t, 0, ec)
+	assert.Regexp(t, `config\.txt\s+\|\s+conflict`, stdout.String())
+	assert.Equal(t, "setting=c\n", readFile(t, project, "config.txt"))
+	assert.Equal(t, "setting=b\n", readFile(t, project, "config.txt.rej"))
+}
+
+func TestProjectUpdate_Renderer(t *testing.T) {
+	// The first release renders with texttemplate, the second with the default
+	// renderer
+	changed := []testtools.Commit{}
+	for _, r := range releases {
+		files := map[string]string{}
+		for name, content := range r.Files {
+			files[name] = content
+		}
+		r.Files = files
+		changed = append(changed, r)
+	}
+	changed[0].Files[".kick.yml"] = "name: versioned\nrenderer: texttemplate\n"
+	changed[0].Files["name.txt"] = "# kick:render\n{{.Project.NAME}}\n"
+	inject, stdout, project := projectTest(t, "TestProjectUpdate_Renderer", changed)
+
+	stdout.Reset()
+	ec := projectcmd.Project([]string{"project", "update", project, "--to", "2.0.0"}, inject)
+	assert.Equal(t, 0, ec)
+	assert.Regexp(t, `name\.txt\s+\|\s+updated`, stdout.String())
+	assert.Contains(t, readFile(t, project, "name.txt"), "name=myproject\n")
+}
+
+// projectTest installs a template pinned to its first release, starts a
+// project from it and modifies the project.
+func projectTest(t *testing.T, id string, releases []testtools.Commit) (*di.DI, *bytes.Buffer, string) {
+	_, url := testtools.PushRepo(t, id, releases...)
+
+	home := filepath.Join(testtools.TempDir(), id)
+	err := os.RemoveAll(home)
+	assert.NoError(t, err)
+	err = os.MkdirAll(filepath.Join(home, ".kick"), 0755)
+	assert.NoError(t, err)
+
+	stdout := &bytes.Buffer{}
+	inject := di.New(&di.Options{Home: home, Stdout: stdout})
+
+	ec := setupcmd.SetupCmd([]string{"setup"}, inject)
+	assert.Equal(t, 0, ec)
+	ec = installcmd.Install([]string{"install", "versioned", url + "#1.0.0"}, inject)
+	assert.Equal(t, 0, ec)
+
+	project := filepath.Join(home, "myproject")
+	inject.MakeStart().Start("myproject", "versioned", project, start.StartOptions{})
+	assert.FileExists(t, filepath.Join(project, template.ManifestFile))
+	assert.Contains(t, readFile(t, project, "name.txt"), "myproject\n")
+
+	err = os.WriteFile(filepath.Join(project, "README.md"), []byte("# title\nONE\ntwo\nthree\nfour\n"), 0644)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(project, "config.txt"), []byte("setting=c\n"), 0644)
+	assert.NoError(t, err)
+	return inject, stdout, project
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	assert.NoError(t, err)
+	return string(b)
+}
@@ -9,7 +9,7 @@ import (
 var UsageDoc = `Buid/list/inform on repositories WIP
 
 Usage:
-    kick repo build
+    kick repo build [--verify] [--report=<file>]
     kick repo list
     kick repo info <repo>
 
@@ -17,6 +17,11 @@ Options:
     -h --help    print help
     repo         repo subcommand
     build        build repo by downloading the URLS defined in repo.yml and creating the files templates/*.yml
+                 and index.json
+    --verify     render each template with sample variables before building and fail on render errors,
+                 unknown variables, invalid modelines, invalid .kick.yml files, non-semver tags and
+                 duplicate template names
+    --report=<file>  verify and write the result to file as JUnit XML (.xml) or JSON (.json)
     list         list repositories
     info         repository and/or template information
     <repo>       name of repository
@@ -26,6 +31,8 @@ Options:
 type OptRepo struct {
 	Repo     bool   `docopt:"repo"`
 	Build    bool   `docopt:"build"`
+	Verify   bool   `docopt:"--verify"`
+	Report   string `docopt:"--report"`
 	List     bool   `docopt:"list"`
 	Info     bool   `docopt:"info"`
 	RepoName string `docopt:"<repo>"`
@@ -38,6 +45,11 @@ func Repo(args []string, inject *di.DI) int {
 	r := inject.MakeRepo()
 	switch {
 	case opts.Build:
+		if opts.Verify || opts.Report != "" {
+			if ret := r.Verify(opts.Report); ret != 0 {
+				return ret
+			}
+		}
 		r.Build()
 	case opts.List:
 		r.List()
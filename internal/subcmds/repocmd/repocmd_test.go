@@ -2,6 +2,7 @@ package repocmd_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,9 +11,12 @@ import (
 
 	"github.com/kick-project/kick/internal/di"
 	"github.com/kick-project/kick/internal/resources/marshal"
+	"github.com/kick-project/kick/internal/resources/output"
 	"github.com/kick-project/kick/internal/resources/testtools"
+	"github.com/kick-project/kick/internal/services/repo"
 	"github.com/kick-project/kick/internal/subcmds/repocmd"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
 )
 
 func TestUsageDoc(t *testing.T) {
@@ -104,3 +108,101 @@ func TestRepocmd_Info(t *testing.T) {
 	assert.Regexp(t, mustMatch1, stdout)
 	assert.Regexp(t, mustMatch2, stdout)
 }
+
+func TestRepocmd_List_JSON(t *testing.T) {
+	home := filepath.Join(testtools.TempDir(), "home")
+	inject := di.New(&di.Options{Home: home, Output: output.JSON})
+	stdout := bytes.NewBufferString(``)
+	inject.Stdout = stdout
+	repocmd.Repo([]string{"repo", "list"}, inject)
+
+	records := []repo.Record{}
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &records))
+	assert.Contains(t, records, repo.Record{Name: "repo1", URL: "http://127.0.0.1:8080/repo1.git"})
+}
+
+func TestRepocmd_Info_YAML(t *testing.T) {
+	home := filepath.Join(testtools.TempDir(), "home")
+	inject := di.New(&di.Options{Home: home, Output: output.YAML})
+	stdout := bytes.NewBufferString(``)
+	inject.Stdout = stdout
+	repocmd.Repo([]string{"repo", "info", "repo1"}, inject)
+
+	record := repo.Record{}
+	assert.NoError(t, yaml.Unmarshal(stdout.Bytes(), &record))
+	assert.Equal(t, "repo1", record.Name)
+	assert.Equal(t, "http://127.0.0.1:8080/repo1.git", record.URL)
+}
+
+func TestRepocmd_Build_Verify(t *testing.T) {
+	dirPath := filepath.Join(testtools.TempDir(), "TestRepocmd_Build_Verify")
+	err := os.RemoveAll(dirPath)
+	assert.NoError(t, err)
+	err = os.MkdirAll(dirPath, 0755)
+	assert.NoError(t, err)
+
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	_ = os.Chdir(dirPath)
+	defer func() { _ = os.Chdir(wd) }()
+	home := filepath.Join(testtools.TempDir(), "home")
+
+	// Passing templates are built
+	data := []byte(
+		`name: verify
+description: verify repo
+templates:
+    - http://127.0.0.1:8080/tmpl.git
+    - http://127.0.0.1:8080/tmpl1.git
+`)
+	err = os.WriteFile(filepath.Join(dirPath, "repo.yml"), data, 0644)
+	assert.NoError(t, err)
+	inject := di.New(&di.Options{Home: home})
+	stdout := bytes.NewBufferString(``)
+	inject.Stdout = stdout
+	ret := repocmd.Repo([]string{"repo", "build", "--verify", "--report=report.xml"}, inject)
+	assert.Equal(t, 0, ret)
+	assert.Regexp(t, `(?m)^ok   tmpl1$`, stdout.String())
+	assert.Contains(t, stdout.String(), "2 templates, 0 failed")
+	assert.FileExists(t, filepath.Join(dirPath, "report.xml"))
+	assert.FileExists(t, filepath.Join(dirPath, "templates", "tmpl1.yml"))
+
+	// Duplicate templates fail and are not built
+	data = []byte(
+		`name: verify
+description: verify repo
+templates:
+    - http://127.0.0.1:8080/tmpl2.git
+    - http://127.0.0.1:8080/tmpl2.git
+`)
+	err = os.WriteFile(filepath.Join(dirPath, "repo.yml"), data, 0644)
+	assert.NoError(t, err)
+	inject = di.New(&di.Options{Home: home})
+	stdout = bytes.NewBufferString(``)
+	inject.Stdout = stdout
+	ret = repocmd.Repo([]string{"repo", "build", "--verify", "--report=report.json"}, inject)
+	assert.Equal(t, 255, ret)
+	assert.Contains(t, stdout.String(), "FAIL tmpl2")
+	assert.NoFileExists(t, filepath.Join(dirPath, "templates", "tmpl2.yml"))
+
+	report := &repo.Report{}
+	b, err := os.ReadFile(filepath.Join(dirPath, "report.json"))
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(b, report))
+	assert.Equal(t, 2, report.Tests)
+	assert.Equal(t, 2, report.Failures)
+	if assert.Len(t, report.Templates, 2) {
+		failures := report.Templates[0].Failures
+		assert.Contains(t, failures, repo.Failure{
+			Check:   repo.CheckDuplicate,
+			Message: "template name tmpl2 is also used by http://127.0.0.1:8080/tmpl2.git",
+		})
+		// The fixture has a modeline with an unknown option
+		assert.Contains(t, failures, repo.Failure{
+			Check:   repo.CheckModeline,
+			File:    "{{.Project.NAME}}/template-interpolate.yml",
+			Line:    1,
+			Message: "modeline error: unknown option: template",
+		})
+	}
+}
@@ -2,6 +2,7 @@ package searchcmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/kick-project/kick/internal/di"
 	"github.com/kick-project/kick/internal/resources/exit"
@@ -12,19 +13,33 @@ import (
 var UsageDoc = `search for templates using a keyword
 
 Usage:
-    kick search [-l] [<term>]
+    kick search [-l] [<term>...]
 
 Options:
     -h --help  print help
     -l         long output
-    <term>     search term
+    <term>     search terms
+
+Terms match template names, descriptions, URLs, labels, variables, versions and
+repository names. Results are ranked best match first. Every term must match.
+Templates whose name contains every term, E.G. mytemplate for template, follow
+the ranked results.
+
+    word              a word
+    word*             a word starting with word
+    "some words"      words in order
+    repo:<name>       templates in the repository <name>. Repeat to match
+                      any of several repositories
+    label:<label>     templates with the label <label>
+    version:<op><v>   templates with a version matching. <op> is one of
+                      =, >, >=, <, <=. E.G. version:>=1.2
 `
 
 // OptSearch bindings for docopts
 type OptSearch struct {
-	Search bool   `docopt:"search"`
-	Long   bool   `docopt:"-l"`
-	Term   string `docopt:"<term>"`
+	Search bool     `docopt:"search"`
+	Long   bool     `docopt:"-l"`
+	Terms  []string `docopt:"<term>"`
 }
 
 // Search for templates
@@ -42,5 +57,18 @@ func Search(args []string, inject *di.DI) int {
 	synchro := inject.MakeSync()
 	synchro.Files()
 	srch := inject.MakeSearch()
-	return srch.Search2Output(opts.Long, opts.Term)
+	return srch.Search2Output(opts.Long, joinTerms(opts.Terms))
+}
+
+// joinTerms joins terms into a query. Terms containing spaces were quoted on the
+// command line and are searched for as a phrase.
+func joinTerms(terms []string) string {
+	query := []string{}
+	for _, term := range terms {
+		if strings.ContainsAny(term, " \t") && !strings.Contains(term, `"`) {
+			term = `"` + term + `"`
+		}
+		query = append(query, term)
+	}
+	return strings.Join(query, " ")
 }
@@ -17,7 +17,7 @@ func TestUsageDoc(t *testing.T) {
 
 func TestSearch(t *testing.T) {
 	exit.Mode(exit.MPanic)
-	args := []string{"search", "keyword"}
+	args := []string{"search", "keyword", "label:go", "hello world"}
 	home := filepath.Join(testtools.TempDir(), "home")
 	inject := di.New(&di.Options{
 		Home: home,
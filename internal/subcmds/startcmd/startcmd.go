@@ -5,33 +5,66 @@ import (
 
 	"github.com/kick-project/kick/internal/di"
 	"github.com/kick-project/kick/internal/resources/options"
+	startsvc "github.com/kick-project/kick/internal/services/start"
 )
 
 // UsageDoc help document passed to docopts
 var UsageDoc = `generate project scaffolding
 
 Usage:
-    kick start <handle> <project>
-    kick start -s <handle>
+    kick start [--merge [--conflict=<policy>]] [--dry-run [--content]] [--no-hooks|--include-hooks] [--strict] [--label=<label>]... [--var=<var>]... [--vars-file=<file>]... <handle> <project>
+    kick start -s [--label=<label>]... <handle>
     kick start (-l|--long)
+    kick start --functions
 
 Options:
-    -h --help     print help
-    -l            list templates 
-    --long        list templates in long format
-    -s            list template files
-    <handle>      template handle
-    <project>     project path
+    -h --help            print help
+    -l                   list templates
+    --long               list templates in long format
+    --functions          list the functions available to text/template templates
+    -s                   list template files with their labels and the rule that decides
+                         if each file is rendered
+    --merge              render into an existing project directory
+    --conflict=<policy>  how to resolve files that exist and differ when merging.
+                         One of skip, overwrite, keep-both or prompt [default: prompt]
+    --dry-run            print the plan of paths, actions and renderers without creating the project
+    --content            include the rendered content of each file in the plan
+    --no-hooks           do not run the pre_generate and post_generate hooks of the template
+    --include-hooks      also run the hooks of included templates. By default only the hooks of
+                         the template itself are run
+    --strict             fail if rendered files or paths use variables that are not set or
+                         are empty, or if a modeline has an unknown option. Every such
+                         variable is reported
+    --label=<label>      only include files without labels or with one of the given labels.
+                         The label "all" includes every file
+    --var=<var>          set a template variable in the form KEY=VALUE
+    --vars-file=<file>   load template variables from a YAML, JSON or dotenv file
+    <handle>             template handle
+    <project>            project path
+
+Variables set with --var take precedence over --vars-file, which take
+precedence over the environment and .env files.
 `
 
 // OptStart start a new project from templates.
 type OptStart struct {
-	Start       bool   `docopt:"start"`
-	Handle      string `docopt:"<handle>"`
-	ProjectPath string `docopt:"<project>"`
-	List        bool   `docopt:"-l"`
-	ListLong    bool   `docopt:"--long"`
-	Show        bool   `docopt:"-s"`
+	Start        bool     `docopt:"start"`
+	Handle       string   `docopt:"<handle>"`
+	ProjectPath  string   `docopt:"<project>"`
+	List         bool     `docopt:"-l"`
+	ListLong     bool     `docopt:"--long"`
+	Functions    bool     `docopt:"--functions"`
+	Show         bool     `docopt:"-s"`
+	Merge        bool     `docopt:"--merge"`
+	Conflict     string   `docopt:"--conflict"`
+	DryRun       bool     `docopt:"--dry-run"`
+	Content      bool     `docopt:"--content"`
+	NoHooks      bool     `docopt:"--no-hooks"`
+	IncludeHooks bool     `docopt:"--include-hooks"`
+	Strict       bool     `docopt:"--strict"`
+	Labels       []string `docopt:"--label"`
+	Vars         []string `docopt:"--var"`
+	VarsFiles    []string `docopt:"--vars-file"`
 }
 
 // Start start cli option
@@ -44,11 +77,27 @@ func Start(args []string, inject *di.DI) {
 	case opts.List:
 		start.List(false)
 	case opts.Show:
-		start.Show(opts.Handle, []string{}, 0)
+		start.Show(opts.Handle, opts.Labels, startsvc.SLABEL|startsvc.SRULE)
 	case opts.ListLong:
 		start.List(true)
+	case opts.Functions:
+		start.Functions()
 	default:
 		name := path.Base(opts.ProjectPath)
-		start.Start(name, opts.Handle, opts.ProjectPath)
+		merge := ""
+		if opts.Merge {
+			merge = opts.Conflict
+		}
+		start.Start(name, opts.Handle, opts.ProjectPath, startsvc.StartOptions{
+			DryRun:       opts.DryRun,
+			Merge:        merge,
+			NoHooks:      opts.NoHooks,
+			IncludeHooks: opts.IncludeHooks,
+			Labels:       opts.Labels,
+			ShowContent:  opts.Content,
+			Strict:       opts.Strict,
+			Vars:         opts.Vars,
+			VarsFiles:    opts.VarsFiles,
+		})
 	}
 }
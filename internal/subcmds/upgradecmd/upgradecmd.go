@@ -0,0 +1,49 @@
+package upgradecmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kick-project/kick/internal/di"
+	"github.com/kick-project/kick/internal/resources/errs"
+	"github.com/kick-project/kick/internal/resources/exit"
+	"github.com/kick-project/kick/internal/resources/options"
+)
+
+// UsageDoc help document passed to docopts
+var UsageDoc = `Upgrade the version an installed template is pinned to
+
+Usage:
+    kick upgrade [<handle>] [--to=<version>]
+
+Options:
+    -h --help          print help
+    <handle>           handle to upgrade. Defaults to all templates pinned to a version
+    --to=<version>     version, branch, tag or commit to pin to. Defaults to the latest version
+`
+
+// OptUpgrade upgrade an installed template
+type OptUpgrade struct {
+	Upgrade bool   `docopt:"upgrade"`
+	Handle  string `docopt:"<handle>"`
+	To      string `docopt:"--to"`
+}
+
+// Upgrade upgrade the version an installed template is pinned to
+func Upgrade(args []string, inject *di.DI) int {
+	opts := &OptUpgrade{}
+	options.Bind(UsageDoc, args, opts)
+	if !opts.Upgrade {
+		errs.Panic(errors.New("Upgrade set to false"))
+		return 256
+	}
+
+	chk := inject.MakeCheck()
+	if err := chk.Init(); err != nil {
+		fmt.Fprintf(inject.Stderr, "%s\n", err.Error())
+		exit.Exit(255)
+	}
+
+	u := inject.MakeUpgrade()
+	return u.Upgrade(opts.Handle, opts.To)
+}
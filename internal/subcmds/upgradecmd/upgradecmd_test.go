@@ -0,0 +1,87 @@
+package upgradecmd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kick-project/kick/internal/di"
+	"github.com/kick-project/kick/internal/resources/output"
+	"github.com/kick-project/kick/internal/resources/testtools"
+	"github.com/kick-project/kick/internal/services/upgrade"
+	"github.com/kick-project/kick/internal/subcmds/installcmd"
+	"github.com/kick-project/kick/internal/subcmds/outdatedcmd"
+	"github.com/kick-project/kick/internal/subcmds/setupcmd"
+	"github.com/kick-project/kick/internal/subcmds/upgradecmd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsageDoc(t *testing.T) {
+	assert.NotRegexp(t, "\t", upgradecmd.UsageDoc)
+}
+
+func TestUpgrade(t *testing.T) {
+	id := "TestUpgrade"
+	commits := []testtools.Commit{}
+	for _, v := range []string{"1.0.0", "1.1.0", "2.0.0"} {
+		commits = append(commits, testtools.Commit{Message: "Release " + v, Tag: v, Files: map[string]string{"VERSION": v + "\n"}})
+	}
+	_, url := testtools.PushRepo(t, id, commits...)
+
+	home := filepath.Join(testtools.TempDir(), id)
+	err := os.RemoveAll(home)
+	assert.NoError(t, err)
+	err = os.MkdirAll(filepath.Join(home, ".kick"), 0755)
+	assert.NoError(t, err)
+
+	stdout := &bytes.Buffer{}
+	inject := di.New(&di.Options{Home: home, Stdout: stdout})
+
+	ec := setupcmd.SetupCmd([]string{"setup"}, inject)
+	assert.Equal(t, 0, ec)
+
+	ec = installcmd.Install([]string{"install", "pinned", url + "#1.0.0"}, inject)
+	assert.Equal(t, 0, ec)
+
+	stdout.Reset()
+	ec = outdatedcmd.Outdated([]string{"outdated"}, inject)
+	assert.Equal(t, 0, ec)
+	assert.Regexp(t, `pinned\s+\|\s+1\.0\.0\s+\|\s+2\.0\.0`, stdout.String())
+
+	stdout.Reset()
+	ec = outdatedcmd.Outdated([]string{"outdated"}, di.New(&di.Options{Home: home, Output: output.JSON, Stdout: stdout}))
+	assert.Equal(t, 0, ec)
+	records := []upgrade.Record{}
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &records))
+	assert.Equal(t, []upgrade.Record{{Handle: "pinned", Current: "1.0.0", Latest: "2.0.0", URL: url}}, records)
+
+	stdout.Reset()
+	ec = upgradecmd.Upgrade([]string{"upgrade", "pinned", "--to", "1.1.0"}, inject)
+	assert.Equal(t, 0, ec)
+	assert.Contains(t, stdout.String(), "upgraded handle:pinned 1.0.0 -> 1.1.0")
+	assert.Contains(t, stdout.String(), "+ ")
+	assert.Contains(t, stdout.String(), "Release 1.1.0")
+	assert.NotContains(t, stdout.String(), "Release 2.0.0")
+
+	stdout.Reset()
+	ec = upgradecmd.Upgrade([]string{"upgrade"}, inject)
+	assert.Equal(t, 0, ec)
+	assert.Contains(t, stdout.String(), "upgraded handle:pinned 1.1.0 -> 2.0.0")
+	assert.Contains(t, stdout.String(), "Release 2.0.0")
+
+	b, err := os.ReadFile(filepath.Join(home, ".kick", "templates.yml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "ref: 2.0.0")
+
+	// Versions are checked out in the clone of the template
+	clones, err := filepath.Glob(filepath.Join(inject.PathTemplateDir, "*", "*@*"))
+	assert.NoError(t, err)
+	assert.Empty(t, clones)
+
+	stdout.Reset()
+	ec = outdatedcmd.Outdated([]string{"outdated"}, inject)
+	assert.Equal(t, 0, ec)
+	assert.Contains(t, stdout.String(), "all pinned templates are up to date")
+}
@@ -1,6 +1,8 @@
 package internal
 
 import (
+	"strings"
+
 	"github.com/docopt/docopt-go"
 	"github.com/kick-project/kick/internal/resources/errs"
 )
@@ -16,10 +18,14 @@ Usage:
     kick install
     kick remove
     kick search
+    kick info
     kick update
     kick setup
     kick init
     kick repo
+    kick outdated
+    kick upgrade
+    kick project
 
 Options:
     -h --help     print help
@@ -28,10 +34,23 @@ Options:
     install       install a template
     remove        remove an installed template
     search        search repositories for available templates
+    info          show information on a template before installing it
     update        update local repository information 
     setup         setup configuration
     init          initialize a template or repository
     repo          tool to build a repository
+    outdated      list templates pinned to an outdated version
+    upgrade       upgrade the version a template is pinned to
+    project       update a project from a newer version of its template
+
+Global options:
+    --offline     serve templates and repositories from local clones only.
+                  Also set with KICK_OFFLINE=true
+    --refresh     fetch templates even if they were fetched within KICK_FETCH_TTL
+                  (default 1h)
+    --output=<format>
+                  output format of listings. One of table, json, yaml or tsv
+                  (default table)
 `
 
 //
@@ -40,15 +59,50 @@ Options:
 
 // OptMain holds all parsed options from GetOptMain.
 type OptMain struct {
-	Start   bool `docopt:"start"`
-	Setup   bool `docopt:"setup"`
-	Install bool `docopt:"install"`
-	List    bool `docopt:"list"`
-	Remove  bool `docopt:"remove"`
-	Search  bool `docopt:"search"`
-	Update  bool `docopt:"update"`
-	Init    bool `docopt:"init"`
-	Repo    bool `docopt:"repo"`
+	Start    bool `docopt:"start"`
+	Setup    bool `docopt:"setup"`
+	Install  bool `docopt:"install"`
+	List     bool `docopt:"list"`
+	Remove   bool `docopt:"remove"`
+	Search   bool `docopt:"search"`
+	Info     bool `docopt:"info"`
+	Update   bool `docopt:"update"`
+	Init     bool `docopt:"init"`
+	Repo     bool `docopt:"repo"`
+	Outdated bool `docopt:"outdated"`
+	Upgrade  bool `docopt:"upgrade"`
+	Project  bool `docopt:"project"`
+}
+
+// OptGlobal options accepted with any sub command
+type OptGlobal struct {
+	Offline bool   // --offline
+	Refresh bool   // --refresh
+	Output  string // --output=<format> or --output <format>
+}
+
+// GetOptGlobal removes the global options from args and returns the remaining
+// arguments and the global options.
+func GetOptGlobal(args []string) ([]string, *OptGlobal) {
+	o := &OptGlobal{}
+	filtered := []string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--offline":
+			o.Offline = true
+		case arg == "--refresh":
+			o.Refresh = true
+		case arg == "--output" && i+1 < len(args):
+			i++
+			o.Output = args[i]
+		case strings.HasPrefix(arg, "--output="):
+			o.Output = strings.TrimPrefix(arg, "--output=")
+		default:
+			filtered = append(filtered, arg)
+		}
+	}
+	return filtered, o
 }
 
 // GetOptMain is a command line option parser that uses docopts-go to parse a
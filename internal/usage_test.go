@@ -25,3 +25,15 @@ func TestGetOptMainInstall(t *testing.T) {
 	assert.False(t, o.Start)
 	assert.False(t, o.List)
 }
+
+func TestGetOptGlobal(t *testing.T) {
+	args, o := GetOptGlobal([]string{"kick", "--offline", "start", "--refresh", "handle", "project"})
+	assert.Equal(t, []string{"kick", "start", "handle", "project"}, args)
+	assert.True(t, o.Offline)
+	assert.True(t, o.Refresh)
+	assert.Equal(t, "", o.Output)
+
+	args, o = GetOptGlobal([]string{"kick", "--output", "json", "search", "--output=yaml", "go"})
+	assert.Equal(t, []string{"kick", "search", "go"}, args)
+	assert.Equal(t, "yaml", o.Output)
+}
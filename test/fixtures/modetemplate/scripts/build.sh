@@ -0,0 +1,3 @@
+#!/bin/sh
+# kick:render
+echo ${PROJECT_NAME}
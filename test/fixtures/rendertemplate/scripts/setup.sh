@@ -0,0 +1,6 @@
+#!/bin/sh
+# Copyright
+# License
+# More license
+# Even more license
+echo ${PROJECT_NAME}